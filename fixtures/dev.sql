@@ -0,0 +1,34 @@
+-- 개발용 샘플 데이터, 마이그레이션 후에 적용
+-- cockroach sql --insecure -d sodam < fixtures/dev.sql
+
+INSERT INTO users (id, email, username, admin)
+VALUES
+	(1, 'john@example.org', 'john', true),
+	(2, 'jane@example.org', 'jane', false)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO posts (id, user_id, content, comments_count)
+VALUES (1, 1, 'sample post', 1)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO timeline (id, user_id, post_id)
+VALUES (1, 1, 1)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO comments (id, user_id, post_id, content)
+VALUES (1, 1, 1, 'sample comment')
+ON CONFLICT DO NOTHING;
+
+-- id를 직접 넣으면 postgres 시퀀스가 그대로라 다음 INSERT가 부딪힘
+-- cockroach의 기본 SERIAL은 시퀀스가 없어서 건너뜀
+SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT max(id) FROM users))
+WHERE pg_get_serial_sequence('users', 'id') IS NOT NULL;
+
+SELECT setval(pg_get_serial_sequence('posts', 'id'), (SELECT max(id) FROM posts))
+WHERE pg_get_serial_sequence('posts', 'id') IS NOT NULL;
+
+SELECT setval(pg_get_serial_sequence('timeline', 'id'), (SELECT max(id) FROM timeline))
+WHERE pg_get_serial_sequence('timeline', 'id') IS NOT NULL;
+
+SELECT setval(pg_get_serial_sequence('comments', 'id'), (SELECT max(id) FROM comments))
+WHERE pg_get_serial_sequence('comments', 'id') IS NOT NULL;
@@ -1,10 +1,12 @@
 module sodam
 
-go 1.13
+go 1.16
 
 require (
 	github.com/disintegration/imaging v1.6.2
 	github.com/gofrs/uuid v3.2.0+incompatible // indirect
+	github.com/graph-gophers/dataloader v5.0.0+incompatible
+	github.com/graph-gophers/graphql-go v1.5.0
 	github.com/hako/branca v0.0.0-20191227164554-3b9970524189
 	github.com/jackc/fake v0.0.0-20150926172116-812a484cc733 // indirect
 	github.com/jackc/pgconn v1.3.2
@@ -12,5 +14,8 @@ require (
 	github.com/lib/pq v1.2.0
 	github.com/matoous/go-nanoid v1.2.0
 	github.com/matryer/way v0.0.0-20180416093233-9632d0c407b0
-	github.com/sanity-io/litter v1.2.0
+	golang.org/x/image v0.18.0
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1
+	google.golang.org/grpc v1.56.3
+	google.golang.org/protobuf v1.30.0
 )
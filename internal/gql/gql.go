@@ -0,0 +1,230 @@
+// Package gql serves the GraphQL API on top of service.Service.
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"sodam/internal/service"
+)
+
+const (
+	// GraphQL 요청 본문의 최대 크기
+	maxBodyBytes = 1 << 16 //64KB
+	// 중첩 깊이 제한 (timeline { nodes { post { repostOf { user { avatar { variants } } } } } })
+	maxDepth = 10
+	// 요청 하나에서 동시에 실행하는 리졸버 수
+	maxParallelism = 10
+)
+
+type handler struct {
+	s      *service.Service
+	schema *graphql.Schema
+	// GET과 SSE용, mutation 없음
+	queries *graphql.Schema
+	// SSE 요청 검증용, subscription만 통과
+	subscriptions *graphql.Schema
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type limitKey struct{}
+
+// WithLimit makes every mutation field call limit with the REST route it stands
+// for, like "POST /posts", so GraphQL shares the per-route rate limits.
+// A non-nil error fails that field.
+func WithLimit(ctx context.Context, limit func(route string) error) context.Context {
+	return context.WithValue(ctx, limitKey{}, limit)
+}
+
+// 뮤테이션마다 REST 라우트의 요청 제한을 같이 씀 (alias로 여러 번 불러도 각각)
+func takeLimit(ctx context.Context, route string) error {
+	limit, ok := ctx.Value(limitKey{}).(func(string) error)
+	if !ok {
+		return nil
+	}
+	return limit(route)
+}
+
+// New creates the GraphQL http.Handler.
+// Subscriptions are served as server-sent events when the client accepts text/event-stream,
+// other operations are rejected with 400 then. Mutations are only accepted over POST.
+func New(s *service.Service) http.Handler {
+	opts := []graphql.SchemaOpt{graphql.MaxDepth(maxDepth), graphql.MaxParallelism(maxParallelism)}
+	return &handler{
+		s:             s,
+		schema:        graphql.MustParseSchema(schema, &resolver{s: s}, opts...),
+		queries:       graphql.MustParseSchema(strings.Replace(schema, "\tmutation: Mutation\n", "", 1), &resolver{s: s}, opts...),
+		subscriptions: graphql.MustParseSchema(subscriptionSchema, nil, graphql.MaxDepth(maxDepth)),
+	}
+}
+
+// 검증만 하는 스키마라 리졸버 없음, query와 mutation의 루트에는 고를 필드가 없음
+var subscriptionSchema = strings.Replace(schema, "\tquery: Query\n\tmutation: Mutation\n", "\tquery: NoOperation\n\tmutation: NoOperation\n", 1) + `
+type NoOperation {
+	_unused: Boolean
+}
+`
+
+// AcceptsEventStream reports whether the Accept header of r lists text/event-stream,
+// so a GraphQL request is answered with server-sent events.
+func AcceptsEventStream(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			mediatype, params, err := mime.ParseMediaType(part)
+			if err != nil || mediatype != "text/event-stream" {
+				continue
+			}
+
+			// q=0은 받지 않겠다는 뜻
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	sch := h.schema
+	if r.Method == http.MethodGet {
+		// GET은 캐시되거나 링크로 보내질 수 있어서 조회만
+		sch = h.queries
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				respondError(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		defer r.Body.Close()
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := withLoaders(r.Context(), h.s)
+	if AcceptsEventStream(r) {
+		// graphql-go가 파싱한 문서로 작업 종류를 확인, 쿼리와 뮤테이션은 구독 스키마 검증에서 걸림
+		if errs := h.subscriptions.ValidateWithVariables(req.Query, req.Variables); len(errs) != 0 {
+			if errs = h.queries.ValidateWithVariables(req.Query, req.Variables); len(errs) == 0 {
+				respondError(w, "only subscriptions are served as text/event-stream", http.StatusBadRequest)
+				return
+			}
+
+			respondErrors(w, errs, http.StatusBadRequest)
+			return
+		}
+
+		h.subscribe(w, r.WithContext(ctx), req)
+		return
+	}
+
+	res := sch.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	maskErrors(res.Errors)
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("could not marshal graphql response: %v\n", err)
+		respondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(b)
+}
+
+// 구독 결과를 SSE로 보냄, 구독이 끝나면 complete 이벤트
+// Subscribe는 쿼리와 뮤테이션도 실행하므로 혹시 몰라 뮤테이션 없는 스키마로
+// (SSE 요청은 idempotency 미들웨어도 거치지 않음)
+func (h *handler) subscribe(w http.ResponseWriter, r *http.Request, req request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	c, err := h.queries.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		log.Printf("could not subscribe: %v\n", err)
+		respondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	for v := range c {
+		res, ok := v.(*graphql.Response)
+		if !ok {
+			continue
+		}
+
+		maskErrors(res.Errors)
+		b, err := json.Marshal(res)
+		if err != nil {
+			log.Printf("could not marshal graphql subscription response: %v\n", err)
+			continue
+		}
+
+		fmt.Fprintf(w, "event: next\ndata: %s\n\n", b)
+		f.Flush()
+	}
+
+	fmt.Fprint(w, "event: complete\ndata:\n\n")
+	f.Flush()
+}
+
+// 요청 자체가 잘못됐을 때도 GraphQL 응답 형식으로
+func respondError(w http.ResponseWriter, message string, statusCode int) {
+	respondErrors(w, []*gqlerrors.QueryError{{Message: message}}, statusCode)
+}
+
+func respondErrors(w http.ResponseWriter, errs []*gqlerrors.QueryError, statusCode int) {
+	b, _ := json.Marshal(graphql.Response{Errors: errs})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write(b)
+}
+
+// 서비스 에러가 아니면 REST처럼 내용을 숨기고 로그만 남김
+func maskErrors(errs []*gqlerrors.QueryError) {
+	for _, err := range errs {
+		if err.ResolverError == nil {
+			continue
+		}
+
+		var e *service.Error
+		if errors.As(err.ResolverError, &e) {
+			continue
+		}
+
+		log.Println(err.ResolverError)
+		err.Message = "internal server error"
+		err.Extensions = map[string]interface{}{"code": "internal"}
+	}
+}
@@ -0,0 +1,78 @@
+package gql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hako/branca"
+
+	"sodam/internal/service"
+)
+
+func TestAcceptsEventStream(t *testing.T) {
+	tests := []struct {
+		accept []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"application/json"}, false},
+		{[]string{"text/event-stream"}, true},
+		{[]string{"Text/Event-Stream; charset=utf-8"}, true},
+		{[]string{"application/json, text/event-stream;q=0.9"}, true},
+		{[]string{"application/json", "text/event-stream"}, true},
+		{[]string{"text/event-stream;q=0"}, false},
+		{[]string{"text/event-stream-ish"}, false},
+		{[]string{"*/*"}, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/graphql", nil)
+		for _, v := range tt.accept {
+			r.Header.Add("Accept", v)
+		}
+
+		if got := AcceptsEventStream(r); got != tt.want {
+			t.Errorf("AcceptsEventStream(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestEventStreamOperations(t *testing.T) {
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	blobs := service.NewLocalBlobStore(t.TempDir(), "http://localhost:3000/img")
+	h := New(service.New(service.NewMemoryStore(), codec, "http://localhost:3000", blobs))
+
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		status        int
+		contentType   string
+	}{
+		// 로그인하지 않은 구독은 에러 하나를 보내고 끝남
+		{"subscription", `subscription { notification { id } }`, "", http.StatusOK, "text/event-stream"},
+		{"named subscription", `subscription S { notification { id } }`, "S", http.StatusOK, "text/event-stream"},
+		{"subscription with fragment", `subscription { ...F } fragment F on Subscription { notification { id } }`, "", http.StatusOK, "text/event-stream"},
+		{"commented keyword", "# subscription\nquery { authUser { username } }", "", http.StatusBadRequest, "application/json"},
+		{"string keyword", `query Q($s: String = "subscription {") { users(search: $s) { nodes { username } } }`, "", http.StatusBadRequest, "application/json"},
+		{"shorthand query", `{ authUser { username } }`, "", http.StatusBadRequest, "application/json"},
+		{"mutation", `mutation { markNotificationsAsRead }`, "", http.StatusBadRequest, "application/json"},
+		{"mixed", `subscription S { notification { id } } mutation M { markNotificationsAsRead }`, "S", http.StatusBadRequest, "application/json"},
+		{"invalid", `subscription { notification(x: "unterminated`, "", http.StatusBadRequest, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"query": ` + strconv.Quote(tt.query) + `, "operationName": "` + tt.operationName + `"}`
+			r := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+			r.Header.Set("Accept", "application/json, text/event-stream")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.status || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("status = %d %s, want %d %s: %s", w.Code, w.Header().Get("Content-Type"), tt.status, tt.contentType, w.Body)
+			}
+		})
+	}
+}
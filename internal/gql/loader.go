@@ -0,0 +1,154 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/graph-gophers/dataloader"
+
+	"sodam/internal/service"
+)
+
+type loadersKey struct{}
+
+// 요청마다 만드는 로더, 같은 요청 안에서 여러 필드가 부른 아이디를 모아서 한 번에 조회
+type loaders struct {
+	users    *dataloader.Loader
+	posts    *dataloader.Loader
+	comments *dataloader.Loader
+}
+
+func withLoaders(ctx context.Context, s *service.Service) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		users:    dataloader.NewBatchedLoader(usersBatch(s)),
+		posts:    dataloader.NewBatchedLoader(postsBatch(s)),
+		comments: dataloader.NewBatchedLoader(commentsBatch(s)),
+	})
+}
+
+func loadUser(ctx context.Context, id int64) (service.User, error) {
+	v, err := ctx.Value(loadersKey{}).(*loaders).users.Load(ctx, idKey(id))()
+	if err != nil {
+		return service.User{}, err
+	}
+	return v.(service.User), nil
+}
+
+func loadPost(ctx context.Context, id int64) (service.Post, error) {
+	v, err := ctx.Value(loadersKey{}).(*loaders).posts.Load(ctx, idKey(id))()
+	if err != nil {
+		return service.Post{}, err
+	}
+	return v.(service.Post), nil
+}
+
+// 게시물의 댓글 페이지, 같은 last, before로 부른 게시물끼리 묶어서 조회
+func loadComments(ctx context.Context, postID int64, last int, before int64) ([]service.Comment, error) {
+	key := commentsKey{postID: postID, last: last, before: before}
+	v, err := ctx.Value(loadersKey{}).(*loaders).comments.Load(ctx, key)()
+	if err != nil {
+		return nil, err
+	}
+	return v.([]service.Comment), nil
+}
+
+type commentsKey struct {
+	postID int64
+	last   int
+	before int64
+}
+
+func (k commentsKey) String() string   { return fmt.Sprintf("%d:%d:%d", k.postID, k.last, k.before) }
+func (k commentsKey) Raw() interface{} { return k }
+
+type commentsPage struct {
+	last   int
+	before int64
+}
+
+func usersBatch(s *service.Service) dataloader.BatchFunc {
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		uu, err := s.UsersByIDs(ctx, keyIDs(keys))
+		return batchResults(keys, err, func(id int64) (interface{}, bool) {
+			u, ok := uu[id]
+			return u, ok
+		}, service.ErrUserNotFound)
+	}
+}
+
+func postsBatch(s *service.Service) dataloader.BatchFunc {
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		pp, err := s.PostsByIDs(ctx, keyIDs(keys))
+		return batchResults(keys, err, func(id int64) (interface{}, bool) {
+			p, ok := pp[id]
+			return p, ok
+		}, service.ErrPostNotFound)
+	}
+}
+
+func commentsBatch(s *service.Service) dataloader.BatchFunc {
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		pages := map[commentsPage][]int64{}
+		for _, key := range keys {
+			k := key.(commentsKey)
+			page := commentsPage{last: k.last, before: k.before}
+			pages[page] = append(pages[page], k.postID)
+		}
+
+		loaded := map[commentsPage]map[int64][]service.Comment{}
+		errs := map[commentsPage]error{}
+		for page, postIDs := range pages {
+			loaded[page], errs[page] = s.PostsComments(ctx, postIDs, page.last, page.before)
+		}
+
+		results := make([]*dataloader.Result, len(keys))
+		for i, key := range keys {
+			k := key.(commentsKey)
+			page := commentsPage{last: k.last, before: k.before}
+			if err := errs[page]; err != nil {
+				results[i] = &dataloader.Result{Error: err}
+				continue
+			}
+
+			cc := loaded[page][k.postID]
+			if cc == nil {
+				cc = []service.Comment{}
+			}
+			results[i] = &dataloader.Result{Data: cc}
+		}
+		return results
+	}
+}
+
+// 키 순서대로 결과를 맞춰줌
+func batchResults(keys dataloader.Keys, err error, get func(int64) (interface{}, bool), notFound error) []*dataloader.Result {
+	results := make([]*dataloader.Result, len(keys))
+	for i, key := range keys {
+		if err != nil {
+			results[i] = &dataloader.Result{Error: err}
+			continue
+		}
+
+		id, _ := strconv.ParseInt(key.String(), 10, 64)
+		if v, ok := get(id); ok {
+			results[i] = &dataloader.Result{Data: v}
+		} else {
+			results[i] = &dataloader.Result{Error: notFound}
+		}
+	}
+	return results
+}
+
+func idKey(id int64) dataloader.Key {
+	return dataloader.StringKey(strconv.FormatInt(id, 10))
+}
+
+func keyIDs(keys dataloader.Keys) []int64 {
+	ids := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		id, _ := strconv.ParseInt(key.String(), 10, 64)
+		ids = append(ids, id)
+	}
+	return ids
+}
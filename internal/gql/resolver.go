@@ -0,0 +1,404 @@
+package gql
+
+import (
+	"context"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"sodam/internal/service"
+)
+
+// 루트 리졸버, 쿼리와 뮤테이션은 서비스 메서드를 그대로 호출
+type resolver struct {
+	s *service.Service
+}
+
+func (r *resolver) AuthUser(ctx context.Context) (*userResolver, error) {
+	u, err := r.s.AuthUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newUser(&u), nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ Username string }) (*userProfileResolver, error) {
+	u, err := r.s.User(ctx, args.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &userProfileResolver{s: r.s, u: u}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Search *string
+	pageAfterArgs
+}) (*userProfileConnection, error) {
+	uu, err := r.s.Users(ctx, stringValue(args.Search), intValue(args.First), stringValue(args.After))
+	if err != nil {
+		return nil, err
+	}
+	return &userProfileConnection{s: r.s, uu: uu, size: intValue(args.First)}, nil
+}
+
+func (r *resolver) Post(ctx context.Context, args struct{ ID graphql.ID }) (*postResolver, error) {
+	postID, err := parseID(args.ID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := r.s.Post(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return &postResolver{s: r.s, p: p}, nil
+}
+
+func (r *resolver) Timeline(ctx context.Context, args pageBeforeArgs) (*timelineItemConnection, error) {
+	before, err := parseOptionalID(args.Before, "before")
+	if err != nil {
+		return nil, err
+	}
+
+	tt, err := r.s.Timeline(ctx, intValue(args.Last), before)
+	if err != nil {
+		return nil, err
+	}
+	return &timelineItemConnection{s: r.s, tt: tt, size: intValue(args.Last)}, nil
+}
+
+func (r *resolver) Notifications(ctx context.Context, args pageBeforeArgs) (*notificationConnection, error) {
+	before, err := parseOptionalID(args.Before, "before")
+	if err != nil {
+		return nil, err
+	}
+
+	nn, err := r.s.Notifications(ctx, intValue(args.Last), before)
+	if err != nil {
+		return nil, err
+	}
+	return &notificationConnection{s: r.s, nn: nn, size: intValue(args.Last)}, nil
+}
+
+func (r *resolver) FollowRequests(ctx context.Context, args pageAfterArgs) (*userProfileConnection, error) {
+	uu, err := r.s.FollowRequests(ctx, intValue(args.First), stringValue(args.After))
+	if err != nil {
+		return nil, err
+	}
+	return &userProfileConnection{s: r.s, uu: uu, size: intValue(args.First)}, nil
+}
+
+func (r *resolver) Reports(ctx context.Context, args struct {
+	Status *string
+	pageBeforeArgs
+}) (*reportConnection, error) {
+	before, err := parseOptionalID(args.Before, "before")
+	if err != nil {
+		return nil, err
+	}
+
+	rr, err := r.s.Reports(ctx, stringValue(args.Status), intValue(args.Last), before)
+	if err != nil {
+		return nil, err
+	}
+	return &reportConnection{rr: rr, size: intValue(args.Last)}, nil
+}
+
+func (r *resolver) SetPrivate(ctx context.Context, args struct{ Private bool }) (bool, error) {
+	if err := takeLimit(ctx, "PUT /auth_user/private"); err != nil {
+		return false, err
+	}
+
+	return ok(r.s.SetPrivate(ctx, args.Private))
+}
+
+func (r *resolver) ApproveFollowRequest(ctx context.Context, args struct{ Username string }) (bool, error) {
+	if err := takeLimit(ctx, "POST /auth_user/follow_requests/:username/approve"); err != nil {
+		return false, err
+	}
+
+	return ok(r.s.ApproveFollowRequest(ctx, args.Username))
+}
+
+func (r *resolver) DenyFollowRequest(ctx context.Context, args struct{ Username string }) (bool, error) {
+	if err := takeLimit(ctx, "POST /auth_user/follow_requests/:username/deny"); err != nil {
+		return false, err
+	}
+
+	return ok(r.s.DenyFollowRequest(ctx, args.Username))
+}
+
+func (r *resolver) ToggleFollow(ctx context.Context, args struct{ Username string }) (*toggleFollowPayloadResolver, error) {
+	if err := takeLimit(ctx, "POST /users/:username/toggle_follow"); err != nil {
+		return nil, err
+	}
+
+	out, err := r.s.ToggleFollow(ctx, args.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &toggleFollowPayloadResolver{out}, nil
+}
+
+func (r *resolver) ToggleBlock(ctx context.Context, args struct{ Username string }) (*toggleBlockPayloadResolver, error) {
+	if err := takeLimit(ctx, "POST /users/:username/toggle_block"); err != nil {
+		return nil, err
+	}
+
+	out, err := r.s.ToggleBlock(ctx, args.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &toggleBlockPayloadResolver{out}, nil
+}
+
+func (r *resolver) ToggleMute(ctx context.Context, args struct{ Username string }) (*toggleMutePayloadResolver, error) {
+	if err := takeLimit(ctx, "POST /users/:username/toggle_mute"); err != nil {
+		return nil, err
+	}
+
+	out, err := r.s.ToggleMute(ctx, args.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &toggleMutePayloadResolver{out}, nil
+}
+
+// 이미지 첨부는 REST(multipart)로만 가능
+func (r *resolver) CreatePost(ctx context.Context, args struct {
+	Content   string
+	SpoilerOf *string
+	NSFW      *bool
+}) (*timelineItemResolver, error) {
+	if err := takeLimit(ctx, "POST /posts"); err != nil {
+		return nil, err
+	}
+
+	nsfw := args.NSFW != nil && *args.NSFW
+	ti, err := r.s.CreatePost(ctx, args.Content, args.SpoilerOf, nsfw, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &timelineItemResolver{s: r.s, ti: ti}, nil
+}
+
+func (r *resolver) TogglePostLike(ctx context.Context, args struct{ PostID graphql.ID }) (*toggleLikePayloadResolver, error) {
+	if err := takeLimit(ctx, "POST /posts/:post_id/toggle_like"); err != nil {
+		return nil, err
+	}
+
+	postID, err := parseID(args.PostID, "postID")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := r.s.TogglePostLike(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return &toggleLikePayloadResolver{out}, nil
+}
+
+func (r *resolver) Repost(ctx context.Context, args struct {
+	PostID  graphql.ID
+	Content *string
+}) (*timelineItemResolver, error) {
+	if err := takeLimit(ctx, "POST /posts/:post_id/repost"); err != nil {
+		return nil, err
+	}
+
+	postID, err := parseID(args.PostID, "postID")
+	if err != nil {
+		return nil, err
+	}
+
+	ti, err := r.s.Repost(ctx, postID, stringValue(args.Content))
+	if err != nil {
+		return nil, err
+	}
+	return &timelineItemResolver{s: r.s, ti: ti}, nil
+}
+
+func (r *resolver) CreateComment(ctx context.Context, args struct {
+	PostID   graphql.ID
+	Content  string
+	ParentID *graphql.ID
+}) (*commentResolver, error) {
+	if err := takeLimit(ctx, "POST /posts/:post_id/comments"); err != nil {
+		return nil, err
+	}
+
+	postID, err := parseID(args.PostID, "postID")
+	if err != nil {
+		return nil, err
+	}
+
+	parentID, err := parseOptionalIDPtr(args.ParentID, "parentID")
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := r.s.CreateComment(ctx, postID, args.Content, parentID)
+	if err != nil {
+		return nil, err
+	}
+	return &commentResolver{s: r.s, c: c}, nil
+}
+
+func (r *resolver) DeleteComment(ctx context.Context, args struct{ CommentID graphql.ID }) (bool, error) {
+	if err := takeLimit(ctx, "DELETE /comments/:comment_id"); err != nil {
+		return false, err
+	}
+
+	commentID, err := parseID(args.CommentID, "commentID")
+	if err != nil {
+		return false, err
+	}
+	return ok(r.s.DeleteComment(ctx, commentID))
+}
+
+func (r *resolver) ToggleCommentLike(ctx context.Context, args struct{ CommentID graphql.ID }) (*toggleLikePayloadResolver, error) {
+	if err := takeLimit(ctx, "POST /comments/:comment_id/toggle_like"); err != nil {
+		return nil, err
+	}
+
+	commentID, err := parseID(args.CommentID, "commentID")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := r.s.ToggleCommentLike(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	return &toggleLikePayloadResolver{out}, nil
+}
+
+func (r *resolver) MarkNotificationAsRead(ctx context.Context, args struct{ NotificationID graphql.ID }) (bool, error) {
+	if err := takeLimit(ctx, "POST /notifications/:notification_id/mark_as_read"); err != nil {
+		return false, err
+	}
+
+	notificationID, err := parseID(args.NotificationID, "notificationID")
+	if err != nil {
+		return false, err
+	}
+	return ok(r.s.MarkNotificationAsRead(ctx, notificationID))
+}
+
+func (r *resolver) MarkNotificationsAsRead(ctx context.Context) (bool, error) {
+	if err := takeLimit(ctx, "POST /mark_notifications_as_read"); err != nil {
+		return false, err
+	}
+
+	return ok(r.s.MarkNotificationsAsRead(ctx))
+}
+
+func (r *resolver) CreateReport(ctx context.Context, args struct {
+	PostID    *graphql.ID
+	CommentID *graphql.ID
+	Username  *string
+	ReviewID  *graphql.ID
+	Reason    string
+	Note      *string
+}) (*reportResolver, error) {
+	if err := takeLimit(ctx, "POST /reports"); err != nil {
+		return nil, err
+	}
+
+	postID, err := parseOptionalIDPtr(args.PostID, "postID")
+	if err != nil {
+		return nil, err
+	}
+
+	commentID, err := parseOptionalIDPtr(args.CommentID, "commentID")
+	if err != nil {
+		return nil, err
+	}
+
+	reviewID, err := parseOptionalIDPtr(args.ReviewID, "reviewID")
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := r.s.CreateReport(ctx, service.CreateReportInput{
+		PostID:    postID,
+		CommentID: commentID,
+		Username:  args.Username,
+		ReviewID:  reviewID,
+		Reason:    args.Reason,
+		Note:      stringValue(args.Note),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reportResolver{report}, nil
+}
+
+func (r *resolver) ResolveReport(ctx context.Context, args struct {
+	ReportID graphql.ID
+	Action   string
+	Note     *string
+}) (bool, error) {
+	if err := takeLimit(ctx, "POST /admin/reports/:report_id/resolve"); err != nil {
+		return false, err
+	}
+
+	reportID, err := parseID(args.ReportID, "reportID")
+	if err != nil {
+		return false, err
+	}
+	return ok(r.s.ResolveReport(ctx, reportID, args.Action, stringValue(args.Note)))
+}
+
+// 구독은 연결(ctx)이 끊길 때까지 이벤트를 보냄
+func (r *resolver) TimelineItem(ctx context.Context) (<-chan *timelineItemResolver, error) {
+	tt, err := r.s.SubscribeToTimeline(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := make(chan *timelineItemResolver)
+	go func() {
+		for {
+			select {
+			case ti := <-tt:
+				select {
+				case c <- &timelineItemResolver{s: r.s, ti: ti}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+func (r *resolver) Notification(ctx context.Context) (<-chan *notificationResolver, error) {
+	nn, err := r.s.SubscribeToNotifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := make(chan *notificationResolver)
+	go func() {
+		for {
+			select {
+			case n := <-nn:
+				select {
+				case c <- &notificationResolver{s: r.s, n: n}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+func ok(err error) (bool, error) {
+	return err == nil, err
+}
@@ -0,0 +1,242 @@
+package gql
+
+// GraphQL 스키마, 목록은 REST와 같은 커서 페이지네이션을 connection으로 감쌈
+// 가입과 로그인은 REST에서만 (라우트별 요청 제한)
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+	subscription: Subscription
+}
+
+scalar Time
+
+type Query {
+	authUser: User!
+	user(username: String!): UserProfile!
+	users(search: String, first: Int, after: String): UserProfileConnection!
+	post(id: ID!): Post!
+	timeline(last: Int, before: ID): TimelineItemConnection!
+	notifications(last: Int, before: ID): NotificationConnection!
+	followRequests(first: Int, after: String): UserProfileConnection!
+	reports(status: String, last: Int, before: ID): ReportConnection!
+}
+
+type Mutation {
+	setPrivate(private: Boolean!): Boolean!
+	approveFollowRequest(username: String!): Boolean!
+	denyFollowRequest(username: String!): Boolean!
+	toggleFollow(username: String!): ToggleFollowPayload!
+	toggleBlock(username: String!): ToggleBlockPayload!
+	toggleMute(username: String!): ToggleMutePayload!
+	createPost(content: String!, spoilerOf: String, nsfw: Boolean): TimelineItem!
+	togglePostLike(postID: ID!): ToggleLikePayload!
+	repost(postID: ID!, content: String): TimelineItem!
+	createComment(postID: ID!, content: String!, parentID: ID): Comment!
+	deleteComment(commentID: ID!): Boolean!
+	toggleCommentLike(commentID: ID!): ToggleLikePayload!
+	markNotificationAsRead(notificationID: ID!): Boolean!
+	markNotificationsAsRead: Boolean!
+	createReport(postID: ID, commentID: ID, username: String, reviewID: ID, reason: String!, note: String): Report!
+	resolveReport(reportID: ID!, action: String!, note: String): Boolean!
+}
+
+type Subscription {
+	timelineItem: TimelineItem!
+	notification: Notification!
+}
+
+type PageInfo {
+	endCursor: String
+	hasNextPage: Boolean!
+}
+
+type AvatarVariant {
+	size: Int!
+	url: String!
+}
+
+type Avatar {
+	url: String!
+	srcset: String!
+	variants: [AvatarVariant!]!
+}
+
+type User {
+	username: String!
+	avatar: Avatar
+}
+
+type UserProfile {
+	username: String!
+	avatar: Avatar
+	email: String
+	followersCount: Int!
+	followeesCount: Int!
+	me: Boolean!
+	following: Boolean!
+	followeed: Boolean!
+	private: Boolean!
+	requested: Boolean!
+	posts(last: Int, before: ID): PostConnection!
+	followers(first: Int, after: String): UserProfileConnection!
+	followees(first: Int, after: String): UserProfileConnection!
+}
+
+type UserProfileEdge {
+	cursor: String!
+	node: UserProfile!
+}
+
+type UserProfileConnection {
+	edges: [UserProfileEdge!]!
+	nodes: [UserProfile!]!
+	pageInfo: PageInfo!
+}
+
+type ToggleFollowPayload {
+	following: Boolean!
+	requested: Boolean!
+	followersCount: Int!
+}
+
+type ToggleBlockPayload {
+	blocked: Boolean!
+}
+
+type ToggleMutePayload {
+	muted: Boolean!
+}
+
+type ToggleLikePayload {
+	liked: Boolean!
+	likesCount: Int!
+}
+
+type MediaRendition {
+	url: String!
+	width: Int!
+	height: Int!
+}
+
+type Media {
+	thumbnail: MediaRendition!
+	medium: MediaRendition!
+	original: MediaRendition!
+}
+
+type Post {
+	id: ID!
+	content: String!
+	spoilerOf: String
+	nsfw: Boolean!
+	likesCount: Int!
+	commentsCount: Int!
+	repostsCount: Int!
+	createdAt: Time!
+	user: User!
+	mine: Boolean!
+	liked: Boolean!
+	held: Boolean!
+	media: [Media!]!
+	repostOf: Post
+	comments(last: Int, before: ID): CommentConnection!
+}
+
+type PostEdge {
+	cursor: String!
+	node: Post!
+}
+
+type PostConnection {
+	edges: [PostEdge!]!
+	nodes: [Post!]!
+	pageInfo: PageInfo!
+}
+
+type TimelineItem {
+	id: ID!
+	post: Post!
+	repostedBy: User
+}
+
+type TimelineItemEdge {
+	cursor: String!
+	node: TimelineItem!
+}
+
+type TimelineItemConnection {
+	edges: [TimelineItemEdge!]!
+	nodes: [TimelineItem!]!
+	pageInfo: PageInfo!
+}
+
+type Comment {
+	id: ID!
+	parentID: ID
+	content: String!
+	likesCount: Int!
+	repliesCount: Int!
+	deleted: Boolean!
+	createdAt: Time!
+	user: User
+	mine: Boolean!
+	liked: Boolean!
+	held: Boolean!
+	replies(last: Int, before: ID): CommentConnection!
+}
+
+type CommentEdge {
+	cursor: String!
+	node: Comment!
+}
+
+type CommentConnection {
+	edges: [CommentEdge!]!
+	nodes: [Comment!]!
+	pageInfo: PageInfo!
+}
+
+type Notification {
+	id: ID!
+	actors: [String!]!
+	type: String!
+	post: Post
+	read: Boolean!
+	issuedAt: Time!
+}
+
+type NotificationEdge {
+	cursor: String!
+	node: Notification!
+}
+
+type NotificationConnection {
+	edges: [NotificationEdge!]!
+	nodes: [Notification!]!
+	pageInfo: PageInfo!
+}
+
+type Report {
+	id: ID!
+	targetType: String!
+	targetID: ID!
+	reason: String!
+	note: String
+	status: String!
+	createdAt: Time!
+	resolvedAt: Time
+	reporter: User
+}
+
+type ReportEdge {
+	cursor: String!
+	node: Report!
+}
+
+type ReportConnection {
+	edges: [ReportEdge!]!
+	nodes: [Report!]!
+	pageInfo: PageInfo!
+}
+`
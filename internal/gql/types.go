@@ -0,0 +1,611 @@
+package gql
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"sodam/internal/service"
+)
+
+// 커서와 페이지 크기로 다음 페이지가 있는지 짐작 (꽉 찬 페이지면 더 있다고 봄)
+type pageInfo struct {
+	endCursor *string
+	hasNext   bool
+}
+
+func newPageInfo(cursors []string, size int) *pageInfo {
+	p := &pageInfo{hasNext: len(cursors) != 0 && len(cursors) >= service.PageSize(size)}
+	if len(cursors) != 0 {
+		p.endCursor = &cursors[len(cursors)-1]
+	}
+	return p
+}
+
+func (p *pageInfo) EndCursor() *string { return p.endCursor }
+func (p *pageInfo) HasNextPage() bool  { return p.hasNext }
+
+func idCursor(id int64) string { return strconv.FormatInt(id, 10) }
+
+func toID(id int64) graphql.ID { return graphql.ID(strconv.FormatInt(id, 10)) }
+
+// 잘못된 아이디는 REST와 같은 400 에러
+func parseID(id graphql.ID, field string) (int64, error) {
+	i, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || i <= 0 {
+		return 0, &service.Error{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_param",
+			Message: field + " must be a positive integer",
+			Field:   field,
+		}
+	}
+	return i, nil
+}
+
+func parseOptionalID(id *graphql.ID, field string) (int64, error) {
+	if id == nil {
+		return 0, nil
+	}
+	return parseID(*id, field)
+}
+
+func parseOptionalIDPtr(id *graphql.ID, field string) (*int64, error) {
+	if id == nil {
+		return nil, nil
+	}
+
+	i, err := parseID(*id, field)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func intValue(i *int32) int {
+	if i == nil {
+		return 0
+	}
+	return int(*i)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+type pageBeforeArgs struct {
+	Last   *int32
+	Before *graphql.ID
+}
+
+type pageAfterArgs struct {
+	First *int32
+	After *string
+}
+
+type avatarResolver struct{ a service.Avatar }
+
+func newAvatar(a *service.Avatar) *avatarResolver {
+	if a == nil {
+		return nil
+	}
+	return &avatarResolver{*a}
+}
+
+func (r *avatarResolver) URL() string    { return r.a.URL }
+func (r *avatarResolver) Srcset() string { return r.a.Srcset }
+func (r *avatarResolver) Variants() []*avatarVariantResolver {
+	vv := make([]*avatarVariantResolver, len(r.a.Variants))
+	for i, v := range r.a.Variants {
+		vv[i] = &avatarVariantResolver{v}
+	}
+	return vv
+}
+
+type avatarVariantResolver struct{ v service.AvatarVariant }
+
+func (r *avatarVariantResolver) Size() int32 { return int32(r.v.Size) }
+func (r *avatarVariantResolver) URL() string { return r.v.URL }
+
+type userResolver struct{ u service.User }
+
+func newUser(u *service.User) *userResolver {
+	if u == nil {
+		return nil
+	}
+	return &userResolver{*u}
+}
+
+func (r *userResolver) Username() string        { return r.u.UserName }
+func (r *userResolver) Avatar() *avatarResolver { return newAvatar(r.u.Avatar) }
+
+type userProfileResolver struct {
+	s *service.Service
+	u service.UserProfile
+}
+
+func (r *userProfileResolver) Username() string        { return r.u.UserName }
+func (r *userProfileResolver) Avatar() *avatarResolver { return newAvatar(r.u.Avatar) }
+func (r *userProfileResolver) FollowersCount() int32   { return int32(r.u.FollowersCount) }
+func (r *userProfileResolver) FolloweesCount() int32   { return int32(r.u.FolloweesCount) }
+func (r *userProfileResolver) Me() bool                { return r.u.Me }
+func (r *userProfileResolver) Following() bool         { return r.u.Following }
+func (r *userProfileResolver) Followeed() bool         { return r.u.Followeed }
+func (r *userProfileResolver) Private() bool           { return r.u.Private }
+func (r *userProfileResolver) Requested() bool         { return r.u.Requested }
+
+func (r *userProfileResolver) Email() *string {
+	if r.u.Email == "" {
+		return nil
+	}
+	return &r.u.Email
+}
+
+func (r *userProfileResolver) Posts(ctx context.Context, args pageBeforeArgs) (*postConnection, error) {
+	before, err := parseOptionalID(args.Before, "before")
+	if err != nil {
+		return nil, err
+	}
+
+	pp, err := r.s.Posts(ctx, r.u.UserName, intValue(args.Last), before)
+	if err != nil {
+		return nil, err
+	}
+	return &postConnection{s: r.s, pp: pp, size: intValue(args.Last)}, nil
+}
+
+func (r *userProfileResolver) Followers(ctx context.Context, args pageAfterArgs) (*userProfileConnection, error) {
+	uu, err := r.s.Followers(ctx, r.u.UserName, intValue(args.First), stringValue(args.After))
+	if err != nil {
+		return nil, err
+	}
+	return &userProfileConnection{s: r.s, uu: uu, size: intValue(args.First)}, nil
+}
+
+func (r *userProfileResolver) Followees(ctx context.Context, args pageAfterArgs) (*userProfileConnection, error) {
+	uu, err := r.s.Followees(ctx, r.u.UserName, intValue(args.First), stringValue(args.After))
+	if err != nil {
+		return nil, err
+	}
+	return &userProfileConnection{s: r.s, uu: uu, size: intValue(args.First)}, nil
+}
+
+type userProfileConnection struct {
+	s    *service.Service
+	uu   []service.UserProfile
+	size int
+}
+
+type userProfileEdge struct {
+	cursor string
+	node   *userProfileResolver
+}
+
+func (e *userProfileEdge) Cursor() string             { return e.cursor }
+func (e *userProfileEdge) Node() *userProfileResolver { return e.node }
+
+func (c *userProfileConnection) Nodes() []*userProfileResolver {
+	nodes := make([]*userProfileResolver, len(c.uu))
+	for i, u := range c.uu {
+		nodes[i] = &userProfileResolver{s: c.s, u: u}
+	}
+	return nodes
+}
+
+func (c *userProfileConnection) Edges() []*userProfileEdge {
+	edges := make([]*userProfileEdge, len(c.uu))
+	for i, node := range c.Nodes() {
+		edges[i] = &userProfileEdge{cursor: node.u.UserName, node: node}
+	}
+	return edges
+}
+
+func (c *userProfileConnection) PageInfo() *pageInfo {
+	cursors := make([]string, len(c.uu))
+	for i, u := range c.uu {
+		cursors[i] = u.UserName
+	}
+	return newPageInfo(cursors, c.size)
+}
+
+type mediaResolver struct{ m service.Media }
+
+func (r *mediaResolver) Thumbnail() *mediaRenditionResolver {
+	return &mediaRenditionResolver{r.m.Thumbnail}
+}
+
+func (r *mediaResolver) Medium() *mediaRenditionResolver {
+	return &mediaRenditionResolver{r.m.Medium}
+}
+
+func (r *mediaResolver) Original() *mediaRenditionResolver {
+	return &mediaRenditionResolver{r.m.Original}
+}
+
+type mediaRenditionResolver struct{ m service.MediaRendition }
+
+func (r *mediaRenditionResolver) URL() string   { return r.m.URL }
+func (r *mediaRenditionResolver) Width() int32  { return int32(r.m.Width) }
+func (r *mediaRenditionResolver) Height() int32 { return int32(r.m.Height) }
+
+type postResolver struct {
+	s *service.Service
+	p service.Post
+}
+
+func (r *postResolver) ID() graphql.ID          { return toID(r.p.ID) }
+func (r *postResolver) Content() string         { return r.p.Content }
+func (r *postResolver) SpoilerOf() *string      { return r.p.SpoilerOf }
+func (r *postResolver) NSFW() bool              { return r.p.NSFW }
+func (r *postResolver) LikesCount() int32       { return int32(r.p.LikesCount) }
+func (r *postResolver) CommentsCount() int32    { return int32(r.p.CommentsCount) }
+func (r *postResolver) RepostsCount() int32     { return int32(r.p.RepostsCount) }
+func (r *postResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.p.CreatedAt} }
+func (r *postResolver) Mine() bool              { return r.p.Mine }
+func (r *postResolver) Liked() bool             { return r.p.Liked }
+func (r *postResolver) Held() bool              { return r.p.Held }
+
+// 목록 쿼리는 작성자를 같이 가져오므로 없을 때만 로더 사용
+func (r *postResolver) User(ctx context.Context) (*userResolver, error) {
+	if r.p.User != nil {
+		return newUser(r.p.User), nil
+	}
+
+	u, err := loadUser(ctx, r.p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return newUser(&u), nil
+}
+
+func (r *postResolver) Media() []*mediaResolver {
+	mm := make([]*mediaResolver, len(r.p.Media))
+	for i, m := range r.p.Media {
+		mm[i] = &mediaResolver{m}
+	}
+	return mm
+}
+
+func (r *postResolver) RepostOf() *postResolver {
+	if r.p.RepostOf == nil {
+		return nil
+	}
+	return &postResolver{s: r.s, p: *r.p.RepostOf}
+}
+
+func (r *postResolver) Comments(ctx context.Context, args pageBeforeArgs) (*commentConnection, error) {
+	before, err := parseOptionalID(args.Before, "before")
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := loadComments(ctx, r.p.ID, intValue(args.Last), before)
+	if err != nil {
+		return nil, err
+	}
+	return &commentConnection{s: r.s, cc: cc, size: intValue(args.Last)}, nil
+}
+
+type postConnection struct {
+	s    *service.Service
+	pp   []service.Post
+	size int
+}
+
+type postEdge struct {
+	cursor string
+	node   *postResolver
+}
+
+func (e *postEdge) Cursor() string      { return e.cursor }
+func (e *postEdge) Node() *postResolver { return e.node }
+
+func (c *postConnection) Nodes() []*postResolver {
+	nodes := make([]*postResolver, len(c.pp))
+	for i, p := range c.pp {
+		nodes[i] = &postResolver{s: c.s, p: p}
+	}
+	return nodes
+}
+
+func (c *postConnection) Edges() []*postEdge {
+	edges := make([]*postEdge, len(c.pp))
+	for i, node := range c.Nodes() {
+		edges[i] = &postEdge{cursor: idCursor(node.p.ID), node: node}
+	}
+	return edges
+}
+
+func (c *postConnection) PageInfo() *pageInfo {
+	cursors := make([]string, len(c.pp))
+	for i, p := range c.pp {
+		cursors[i] = idCursor(p.ID)
+	}
+	return newPageInfo(cursors, c.size)
+}
+
+type timelineItemResolver struct {
+	s  *service.Service
+	ti service.TimelineItem
+}
+
+func (r *timelineItemResolver) ID() graphql.ID            { return toID(r.ti.ID) }
+func (r *timelineItemResolver) Post() *postResolver       { return &postResolver{s: r.s, p: r.ti.Post} }
+func (r *timelineItemResolver) RepostedBy() *userResolver { return newUser(r.ti.RepostedBy) }
+
+type timelineItemConnection struct {
+	s    *service.Service
+	tt   []service.TimelineItem
+	size int
+}
+
+type timelineItemEdge struct {
+	cursor string
+	node   *timelineItemResolver
+}
+
+func (e *timelineItemEdge) Cursor() string              { return e.cursor }
+func (e *timelineItemEdge) Node() *timelineItemResolver { return e.node }
+
+func (c *timelineItemConnection) Nodes() []*timelineItemResolver {
+	nodes := make([]*timelineItemResolver, len(c.tt))
+	for i, ti := range c.tt {
+		nodes[i] = &timelineItemResolver{s: c.s, ti: ti}
+	}
+	return nodes
+}
+
+func (c *timelineItemConnection) Edges() []*timelineItemEdge {
+	edges := make([]*timelineItemEdge, len(c.tt))
+	for i, node := range c.Nodes() {
+		edges[i] = &timelineItemEdge{cursor: idCursor(node.ti.ID), node: node}
+	}
+	return edges
+}
+
+func (c *timelineItemConnection) PageInfo() *pageInfo {
+	cursors := make([]string, len(c.tt))
+	for i, ti := range c.tt {
+		cursors[i] = idCursor(ti.ID)
+	}
+	return newPageInfo(cursors, c.size)
+}
+
+type commentResolver struct {
+	s *service.Service
+	c service.Comment
+}
+
+func (r *commentResolver) ID() graphql.ID          { return toID(r.c.ID) }
+func (r *commentResolver) Content() string         { return r.c.Content }
+func (r *commentResolver) LikesCount() int32       { return int32(r.c.LikesCount) }
+func (r *commentResolver) RepliesCount() int32     { return int32(r.c.RepliesCount) }
+func (r *commentResolver) Deleted() bool           { return r.c.Deleted }
+func (r *commentResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.c.CreatedAt} }
+func (r *commentResolver) Mine() bool              { return r.c.Mine }
+func (r *commentResolver) Liked() bool             { return r.c.Liked }
+func (r *commentResolver) Held() bool              { return r.c.Held }
+
+func (r *commentResolver) ParentID() *graphql.ID {
+	if r.c.ParentID == nil {
+		return nil
+	}
+
+	id := toID(*r.c.ParentID)
+	return &id
+}
+
+// 삭제된 댓글은 작성자가 없음
+func (r *commentResolver) User(ctx context.Context) (*userResolver, error) {
+	if r.c.User != nil || r.c.Deleted || r.c.UserID == 0 {
+		return newUser(r.c.User), nil
+	}
+
+	u, err := loadUser(ctx, r.c.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return newUser(&u), nil
+}
+
+func (r *commentResolver) Replies(ctx context.Context, args pageBeforeArgs) (*commentConnection, error) {
+	before, err := parseOptionalID(args.Before, "before")
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := r.s.Replies(ctx, r.c.ID, intValue(args.Last), before)
+	if err != nil {
+		return nil, err
+	}
+	return &commentConnection{s: r.s, cc: cc, size: intValue(args.Last)}, nil
+}
+
+type commentConnection struct {
+	s    *service.Service
+	cc   []service.Comment
+	size int
+}
+
+type commentEdge struct {
+	cursor string
+	node   *commentResolver
+}
+
+func (e *commentEdge) Cursor() string         { return e.cursor }
+func (e *commentEdge) Node() *commentResolver { return e.node }
+
+func (c *commentConnection) Nodes() []*commentResolver {
+	nodes := make([]*commentResolver, len(c.cc))
+	for i, cm := range c.cc {
+		nodes[i] = &commentResolver{s: c.s, c: cm}
+	}
+	return nodes
+}
+
+func (c *commentConnection) Edges() []*commentEdge {
+	edges := make([]*commentEdge, len(c.cc))
+	for i, node := range c.Nodes() {
+		edges[i] = &commentEdge{cursor: idCursor(node.c.ID), node: node}
+	}
+	return edges
+}
+
+func (c *commentConnection) PageInfo() *pageInfo {
+	cursors := make([]string, len(c.cc))
+	for i, cm := range c.cc {
+		cursors[i] = idCursor(cm.ID)
+	}
+	return newPageInfo(cursors, c.size)
+}
+
+type notificationResolver struct {
+	s *service.Service
+	n service.Notification
+}
+
+func (r *notificationResolver) ID() graphql.ID         { return toID(r.n.ID) }
+func (r *notificationResolver) Actors() []string       { return r.n.Actors }
+func (r *notificationResolver) Type() string           { return r.n.Type }
+func (r *notificationResolver) Read() bool             { return r.n.Read }
+func (r *notificationResolver) IssuedAt() graphql.Time { return graphql.Time{Time: r.n.IssuedAt} }
+
+// 알림 목록의 게시물은 로더로 한 번에 불러옴, 볼 수 없게 된 게시물은 null
+func (r *notificationResolver) Post(ctx context.Context) (*postResolver, error) {
+	if r.n.PostID == nil {
+		return nil, nil
+	}
+
+	p, err := loadPost(ctx, *r.n.PostID)
+	if err == service.ErrPostNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &postResolver{s: r.s, p: p}, nil
+}
+
+type notificationConnection struct {
+	s    *service.Service
+	nn   []service.Notification
+	size int
+}
+
+type notificationEdge struct {
+	cursor string
+	node   *notificationResolver
+}
+
+func (e *notificationEdge) Cursor() string              { return e.cursor }
+func (e *notificationEdge) Node() *notificationResolver { return e.node }
+
+func (c *notificationConnection) Nodes() []*notificationResolver {
+	nodes := make([]*notificationResolver, len(c.nn))
+	for i, n := range c.nn {
+		nodes[i] = &notificationResolver{s: c.s, n: n}
+	}
+	return nodes
+}
+
+func (c *notificationConnection) Edges() []*notificationEdge {
+	edges := make([]*notificationEdge, len(c.nn))
+	for i, node := range c.Nodes() {
+		edges[i] = &notificationEdge{cursor: idCursor(node.n.ID), node: node}
+	}
+	return edges
+}
+
+func (c *notificationConnection) PageInfo() *pageInfo {
+	cursors := make([]string, len(c.nn))
+	for i, n := range c.nn {
+		cursors[i] = idCursor(n.ID)
+	}
+	return newPageInfo(cursors, c.size)
+}
+
+type reportResolver struct{ r service.Report }
+
+func (r *reportResolver) ID() graphql.ID          { return toID(r.r.ID) }
+func (r *reportResolver) TargetType() string      { return r.r.TargetType }
+func (r *reportResolver) TargetID() graphql.ID    { return toID(r.r.TargetID) }
+func (r *reportResolver) Reason() string          { return r.r.Reason }
+func (r *reportResolver) Status() string          { return r.r.Status }
+func (r *reportResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.r.CreatedAt} }
+func (r *reportResolver) Reporter() *userResolver { return newUser(r.r.Reporter) }
+
+func (r *reportResolver) Note() *string {
+	if r.r.Note == "" {
+		return nil
+	}
+	return &r.r.Note
+}
+
+func (r *reportResolver) ResolvedAt() *graphql.Time {
+	if r.r.ResolvedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.r.ResolvedAt}
+}
+
+type reportConnection struct {
+	rr   []service.Report
+	size int
+}
+
+type reportEdge struct {
+	cursor string
+	node   *reportResolver
+}
+
+func (e *reportEdge) Cursor() string        { return e.cursor }
+func (e *reportEdge) Node() *reportResolver { return e.node }
+
+func (c *reportConnection) Nodes() []*reportResolver {
+	nodes := make([]*reportResolver, len(c.rr))
+	for i, r := range c.rr {
+		nodes[i] = &reportResolver{r}
+	}
+	return nodes
+}
+
+func (c *reportConnection) Edges() []*reportEdge {
+	edges := make([]*reportEdge, len(c.rr))
+	for i, node := range c.Nodes() {
+		edges[i] = &reportEdge{cursor: idCursor(node.r.ID), node: node}
+	}
+	return edges
+}
+
+func (c *reportConnection) PageInfo() *pageInfo {
+	cursors := make([]string, len(c.rr))
+	for i, r := range c.rr {
+		cursors[i] = idCursor(r.ID)
+	}
+	return newPageInfo(cursors, c.size)
+}
+
+type toggleFollowPayloadResolver struct{ out service.ToggleFollowOutput }
+
+func (r *toggleFollowPayloadResolver) Following() bool       { return r.out.Following }
+func (r *toggleFollowPayloadResolver) Requested() bool       { return r.out.Requested }
+func (r *toggleFollowPayloadResolver) FollowersCount() int32 { return int32(r.out.FollowersCount) }
+
+type toggleBlockPayloadResolver struct{ out service.ToggleBlockOutput }
+
+func (r *toggleBlockPayloadResolver) Blocked() bool { return r.out.Blocked }
+
+type toggleMutePayloadResolver struct{ out service.ToggleMuteOutput }
+
+func (r *toggleMutePayloadResolver) Muted() bool { return r.out.Muted }
+
+type toggleLikePayloadResolver struct{ out service.ToggleLikeOutput }
+
+func (r *toggleLikePayloadResolver) Liked() bool       { return r.out.Liked }
+func (r *toggleLikePayloadResolver) LikesCount() int32 { return int32(r.out.LikesCount) }
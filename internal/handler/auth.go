@@ -2,34 +2,24 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"sodam/internal/service"
 	"strings"
 )
 
 type loginInput struct {
-	Email string
+	Email string `json:"email"`
 }
 
 func (h *handler) login(w http.ResponseWriter, r *http.Request) {
 	var in loginInput
 	defer r.Body.Close()
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
 		return
 	}
 
 	out, err := h.Login(r.Context(), in.Email)
-	if err == service.ErrInvalidEmail {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
-		return
-	}
-	if err == service.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
 	if err != nil {
 		respondError(w, err)
 		return
@@ -40,16 +30,6 @@ func (h *handler) login(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) authUser(w http.ResponseWriter, r *http.Request) {
 	u, err := h.AuthUser(r.Context())
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-
-	if err == service.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
 	if err != nil {
 		respondError(w, err)
 		return
@@ -68,9 +48,9 @@ func (h *handler) withAuth(next http.Handler) http.Handler {
 		}
 
 		token := a[7:]
-		uid, err := h.AuthUserID(token)
+		uid, err := h.AuthUserID(r.Context(), token)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			respondError(w, err)
 			return
 		}
 
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/matryer/way"
+
+	"sodam/internal/service"
+)
+
+// JSON 요청 본문의 최대 크기
+const maxBodyBytes = 1 << 16 //64KB
+
+var (
+	errInvalidParams = &service.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_params",
+		Message: "invalid params",
+	}
+	errEmptyBody = &service.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "empty_body",
+		Message: "request body is empty",
+	}
+	errBodyTooLarge = &service.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "body_too_large",
+		Message: fmt.Sprintf("request body must not be larger than %d bytes", maxBodyBytes),
+	}
+)
+
+// 경로와 쿼리 파라미터를 타입에 맞게 읽고, 잘못된 값은 모아뒀다가 한 번에 알려줌
+type binder struct {
+	r    *http.Request
+	q    url.Values
+	errs []*service.Error
+}
+
+func bind(r *http.Request) *binder {
+	return &binder{r: r, q: r.URL.Query()}
+}
+
+// pathID reads a positive integer id from the path.
+func (b *binder) pathID(name string) int64 {
+	s := way.Param(b.r.Context(), name)
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i <= 0 {
+		b.fail(name, "must be a positive integer")
+		return 0
+	}
+	return i
+}
+
+// queryInt reads an optional integer from the query, zero when missing.
+func (b *binder) queryInt(name string) int {
+	s := b.q.Get(name)
+	if s == "" {
+		return 0
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		b.fail(name, "must be a non-negative integer")
+		return 0
+	}
+	return i
+}
+
+// queryID reads an optional id cursor from the query, zero when missing.
+func (b *binder) queryID(name string) int64 {
+	s := b.q.Get(name)
+	if s == "" {
+		return 0
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i <= 0 {
+		b.fail(name, "must be a positive integer")
+		return 0
+	}
+	return i
+}
+
+func (b *binder) query(name string) string {
+	return b.q.Get(name)
+}
+
+// formBool reads an optional boolean from a multipart form, false when missing.
+func (b *binder) formBool(name string) bool {
+	s := b.r.FormValue(name)
+	if s == "" {
+		return false
+	}
+
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		b.fail(name, "must be a boolean")
+		return false
+	}
+	return v
+}
+
+func (b *binder) fail(field, message string) {
+	b.errs = append(b.errs, &service.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_param",
+		Message: field + " " + message,
+		Field:   field,
+	})
+}
+
+// err returns nil, the only invalid param or errInvalidParams with every invalid param.
+func (b *binder) err() error {
+	switch len(b.errs) {
+	case 0:
+		return nil
+	case 1:
+		return b.errs[0]
+	}
+
+	return &service.Error{
+		Status:  errInvalidParams.Status,
+		Code:    errInvalidParams.Code,
+		Message: errInvalidParams.Message,
+		Details: b.errs,
+	}
+}
+
+// JSON 본문 읽기, 크기 제한과 모르는 필드 거부
+// decodeJSON reads exactly one JSON value from the body into v.
+// It returns errEmptyBody when there is no body at all.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		return badRequest(errors.New("request body must only contain a single JSON value"))
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if err == io.EOF {
+		return errEmptyBody
+	}
+
+	// MaxBytesReader의 에러는 타입이 없어서 메시지로 구분
+	if strings.Contains(err.Error(), "request body too large") {
+		return errBodyTooLarge
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return badRequest(fmt.Errorf("malformed JSON at position %d", syntaxErr.Offset))
+	case err == io.ErrUnexpectedEOF:
+		return badRequest(errors.New("malformed JSON"))
+	case errors.As(err, &typeErr):
+		return &service.Error{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_field",
+			Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type),
+			Field:   typeErr.Field,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return &service.Error{
+			Status:  http.StatusBadRequest,
+			Code:    "unknown_field",
+			Message: "unknown field " + field,
+			Field:   field,
+		}
+	}
+
+	return badRequest(err)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matryer/way"
+
+	"sodam/internal/service"
+)
+
+// 라우터를 거쳐 경로 파라미터까지 읽은 binder
+func bindTestRequest(r *http.Request, pattern string, read func(b *binder)) *binder {
+	var b *binder
+	router := way.NewRouter()
+	router.HandleFunc(r.Method, pattern, func(w http.ResponseWriter, r *http.Request) {
+		b = bind(r)
+		read(b)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	return b
+}
+
+func TestBindPathID(t *testing.T) {
+	tests := []struct {
+		path string
+		want int64
+		ok   bool
+	}{
+		{"/posts/12", 12, true},
+		{"/posts/9223372036854775807", 9223372036854775807, true},
+		{"/posts/0", 0, false},
+		{"/posts/-1", 0, false},
+		{"/posts/abc", 0, false},
+		{"/posts/9223372036854775808", 0, false},
+	}
+	for _, tt := range tests {
+		var got int64
+		b := bindTestRequest(httptest.NewRequest("GET", tt.path, nil), "/posts/:post_id", func(b *binder) {
+			got = b.pathID("post_id")
+		})
+		if got != tt.want || (b.err() == nil) != tt.ok {
+			t.Errorf("pathID %s = %d, %v, want %d", tt.path, got, b.err(), tt.want)
+		}
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		last   int
+		before int64
+		fields []string
+	}{
+		{name: "missing", query: ""},
+		{name: "valid", query: "last=10&before=42", last: 10, before: 42},
+		{name: "zero last", query: "last=0", last: 0},
+		{name: "negative last", query: "last=-1", fields: []string{"last"}},
+		{name: "last not a number", query: "last=ten", fields: []string{"last"}},
+		{name: "zero before", query: "before=0", fields: []string{"before"}},
+		{name: "before not a number", query: "before=abc", fields: []string{"before"}},
+		{name: "both invalid", query: "last=x&before=y", fields: []string{"last", "before"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bind(httptest.NewRequest("GET", "/posts?"+tt.query, nil))
+			last, before := b.queryInt("last"), b.queryID("before")
+			if last != tt.last || before != tt.before {
+				t.Errorf("last, before = %d, %d, want %d, %d", last, before, tt.last, tt.before)
+			}
+
+			assertInvalidParams(t, b.err(), tt.fields)
+		})
+	}
+}
+
+func TestBindFormBool(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+		ok    bool
+	}{
+		{"", false, true},
+		{"true", true, true},
+		{"1", true, true},
+		{"false", false, true},
+		{"yes", false, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/posts", strings.NewReader("nsfw="+tt.value))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		b := bind(r)
+		if got := b.formBool("nsfw"); got != tt.want || (b.err() == nil) != tt.ok {
+			t.Errorf("formBool %q = %v, %v, want %v", tt.value, got, b.err(), tt.want)
+		}
+	}
+}
+
+// 잘못된 파라미터가 하나면 그 에러, 여럿이면 details로
+func assertInvalidParams(t *testing.T, err error, fields []string) {
+	t.Helper()
+	if len(fields) == 0 {
+		if err != nil {
+			t.Errorf("err = %v, want nil", err)
+		}
+		return
+	}
+
+	var e *service.Error
+	if !errors.As(err, &e) || e.Status != http.StatusBadRequest {
+		t.Fatalf("err = %v, want a 400 service error", err)
+	}
+
+	var got []string
+	if len(fields) == 1 {
+		got = append(got, e.Field)
+		if e.Code != "invalid_param" {
+			t.Errorf("code = %q, want invalid_param", e.Code)
+		}
+	} else {
+		for _, d := range e.Details {
+			got = append(got, d.Field)
+		}
+		if e.Code != "invalid_params" {
+			t.Errorf("code = %q, want invalid_params", e.Code)
+		}
+	}
+
+	if !reflect.DeepEqual(got, fields) {
+		t.Errorf("invalid fields = %v, want %v", got, fields)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type input struct {
+		Content string `json:"content"`
+		NSFW    bool   `json:"nsfw"`
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		code  string
+		field string
+	}{
+		{name: "valid", body: `{"content":"hi","nsfw":true}`},
+		{name: "trailing space", body: "{\"content\":\"hi\"}\n"},
+		{name: "empty", body: "", code: "empty_body"},
+		{name: "unknown field", body: `{"content":"hi","title":"x"}`, code: "unknown_field", field: "title"},
+		{name: "wrong type", body: `{"nsfw":"yes"}`, code: "invalid_field", field: "nsfw"},
+		{name: "malformed", body: `{"content":}`, code: "bad_request"},
+		{name: "truncated", body: `{"content":"hi"`, code: "bad_request"},
+		{name: "trailing data", body: `{"content":"hi"}{"content":"again"}`, code: "bad_request"},
+		{name: "trailing garbage", body: `{"content":"hi"} x`, code: "bad_request"},
+		{name: "at the limit", body: `{"content":"` + strings.Repeat("a", maxBodyBytes-14) + `"}`},
+		{name: "too large", body: `{"content":"` + strings.Repeat("a", maxBodyBytes) + `"}`, code: "body_too_large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/posts", strings.NewReader(tt.body))
+
+			var in input
+			err := decodeJSON(w, r, &in)
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("err = %v", err)
+				}
+				return
+			}
+
+			// 응답 본문은 {"code", "message", "field"}로 400
+			respondError(w, err)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+
+			var body map[string]interface{}
+			if err = json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body["code"] != tt.code || body["message"] == "" {
+				t.Errorf("body = %s, want code %s with a message", w.Body, tt.code)
+			}
+
+			if field, _ := body["field"].(string); field != tt.field {
+				t.Errorf("field = %q, want %q", field, tt.field)
+			}
+		})
+	}
+}
@@ -1,59 +1,70 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"sodam/internal/service"
-	"strconv"
-
-	"github.com/matryer/way"
 )
 
 type createCommentInput struct {
-	Content string
+	Content  string `json:"content"`
+	ParentID *int64 `json:"parent_id,omitempty"`
 }
 
 func (h *handler) createComment(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	var in createCommentInput
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
 		return
 	}
 
 	ctx := r.Context()
-	postID, _ := strconv.ParseInt(way.Param(ctx, "post_id"), 10, 64)
-	c, err := h.CreateComment(ctx, postID, in.Content)
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	b := bind(r)
+	postID := b.pathID("post_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
-
-	if err == service.ErrInvalidContent {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	c, err := h.CreateComment(ctx, postID, in.Content, in.ParentID)
+	if err != nil {
+		respondError(w, err)
 		return
 	}
 
-	if err == service.ErrPostNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	respond(w, c, http.StatusCreated)
+}
+
+func (h *handler) comments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	b := bind(r)
+	postID := b.pathID("post_id")
+	last := b.queryInt("last")
+	before := b.queryID("before")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
-
+	cc, err := h.Comments(ctx, postID, last, before)
 	if err != nil {
 		respondError(w, err)
 		return
 	}
 
-	respond(w, c, http.StatusCreated)
+	respond(w, cc, http.StatusOK)
 }
 
-func (h *handler) comments(w http.ResponseWriter, r *http.Request) {
+func (h *handler) replies(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	q := r.URL.Query()
-	postID, _ := strconv.ParseInt(way.Param(ctx, "post_id"), 10, 64)
-	last, _ := strconv.Atoi(q.Get("last"))
-	before, _ := strconv.ParseInt(q.Get("before"), 10, 64)
-	cc, err := h.Comments(ctx, postID, last, before)
+	b := bind(r)
+	commentID := b.pathID("comment_id")
+	last := b.queryInt("last")
+	before := b.queryID("before")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+	cc, err := h.Replies(ctx, commentID, last, before)
 	if err != nil {
 		respondError(w, err)
 		return
@@ -62,20 +73,58 @@ func (h *handler) comments(w http.ResponseWriter, r *http.Request) {
 	respond(w, cc, http.StatusOK)
 }
 
+func (h *handler) deleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	b := bind(r)
+	commentID := b.pathID("comment_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+	err := h.DeleteComment(ctx, commentID)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *handler) toggleCommentLike(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	commentID, _ := strconv.ParseInt(way.Param(ctx, "comment_id"), 10, 64)
+	b := bind(r)
+	commentID := b.pathID("comment_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
 	out, err := h.ToggleCommentLike(ctx, commentID)
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	if err != nil {
+		respondError(w, err)
 		return
 	}
 
-	if err == service.ErrCommentNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	respond(w, out, http.StatusOK)
+}
+
+// PUT, DELETE는 여러 번 보내도 결과가 같음
+func (h *handler) likeComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentLike(w, r, h.LikeComment)
+}
+
+func (h *handler) unlikeComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentLike(w, r, h.UnlikeComment)
+}
+
+func (h *handler) setCommentLike(w http.ResponseWriter, r *http.Request, set func(context.Context, int64) (service.ToggleLikeOutput, error)) {
+	b := bind(r)
+	commentID := b.pathID("comment_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
 
+	out, err := set(r.Context(), commentID)
 	if err != nil {
 		respondError(w, err)
 		return
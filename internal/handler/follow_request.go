@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/matryer/way"
+)
+
+type setPrivateInput struct {
+	Private bool `json:"private"`
+}
+
+// 비공개 계정 설정 핸들러
+func (h *handler) setPrivate(w http.ResponseWriter, r *http.Request) {
+	var in setPrivateInput
+	defer r.Body.Close()
+
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	err := h.SetPrivate(r.Context(), in.Private)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 받은 팔로우 요청 목록 핸들러
+func (h *handler) followRequests(w http.ResponseWriter, r *http.Request) {
+	b := bind(r)
+	first := b.queryInt("first")
+	after := b.query("after")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+	uu, err := h.FollowRequests(r.Context(), first, after)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, uu, http.StatusOK)
+}
+
+// 팔로우 요청 승인 핸들러
+func (h *handler) approveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.answerFollowRequest(w, h.ApproveFollowRequest(ctx, way.Param(ctx, "username")))
+}
+
+// 팔로우 요청 거절 핸들러
+func (h *handler) denyFollowRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.answerFollowRequest(w, h.DenyFollowRequest(ctx, way.Param(ctx, "username")))
+}
+
+func (h *handler) answerFollowRequest(w http.ResponseWriter, err error) {
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
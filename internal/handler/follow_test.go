@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hako/branca"
+
+	"sodam/internal/service"
+)
+
+// 메모리 저장소 서비스와 그 위의 API, 토큰을 돌려줌
+func newTestAPI(t *testing.T, usernames ...string) (http.Handler, map[string]string) {
+	t.Helper()
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	blobs := service.NewLocalBlobStore(t.TempDir(), "http://localhost:3000/img")
+	s := service.New(service.NewMemoryStore(), codec, "http://localhost:3000", blobs)
+
+	ctx := context.Background()
+	tokens := map[string]string{}
+	for _, username := range usernames {
+		if err := s.CreateUser(ctx, username+"@example.org", username); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := s.Login(ctx, username+"@example.org")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[username] = out.Token
+	}
+	return New(s), tokens
+}
+
+func TestFollowRoutes(t *testing.T) {
+	h, tokens := newTestAPI(t, "alice", "bob")
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"follow", "PUT", "/api/users/bob/follow", http.StatusOK, `"following":true`},
+		{"follow again", "PUT", "/api/users/bob/follow", http.StatusOK, `"followers_count":1`},
+		{"unfollow", "DELETE", "/api/users/bob/follow", http.StatusOK, `"following":false`},
+		{"unfollow again", "DELETE", "/api/users/bob/follow", http.StatusOK, `"followers_count":0`},
+		{"self", "PUT", "/api/users/alice/follow", http.StatusConflict, `"code":"forbidden_follow"`},
+		{"self toggle", "POST", "/api/users/alice/toggle_follow", http.StatusConflict, `"code":"forbidden_follow"`},
+		// 차단, 뮤트도 팔로우처럼 자기 자신은 409
+		{"self block", "POST", "/api/users/alice/toggle_block", http.StatusConflict, `"code":"forbidden_block"`},
+		{"self mute", "POST", "/api/users/alice/toggle_mute", http.StatusConflict, `"code":"forbidden_mute"`},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		r.Header.Set("Authorization", "Bearer "+tokens["alice"])
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s = %d %s, want %d with %s", tt.name, w.Code, w.Body, tt.status, tt.body)
+		}
+	}
+}
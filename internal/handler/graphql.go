@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"sodam/internal/gql"
+)
+
+// 문서용 GraphQL 요청
+type graphQLInput struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// 문서용 GraphQL 응답
+type graphQLOutput struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []graphQLError         `json:"errors,omitempty"`
+}
+
+func (h *handler) queryGraphQL(w http.ResponseWriter, r *http.Request) {
+	h.graphql.ServeHTTP(w, r)
+}
+
+// 뮤테이션은 필드마다 REST 라우트의 제한도 받음
+func (h *handler) execGraphQL(w http.ResponseWriter, r *http.Request) {
+	if h.limiter != nil {
+		r = r.WithContext(gql.WithLimit(r.Context(), h.limiter.routeLimit(r)))
+	}
+	h.graphql.ServeHTTP(w, r)
+}
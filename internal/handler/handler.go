@@ -5,41 +5,187 @@ import (
 
 	"github.com/matryer/way"
 
+	"sodam/internal/gql"
 	"sodam/internal/service"
 )
 
 type handler struct {
 	*service.Service
+	limiter     *rateLimiter
+	idempotency IdempotencyStore
+	graphql     http.Handler
+	spec        object
+}
+
+// 선택 옵션
+// Option configures optional handler behaviour.
+type Option func(*handler)
+
+// WithRateLimit limits the matching routes per authenticated user or client IP.
+// trustProxy reads the client IP from X-Forwarded-For.
+func WithRateLimit(limits map[string]RateLimit, store RateLimitStore, trustProxy bool) Option {
+	return func(h *handler) {
+		h.limiter = newRateLimiter(limits, store, trustProxy)
+	}
+}
+
+// WithIdempotency replays the saved response of a POST retried with the same Idempotency-Key.
+func WithIdempotency(store IdempotencyStore) Option {
+	return func(h *handler) {
+		h.idempotency = store
+	}
+}
+
+// API 라우트, 문서(openapi.json)도 이 목록으로 만들어짐
+// route of the API with what the OpenAPI document needs to describe it.
+type route struct {
+	method  string
+	pattern string
+	handle  http.HandlerFunc
+	summary string
+	auth    bool        // 로그인 필요
+	query   []string    // 쿼리 파라미터 이름
+	in      interface{} // JSON 본문
+	inOpt   bool        // 본문 없이도 됨
+	form    bool        // multipart/form-data 본문도 받음
+	image   bool        // 본문이 이미지 그 자체
+	out     interface{} // 응답 본문, 없으면 204
+	status  int
+}
+
+var (
+	pageBefore = []string{"last", "before"}
+	pageAfter  = []string{"first", "after"}
+)
+
+func (h *handler) routes() []route {
+	return []route{
+		{method: "POST", pattern: "/login", handle: h.login, summary: "Login with an email",
+			in: loginInput{}, out: service.LoginOutput{}},
+		{method: "GET", pattern: "/auth_user", handle: h.authUser, summary: "Authenticated user", auth: true,
+			out: service.User{}},
+		{method: "POST", pattern: "/users", handle: h.createUser, summary: "Create a user",
+			in: createUserInput{}, status: http.StatusNoContent},
+		{method: "GET", pattern: "/users/:username", handle: h.user, summary: "User profile",
+			out: service.UserProfile{}},
+		{method: "PUT", pattern: "/auth_user/avatar", handle: h.updateAvatar, summary: "Update the avatar", auth: true,
+			image: true, out: service.Avatar{}},
+		{method: "PUT", pattern: "/auth_user/private", handle: h.setPrivate, summary: "Make the account private or public", auth: true,
+			in: setPrivateInput{}, status: http.StatusNoContent},
+		{method: "GET", pattern: "/auth_user/follow_requests", handle: h.followRequests, summary: "Pending follow requests", auth: true,
+			query: pageAfter, out: []service.UserProfile{}},
+		{method: "POST", pattern: "/auth_user/follow_requests/:username/approve", handle: h.approveFollowRequest, summary: "Approve a follow request", auth: true,
+			status: http.StatusNoContent},
+		{method: "POST", pattern: "/auth_user/follow_requests/:username/deny", handle: h.denyFollowRequest, summary: "Deny a follow request", auth: true,
+			status: http.StatusNoContent},
+		{method: "POST", pattern: "/users/:username/toggle_follow", handle: h.toggleFollow, summary: "Follow, unfollow or request to follow", auth: true,
+			out: service.ToggleFollowOutput{}},
+		{method: "PUT", pattern: "/users/:username/follow", handle: h.follow, summary: "Follow or request to follow, keeps an existing follow", auth: true,
+			out: service.ToggleFollowOutput{}},
+		{method: "DELETE", pattern: "/users/:username/follow", handle: h.unfollow, summary: "Unfollow or cancel a follow request", auth: true,
+			out: service.ToggleFollowOutput{}},
+		{method: "POST", pattern: "/users/:username/toggle_block", handle: h.toggleBlock, summary: "Block or unblock", auth: true,
+			out: service.ToggleBlockOutput{}},
+		{method: "POST", pattern: "/users/:username/toggle_mute", handle: h.toggleMute, summary: "Mute or unmute", auth: true,
+			out: service.ToggleMuteOutput{}},
+		{method: "GET", pattern: "/users", handle: h.users, summary: "Search users",
+			query: append([]string{"search"}, pageAfter...), out: []service.UserProfile{}},
+		{method: "GET", pattern: "/users/:username/followers", handle: h.followers, summary: "Followers of a user",
+			query: pageAfter, out: []service.UserProfile{}},
+		{method: "GET", pattern: "/users/:username/followees", handle: h.followees, summary: "Users followed by a user",
+			query: pageAfter, out: []service.UserProfile{}},
+		{method: "POST", pattern: "/posts", handle: h.createPost, summary: "Create a post", auth: true,
+			in: createPostInput{}, form: true, out: service.TimelineItem{}, status: http.StatusCreated},
+		{method: "GET", pattern: "/users/:username/posts", handle: h.posts, summary: "Posts of a user",
+			query: pageBefore, out: []service.Post{}},
+		{method: "GET", pattern: "/posts/:post_id", handle: h.post, summary: "Post",
+			out: service.Post{}},
+		{method: "POST", pattern: "/posts/:post_id/toggle_like", handle: h.togglePostLike, summary: "Like or unlike a post", auth: true,
+			out: service.ToggleLikeOutput{}},
+		{method: "PUT", pattern: "/posts/:post_id/like", handle: h.likePost, summary: "Like a post", auth: true,
+			out: service.ToggleLikeOutput{}},
+		{method: "DELETE", pattern: "/posts/:post_id/like", handle: h.unlikePost, summary: "Unlike a post", auth: true,
+			out: service.ToggleLikeOutput{}},
+		{method: "POST", pattern: "/posts/:post_id/repost", handle: h.repost, summary: "Repost, optionally quoting", auth: true,
+			in: repostInput{}, inOpt: true, out: service.TimelineItem{}, status: http.StatusCreated},
+		{method: "GET", pattern: "/timeline", handle: h.timeline, summary: "Timeline of the authenticated user", auth: true,
+			query: pageBefore, out: []service.TimelineItem{}},
+		{method: "POST", pattern: "/posts/:post_id/comments", handle: h.createComment, summary: "Comment on a post or reply to a comment", auth: true,
+			in: createCommentInput{}, out: service.Comment{}, status: http.StatusCreated},
+		{method: "GET", pattern: "/posts/:post_id/comments", handle: h.comments, summary: "Comments of a post",
+			query: pageBefore, out: []service.Comment{}},
+		{method: "GET", pattern: "/comments/:comment_id/replies", handle: h.replies, summary: "Replies to a comment",
+			query: pageBefore, out: []service.Comment{}},
+		{method: "DELETE", pattern: "/comments/:comment_id", handle: h.deleteComment, summary: "Delete a comment", auth: true,
+			status: http.StatusNoContent},
+		{method: "POST", pattern: "/comments/:comment_id/toggle_like", handle: h.toggleCommentLike, summary: "Like or unlike a comment", auth: true,
+			out: service.ToggleLikeOutput{}},
+		{method: "PUT", pattern: "/comments/:comment_id/like", handle: h.likeComment, summary: "Like a comment", auth: true,
+			out: service.ToggleLikeOutput{}},
+		{method: "DELETE", pattern: "/comments/:comment_id/like", handle: h.unlikeComment, summary: "Unlike a comment", auth: true,
+			out: service.ToggleLikeOutput{}},
+		{method: "POST", pattern: "/posts/:post_id/reviews", handle: h.createReview, summary: "Review an ordered post", auth: true,
+			in: createReviewInput{}, out: service.Review{}, status: http.StatusCreated},
+		{method: "GET", pattern: "/posts/:post_id/reviews", handle: h.reviews, summary: "Reviews of a post",
+			query: pageBefore, out: []service.Review{}},
+		{method: "GET", pattern: "/notifications", handle: h.notifications, summary: "Notifications", auth: true,
+			query: pageBefore, out: []service.Notification{}},
+		{method: "POST", pattern: "/notifications/:notification_id/mark_as_read", handle: h.markNotificationAsRead, summary: "Mark a notification as read", auth: true,
+			status: http.StatusNoContent},
+		{method: "POST", pattern: "/mark_notifications_as_read", handle: h.markNotificationsAsRead, summary: "Mark every notification as read", auth: true,
+			status: http.StatusNoContent},
+		{method: "POST", pattern: "/reports", handle: h.createReport, summary: "Report a post, comment, user or review", auth: true,
+			in: createReportInput{}, out: service.Report{}, status: http.StatusCreated},
+		{method: "GET", pattern: "/admin/reports", handle: h.reports, summary: "Moderation queue (admin)", auth: true,
+			query: append([]string{"status"}, pageBefore...), out: []service.Report{}},
+		{method: "POST", pattern: "/admin/reports/:report_id/resolve", handle: h.resolveReport, summary: "Resolve a report (admin)", auth: true,
+			in: resolveReportInput{}, status: http.StatusNoContent},
+		{method: "GET", pattern: "/graphql", handle: h.queryGraphQL, summary: "GraphQL query, mutations only over POST",
+			query: []string{"query", "operationName", "variables"}, out: graphQLOutput{}, status: http.StatusOK},
+		{method: "POST", pattern: "/graphql", handle: h.execGraphQL, summary: "GraphQL query or mutation",
+			in: graphQLInput{}, out: graphQLOutput{}, status: http.StatusOK},
+		{method: "GET", pattern: "/openapi.json", handle: h.openAPI, summary: "This OpenAPI document",
+			status: http.StatusOK},
+		{method: "GET", pattern: "/docs", handle: h.docs, summary: "Swagger UI of this document",
+			status: http.StatusOK},
+	}
+}
+
+// 라우트 목록에 있는 것만 등록
+func (h *handler) router() *way.Router {
+	h.graphql = gql.New(h.Service)
+	routes := h.routes()
+	h.spec = openAPISpec(routes)
+
+	r := way.NewRouter()
+	for _, rt := range routes {
+		r.HandleFunc(rt.method, rt.pattern, rt.handle)
+	}
+	return r
 }
 
 //New creates an http.Handler with predefined routing.
-func New(s *service.Service) http.Handler {
-	h := &handler{s}
-
-	api := way.NewRouter()
-	api.HandleFunc("POST", "/login", h.login)
-	api.HandleFunc("GET", "/auth_user", h.authUser)
-	api.HandleFunc("POST", "/users", h.createUser)
-	api.HandleFunc("GET", "/users/:username", h.user)
-	api.HandleFunc("PUT", "/auth_user/avatar", h.updateAvatar)
-	api.HandleFunc("POST", "/users/:username/toggle_follow", h.toggleFollow)
-	api.HandleFunc("GET", "/users", h.users)
-	api.HandleFunc("GET", "/users/:username/followers", h.followers)
-	api.HandleFunc("GET", "/users/:username/followees", h.followees)
-	api.HandleFunc("POST", "/posts", h.createPost)
-	api.HandleFunc("GET", "/users/:username/posts", h.posts)
-	api.HandleFunc("GET", "/posts/:post_id", h.post)
-	api.HandleFunc("POST", "/posts/:post_id/toggle_like", h.togglePostLike)
-	api.HandleFunc("GET", "/timeline", h.timeline)
-	api.HandleFunc("POST", "/posts/:post_id/comments", h.createComment)
-	api.HandleFunc("GET", "/posts/:post_id/comments", h.comments)
-	api.HandleFunc("POST", "/comments/:comment_id/toggle_like", h.toggleCommentLike)
-	api.HandleFunc("GET", "/notifications", h.notifications)
-	api.HandleFunc("POST", "/notifications/:notification_id/mark_as_read", h.markNotificationAsRead)
-	api.HandleFunc("POST", "/mark_notifications_as_read", h.markNotificationsAsRead)
+func New(s *service.Service, opts ...Option) http.Handler {
+	h := &handler{Service: s}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	var apiHandler http.Handler = h.router()
+	if h.idempotency != nil {
+		apiHandler = idempotency(h.idempotency, apiHandler)
+	}
+
+	if h.limiter != nil {
+		apiHandler = h.limiter.middleware(apiHandler)
+	}
 
 	r := way.NewRouter()
-	r.Handle("*", "/api...", http.StripPrefix("/api", h.withAuth(api)))
+	r.Handle("*", "/api...", http.StripPrefix("/api", h.withAuth(apiHandler)))
+
+	static := staticFiles{dirs: staticDirs, index: spaIndex}
+	r.Handle("GET", "/...", static)
+	r.Handle("HEAD", "/...", static)
 
 	return r
 }
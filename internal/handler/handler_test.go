@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoutesInOpenAPISpec(t *testing.T) {
+	h := &handler{}
+	router := reflect.ValueOf(h.router()).Elem()
+	paths := h.spec["paths"].(object)
+
+	registered := router.FieldByName("routes")
+	if registered.Len() == 0 {
+		t.Fatal("no routes registered")
+	}
+
+	for i := 0; i < registered.Len(); i++ {
+		rt := registered.Index(i).Elem()
+		method := rt.FieldByName("method").String()
+		segs := rt.FieldByName("segs")
+		parts := make([]string, segs.Len())
+		for j := range parts {
+			parts[j] = segs.Index(j).String()
+		}
+
+		pattern := "/" + strings.Join(parts, "/")
+		path, _ := openAPIPath(pattern)
+		item, ok := paths[path].(object)
+		if !ok {
+			t.Errorf("%s %s: path %s not in openapi spec", strings.ToUpper(method), pattern, path)
+			continue
+		}
+
+		if _, ok := item[method]; !ok {
+			t.Errorf("%s %s: method not in openapi spec", strings.ToUpper(method), pattern)
+		}
+	}
+
+	ops := 0
+	for _, item := range paths {
+		ops += len(item.(object))
+	}
+	if ops != registered.Len() {
+		t.Errorf("openapi spec has %d operations, router has %d routes", ops, registered.Len())
+	}
+}
+
+func TestOpenAPIOperationIDsUnique(t *testing.T) {
+	seen := map[string]string{}
+	h := &handler{}
+	for _, rt := range h.routes() {
+		id := handlerName(rt.handle)
+		if other, ok := seen[id]; ok {
+			t.Errorf("operationId %q used by %s and %s %s", id, other, rt.method, rt.pattern)
+		}
+		seen[id] = rt.method + " " + rt.pattern
+	}
+}
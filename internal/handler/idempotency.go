@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sodam/internal/gql"
+	"sodam/internal/service"
+)
+
+// 저장된 응답을 돌려주는 기간
+// IdempotencyTTL is how long a response is replayed for the same key.
+const IdempotencyTTL = time.Hour * 24
+
+// 키를 잡은 요청은 이 시간 안에 끝나야 함, 지나면 컨텍스트가 취소되어 저장하지 못함
+// 업로드가 느려도 잠금이 먼저 풀려서 재시도가 같이 실행되는 일이 없도록 잠금은 응답을 저장할 여유까지
+const (
+	idempotencyRequestTimeout = time.Minute * 5
+	idempotencyLockTTL        = idempotencyRequestTimeout + time.Second*30
+)
+
+const maxIdempotencyKeyLen = 255
+
+// IdempotentResponse saved for an Idempotency-Key.
+// RequestHash tells a retry apart from a different request that reused the key.
+type IdempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+	RequestHash string `json:"request_hash"`
+}
+
+// 멱등성 키 저장소, 여러 서버를 띄울 때는 RedisIdempotencyStore 사용
+// IdempotencyStore keeps the responses per key.
+type IdempotencyStore interface {
+	// Lock reserves the key for a request.
+	// When the key is taken ok is false and res is the saved response, or nil while the first request runs.
+	Lock(ctx context.Context, key string, ttl time.Duration) (res *IdempotentResponse, ok bool, err error)
+	// Save the response of the request that holds the key.
+	Save(ctx context.Context, key string, res IdempotentResponse, ttl time.Duration) error
+	// Unlock frees the key without a response so the request can be retried.
+	Unlock(ctx context.Context, key string) error
+}
+
+var (
+	errIdempotencyKeyInvalid = &service.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_idempotency_key",
+		Message: "invalid idempotency key",
+	}
+	errIdempotencyKeyInUse = &service.Error{
+		Status:  http.StatusConflict,
+		Code:    "idempotency_key_in_use",
+		Message: "a request with this idempotency key is still in progress",
+	}
+	errIdempotencyKeyReused = &service.Error{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "idempotency_key_reused",
+		Message: "idempotency key was used for a different request",
+	}
+)
+
+// POST 요청의 Idempotency-Key, 유저, 경로별로 첫 응답을 저장하고 재시도에는 그대로 돌려줌
+// 로그인하지 않은 요청과 GraphQL 구독(SSE)은 그냥 지나감, SSE로는 subscription만 받음
+func idempotency(store IdempotencyStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		uid, authenticated := r.Context().Value(service.KeyAuthUserID).(int64)
+		if r.Method != http.MethodPost || key == "" || !authenticated ||
+			r.URL.Path == "/graphql" && gql.AcceptsEventStream(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			respondError(w, errIdempotencyKeyInvalid)
+			return
+		}
+
+		ctx := r.Context()
+		storeKey := "idempotency:user:" + strconv.FormatInt(uid, 10) + ":" + r.Method + " " + r.URL.Path + ":" + key
+		saved, ok, err := store.Lock(ctx, storeKey, idempotencyLockTTL)
+		if err != nil {
+			// 저장소 장애로 서비스를 막지는 않음
+			log.Printf("could not lock idempotency key: %v\n", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// multipart 경계는 재시도마다 달라질 수 있어서 본문을 비교하지 않음
+		// JSON 본문은 bind와 같은 크기까지만 읽음
+		hashable := !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/")
+		if hashable {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		}
+		if !ok {
+			if saved == nil {
+				respondError(w, errIdempotencyKeyInUse)
+				return
+			}
+
+			h := sha256.New()
+			if hashable && saved.RequestHash != "" && requestHash(r.Body, h, h) != saved.RequestHash {
+				respondError(w, errIdempotencyKeyReused)
+				return
+			}
+
+			if saved.ContentType != "" {
+				w.Header().Set("Content-Type", saved.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(saved.Status)
+			w.Write(saved.Body)
+			return
+		}
+
+		// 5xx나 패닉이면 저장하지 않고 다시 시도할 수 있게 키를 풂
+		// 요청 컨텍스트는 끝났을 수 있어서 Background
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+
+			if err := store.Unlock(context.Background(), storeKey); err != nil {
+				log.Printf("could not unlock idempotency key: %v\n", err)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(ctx, idempotencyRequestTimeout)
+		defer cancel()
+
+		// 핸들러가 읽는 본문으로 요청 해시를 만듦, 핸들러가 닫아도 나머지를 읽을 수 있게 NopCloser
+		h := sha256.New()
+		body := io.TeeReader(r.Body, h)
+		r.Body = ioutil.NopCloser(body)
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		res := IdempotentResponse{
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}
+		// 핸들러가 읽지 않은 나머지는 TeeReader로 해시에 들어가므로 버리기만 함
+		if hashable {
+			res.RequestHash = requestHash(body, ioutil.Discard, h)
+		}
+		stored = true
+		if err = store.Save(context.Background(), storeKey, res, IdempotencyTTL); err != nil {
+			log.Printf("could not save idempotent response: %v\n", err)
+		}
+	})
+}
+
+// 남은 본문을 dst로 읽고 해시, 본문은 maxBodyBytes로 제한되어 있음
+func requestHash(body io.Reader, dst io.Writer, h hash.Hash) string {
+	io.Copy(dst, io.LimitReader(body, maxBodyBytes))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 상태 코드와 본문을 같이 기록
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wrote {
+		rec.status = status
+		rec.wrote = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wrote = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// 메모리 저장소, 서버 하나일 때 사용
+// MemoryIdempotencyStore keeps the responses in process.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+	sweep   time.Time
+}
+
+type idempotencyEntry struct {
+	res     *IdempotentResponse
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in process store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]idempotencyEntry{}, sweep: time.Now()}
+}
+
+// Lock reserves the key or returns its saved response.
+func (s *MemoryIdempotencyStore) Lock(_ context.Context, key string, ttl time.Duration) (*IdempotentResponse, bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 만료된 키는 가끔씩 정리
+	if now.Sub(s.sweep) > time.Minute {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return e.res, false, nil
+	}
+
+	s.entries[key] = idempotencyEntry{expires: now.Add(ttl)}
+	return nil, true, nil
+}
+
+// Save the response for the key.
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, res IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = idempotencyEntry{res: &res, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Unlock frees the key.
+func (s *MemoryIdempotencyStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 처리 중인 키의 값
+const idempotencyPending = "pending"
+
+// Redis 호환 저장소, 여러 서버에서 응답 공유
+// RedisIdempotencyStore shares the responses between instances through a Redis compatible server.
+type RedisIdempotencyStore struct {
+	*redisClient
+}
+
+// NewRedisIdempotencyStore from a redis://[:password@]host:port[/db] URL.
+func NewRedisIdempotencyStore(rawurl string) (*RedisIdempotencyStore, error) {
+	c, err := newRedisClient(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisIdempotencyStore{c}, nil
+}
+
+// Lock reserves the key with SET NX or returns its saved response.
+func (s *RedisIdempotencyStore) Lock(ctx context.Context, key string, ttl time.Duration) (*IdempotentResponse, bool, error) {
+	reply, err := s.do(ctx, "SET", key, idempotencyPending, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if reply == "OK" {
+		return nil, true, nil
+	}
+
+	// 다른 요청이 처리 중이거나 그 사이에 만료됨
+	if reply, err = s.do(ctx, "GET", key); err != nil {
+		return nil, false, err
+	}
+
+	str, _ := reply.(string)
+	if str == "" || str == idempotencyPending {
+		return nil, false, nil
+	}
+
+	var res IdempotentResponse
+	if err = json.Unmarshal([]byte(str), &res); err != nil {
+		return nil, false, fmt.Errorf("could not unmarshal idempotent response: %v", err)
+	}
+
+	return &res, false, nil
+}
+
+// Save the response for the key.
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, res IdempotentResponse, ttl time.Duration) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("could not marshal idempotent response: %v", err)
+	}
+
+	_, err = s.do(ctx, "SET", key, string(b), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Unlock frees the key.
+func (s *RedisIdempotencyStore) Unlock(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", key)
+	return err
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sodam/internal/service"
+)
+
+// 같은 키로 보낸 로그인한 POST 요청
+func serveIdempotent(h http.Handler, path, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, strings.NewReader(`{"content":"post"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Idempotency-Key", "key")
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	r = r.WithContext(context.WithValue(r.Context(), service.KeyAuthUserID, int64(1)))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	calls := 0
+	h := idempotency(NewMemoryIdempotencyStore(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// text/event-stream을 달아도 REST 라우트는 건너뛰지 않음
+	for _, accept := range []string{"", "text/event-stream"} {
+		w := serveIdempotent(h, "/posts", accept)
+		if w.Code != http.StatusCreated {
+			t.Errorf("status with Accept %q = %d, want 201", accept, w.Code)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+}
+
+// 5xx와 패닉은 키를 풀어서 재시도가 실행되게 함
+func TestIdempotencyUnlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(w http.ResponseWriter)
+	}{
+		{"server error", func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) }},
+		{"panic", func(w http.ResponseWriter) { panic("handler failed") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := idempotency(NewMemoryIdempotencyStore(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					tt.handle(w)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			func() {
+				defer func() { recover() }()
+				serveIdempotent(h, "/posts", "")
+			}()
+
+			if w := serveIdempotent(h, "/posts", ""); w.Code != http.StatusCreated || calls != 2 {
+				t.Errorf("retry status = %d after %d calls, want 201 after 2", w.Code, calls)
+			}
+		})
+	}
+}
+
+// 요청은 잠금이 풀리기 전에 끝나도록 시간 제한을 받음
+func TestIdempotencyRequestTimeout(t *testing.T) {
+	var deadline time.Time
+	h := idempotency(NewMemoryIdempotencyStore(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	}))
+
+	serveIdempotent(h, "/posts", "")
+	if deadline.IsZero() || time.Until(deadline) > idempotencyRequestTimeout || idempotencyLockTTL <= idempotencyRequestTimeout {
+		t.Errorf("deadline in %v with lock ttl %v, want at most %v", time.Until(deadline), idempotencyLockTTL, idempotencyRequestTimeout)
+	}
+}
@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"sodam/internal/service"
+)
+
+type object = map[string]interface{}
+
+// 쿼리 파라미터 설명
+var queryParams = map[string]object{
+	"last":          {"type": "integer", "minimum": 0, "description": "page size, newest first"},
+	"before":        {"type": "integer", "format": "int64", "minimum": 1, "description": "id cursor, items older than it"},
+	"first":         {"type": "integer", "minimum": 0, "description": "page size, in username order"},
+	"after":         {"type": "string", "description": "username cursor, users after it"},
+	"search":        {"type": "string", "description": "username prefix"},
+	"status":        {"type": "string", "enum": []string{"open", "resolved", "dismissed"}, "description": "report status, open by default"},
+	"query":         {"type": "string", "description": "GraphQL query"},
+	"operationName": {"type": "string", "description": "GraphQL operation to run"},
+	"variables":     {"type": "string", "description": "GraphQL variables as a JSON object"},
+}
+
+// 라우트 목록으로 OpenAPI 3 문서 만들기
+// openAPISpec describes every route with schemas taken from the Go types.
+func openAPISpec(routes []route) object {
+	g := &schemaGen{schemas: object{}}
+	errRef := g.schema(reflect.TypeOf(service.Error{}))
+	paths := object{}
+	for _, rt := range routes {
+		path, params := openAPIPath(rt.pattern)
+		for _, name := range rt.query {
+			params = append(params, object{"name": name, "in": "query", "schema": queryParams[name]})
+		}
+
+		op := object{
+			"operationId": handlerName(rt.handle),
+			"summary":     rt.summary,
+			"tags":        []string{strings.Split(strings.Trim(rt.pattern, "/"), "/")[0]},
+			"responses": object{
+				"default": object{
+					"description": "Error",
+					"content":     object{"application/json": object{"schema": errRef}},
+				},
+			},
+		}
+		// 로그인한 POST 요청은 Idempotency-Key로 재시도 가능
+		if rt.method == http.MethodPost && rt.auth {
+			params = append(params, object{"name": "Idempotency-Key", "in": "header", "schema": object{"type": "string", "maxLength": maxIdempotencyKeyLen}})
+		}
+
+		if len(params) != 0 {
+			op["parameters"] = params
+		}
+
+		if body := g.requestBody(rt); body != nil {
+			op["requestBody"] = body
+		}
+
+		status := rt.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		res := object{"description": http.StatusText(status)}
+		if rt.out != nil {
+			res["content"] = object{"application/json": object{"schema": g.schema(reflect.TypeOf(rt.out))}}
+		}
+		op["responses"].(object)[strconv.Itoa(status)] = res
+
+		if rt.auth {
+			op["security"] = []object{{"bearerAuth": []string{}}}
+		}
+
+		item, ok := paths[path].(object)
+		if !ok {
+			item = object{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "sodam",
+			"version": "1.0.0",
+		},
+		"servers": []object{{"url": "/api"}},
+		"paths":   paths,
+		"components": object{
+			"schemas": g.schemas,
+			"securitySchemes": object{
+				"bearerAuth": object{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// way 패턴을 OpenAPI 경로로, :post_id -> {post_id}
+func openAPIPath(pattern string) (string, []object) {
+	var params []object
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+
+		name := seg[1:]
+		schema := object{"type": "string"}
+		if strings.HasSuffix(name, "_id") {
+			schema = object{"type": "integer", "format": "int64", "minimum": 1}
+		}
+		params = append(params, object{"name": name, "in": "path", "required": true, "schema": schema})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// sodam/internal/handler.(*handler).login-fm -> login
+func handlerName(fn http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+type schemaGen struct {
+	schemas object
+}
+
+func (g *schemaGen) requestBody(rt route) object {
+	content := object{}
+	if rt.in != nil {
+		content["application/json"] = object{"schema": g.schema(reflect.TypeOf(rt.in))}
+	}
+
+	if rt.form {
+		content["multipart/form-data"] = object{"schema": object{
+			"type": "object",
+			"properties": object{
+				"content":   object{"type": "string"},
+				"spoilerOf": object{"type": "string"},
+				"nsfw":      object{"type": "boolean"},
+				"media": object{
+					"type":     "array",
+					"maxItems": service.MaxPostMedia,
+					"items":    object{"type": "string", "format": "binary"},
+				},
+			},
+		}}
+	}
+
+	if rt.image {
+		for _, typ := range []string{"image/png", "image/jpeg", "image/gif", "image/webp"} {
+			content[typ] = object{"schema": object{"type": "string", "format": "binary"}}
+		}
+	}
+
+	if len(content) == 0 {
+		return nil
+	}
+	return object{"required": !rt.inOpt, "content": content}
+}
+
+// 구조체는 components에 한 번만 넣고 $ref로 참조
+func (g *schemaGen) schema(t reflect.Type) object {
+	if t == reflect.TypeOf(time.Time{}) {
+		return object{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if _, ref := s["$ref"]; !ref {
+			s["nullable"] = true
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return object{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Struct:
+		name := exportedName(t.Name())
+		if _, ok := g.schemas[name]; !ok {
+			// 자기 자신을 참조하는 타입(Post.RepostOf)을 위해 먼저 자리를 잡아둠
+			g.schemas[name] = object{}
+			g.schemas[name] = g.structSchema(t)
+		}
+		return object{"$ref": "#/components/schemas/" + name}
+	}
+
+	return object{}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) object {
+	props := object{}
+	var required []string
+	g.fields(t, props, &required)
+
+	s := object{"type": "object", "properties": props}
+	if len(required) != 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaGen) fields(t reflect.Type, props object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		// 임베드된 구조체의 필드는 encoding/json처럼 펼침
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props, required)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = f.Name
+		}
+
+		omitempty := false
+		for _, opt := range parts[1:] {
+			omitempty = omitempty || opt == "omitempty"
+		}
+
+		props[name] = g.schema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// Swagger UI, openapi.json을 읽어서 보여줌
+const docsPage = `<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="utf-8">
+<title>sodam API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" })
+</script>
+</body>
+</html>
+`
+
+func (h *handler) openAPI(w http.ResponseWriter, r *http.Request) {
+	respond(w, h.spec, http.StatusOK)
+}
+
+func (h *handler) docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
@@ -1,39 +1,72 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"io"
+	"mime"
 	"net/http"
 	"sodam/internal/service"
-	"strconv"
+	"strings"
 
 	"github.com/matryer/way"
 )
 
 type createPostInput struct {
-	Content   string
-	SpoilerOf *string
-	NSFW      bool
+	Content   string  `json:"content"`
+	SpoilerOf *string `json:"spoilerOf,omitempty"`
+	NSFW      bool    `json:"nsfw"`
 }
 
 func (h *handler) createPost(w http.ResponseWriter, r *http.Request) {
 	var in createPostInput
+	var media []io.Reader
 	defer r.Body.Close()
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	ti, err := h.CreatePost(r.Context(), in.Content, in.SpoilerOf, in.NSFW)
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
 
-	if err == service.ErrInvalidContent || err == service.ErrInvalidSpoiler {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	// 이미지가 포함된 게시물은 multipart/form-data로 받음
+	// posts with images come as multipart/form-data
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, service.MaxPostMedia*service.MaxMediaBytes+maxMemory)
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			// MaxBytesReader의 에러는 타입이 없어서 메시지로 구분
+			if strings.Contains(err.Error(), "request body too large") {
+				respondError(w, service.ErrMediaTooLarge)
+				return
+			}
+
+			respondError(w, badRequest(err))
+			return
+		}
+
+		defer r.MultipartForm.RemoveAll()
+
+		// 필드 이름은 JSON 본문과 같음
+		in.Content = r.FormValue("content")
+		if spoilerOf, ok := r.MultipartForm.Value["spoilerOf"]; ok && len(spoilerOf) != 0 {
+			in.SpoilerOf = &spoilerOf[0]
+		}
+		b := bind(r)
+		in.NSFW = b.formBool("nsfw")
+		if err := b.err(); err != nil {
+			respondError(w, err)
+			return
+		}
+
+		for _, fh := range r.MultipartForm.File["media"] {
+			f, err := fh.Open()
+			if err != nil {
+				respondError(w, err)
+				return
+			}
+
+			defer f.Close()
+			media = append(media, f)
+		}
+	} else if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
 		return
 	}
 
+	ti, err := h.CreatePost(r.Context(), in.Content, in.SpoilerOf, in.NSFW, media)
 	if err != nil {
 		respondError(w, err)
 		return
@@ -44,15 +77,14 @@ func (h *handler) createPost(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) posts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	q := r.URL.Query()
-	last, _ := strconv.Atoi(q.Get("last"))
-	before, _ := strconv.ParseInt(q.Get("before"), 10, 64)
-	pp, err := h.Posts(ctx, way.Param(ctx, "username"), last, before)
-	if err == service.ErrInvalidUsername {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	b := bind(r)
+	last := b.queryInt("last")
+	before := b.queryID("before")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
-
+	pp, err := h.Posts(ctx, way.Param(ctx, "username"), last, before)
 	if err != nil {
 		respondError(w, err)
 		return
@@ -63,13 +95,13 @@ func (h *handler) posts(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) post(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	postID, _ := strconv.ParseInt(way.Param(ctx, "post_id"), 10, 64)
-	p, err := h.Post(ctx, postID)
-	if err == service.ErrPostNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	b := bind(r)
+	postID := b.pathID("post_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
-
+	p, err := h.Post(ctx, postID)
 	if err != nil {
 		respondError(w, err)
 		return
@@ -80,18 +112,39 @@ func (h *handler) post(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) togglePostLike(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	postID, _ := strconv.ParseInt(way.Param(ctx, "post_id"), 10, 64)
+	b := bind(r)
+	postID := b.pathID("post_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
 	out, err := h.TogglePostLike(ctx, postID)
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	if err != nil {
+		respondError(w, err)
 		return
 	}
 
-	if err == service.ErrPostNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	respond(w, out, http.StatusOK)
+}
+
+// PUT, DELETE는 여러 번 보내도 결과가 같음
+func (h *handler) likePost(w http.ResponseWriter, r *http.Request) {
+	h.setPostLike(w, r, h.LikePost)
+}
+
+func (h *handler) unlikePost(w http.ResponseWriter, r *http.Request) {
+	h.setPostLike(w, r, h.UnlikePost)
+}
+
+func (h *handler) setPostLike(w http.ResponseWriter, r *http.Request, set func(context.Context, int64) (service.ToggleLikeOutput, error)) {
+	b := bind(r)
+	postID := b.pathID("post_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
 
+	out, err := set(r.Context(), postID)
 	if err != nil {
 		respondError(w, err)
 		return
@@ -99,3 +152,32 @@ func (h *handler) togglePostLike(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, out, http.StatusOK)
 }
+
+type repostInput struct {
+	Content string `json:"content"`
+}
+
+func (h *handler) repost(w http.ResponseWriter, r *http.Request) {
+	var in repostInput
+	defer r.Body.Close()
+	// 내용 없이 공유할 때는 본문이 비어 있어도 됨
+	if err := decodeJSON(w, r, &in); err != nil && err != errEmptyBody {
+		respondError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	b := bind(r)
+	postID := b.pathID("post_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+	ti, err := h.Repost(ctx, postID, in.Content)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, ti, http.StatusCreated)
+}
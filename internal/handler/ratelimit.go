@@ -0,0 +1,348 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sodam/internal/service"
+)
+
+// 토큰 버킷 설정, Per 동안 Requests번 (한 번에 Requests번까지 몰아서 가능)
+// RateLimit allows Requests per Per with bursts up to Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// 버킷에서 토큰 하나를 꺼낸 결과
+// RateLimitResult of taking one token.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+var errTooManyRequests = &service.Error{
+	Status:  http.StatusTooManyRequests,
+	Code:    "too_many_requests",
+	Message: "too many requests",
+}
+
+// 토큰 버킷 저장소, 여러 서버를 띄울 때는 RedisRateLimitStore 사용
+// RateLimitStore keeps the token buckets.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, l RateLimit) (RateLimitResult, error)
+}
+
+// 기본 제한, "METHOD /pattern" 형식 (way 라우터와 같은 :param, ... 패턴)
+// DefaultRateLimits for the routes that can be abused.
+var DefaultRateLimits = map[string]RateLimit{
+	"POST /login":                            {Requests: 5, Per: time.Minute},
+	"POST /users":                            {Requests: 5, Per: time.Hour},
+	"GET /users":                             {Requests: 60, Per: time.Minute},
+	"POST /posts":                            {Requests: 30, Per: time.Minute},
+	"POST /posts/:post_id/comments":          {Requests: 30, Per: time.Minute},
+	"POST /posts/:post_id/repost":            {Requests: 30, Per: time.Minute},
+	"POST /posts/:post_id/reviews":           {Requests: 30, Per: time.Minute},
+	"POST /posts/:post_id/toggle_like":       {Requests: 60, Per: time.Minute},
+	"POST /comments/:comment_id/toggle_like": {Requests: 60, Per: time.Minute},
+	"PUT /posts/:post_id/like":               {Requests: 60, Per: time.Minute},
+	"DELETE /posts/:post_id/like":            {Requests: 60, Per: time.Minute},
+	"PUT /comments/:comment_id/like":         {Requests: 60, Per: time.Minute},
+	"DELETE /comments/:comment_id/like":      {Requests: 60, Per: time.Minute},
+	"POST /users/:username/toggle_follow":    {Requests: 30, Per: time.Minute},
+	"PUT /users/:username/follow":            {Requests: 30, Per: time.Minute},
+	"DELETE /users/:username/follow":         {Requests: 30, Per: time.Minute},
+	"POST /users/:username/toggle_block":     {Requests: 30, Per: time.Minute},
+	"POST /users/:username/toggle_mute":      {Requests: 30, Per: time.Minute},
+	"POST /reports":                          {Requests: 10, Per: time.Minute},
+	"POST /graphql":                          {Requests: 120, Per: time.Minute},
+	"GET /graphql":                           {Requests: 120, Per: time.Minute},
+}
+
+// ParseRateLimits reads "POST /login=5/1m;POST /posts=30/1m" into limits.
+func ParseRateLimits(s string) (map[string]RateLimit, error) {
+	limits := map[string]RateLimit{}
+	for _, rule := range strings.Split(s, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		eq := strings.LastIndex(rule, "=")
+		slash := strings.LastIndex(rule, "/")
+		if eq == -1 || slash < eq {
+			return nil, fmt.Errorf("invalid rate limit %q", rule)
+		}
+
+		n, err := strconv.Atoi(rule[eq+1 : slash])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid rate limit requests %q", rule)
+		}
+
+		per, err := time.ParseDuration(rule[slash+1:])
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("invalid rate limit period %q", rule)
+		}
+
+		limits[strings.Join(strings.Fields(rule[:eq]), " ")] = RateLimit{Requests: n, Per: per}
+	}
+	return limits, nil
+}
+
+type rateLimitRule struct {
+	method   string
+	segments []string
+	limit    RateLimit
+	key      string
+}
+
+// 라우트별 제한
+type rateLimiter struct {
+	rules      []rateLimitRule
+	store      RateLimitStore
+	trustProxy bool
+}
+
+func newRateLimiter(limits map[string]RateLimit, store RateLimitStore, trustProxy bool) *rateLimiter {
+	rl := &rateLimiter{store: store, trustProxy: trustProxy}
+	for route, l := range limits {
+		parts := strings.SplitN(route, " ", 2)
+		if len(parts) != 2 || l.Requests <= 0 || l.Per <= 0 {
+			log.Printf("ignoring invalid rate limit %q\n", route)
+			continue
+		}
+
+		rl.rules = append(rl.rules, rateLimitRule{
+			method:   parts[0],
+			segments: strings.Split(strings.Trim(parts[1], "/"), "/"),
+			limit:    l,
+			key:      route,
+		})
+	}
+
+	// 겹치는 패턴은 구체적인 규칙이 먼저, 고정 경로 > :param > ...
+	sort.Slice(rl.rules, func(i, j int) bool {
+		a, b := rl.rules[i].segments, rl.rules[j].segments
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if ra, rb := segmentRank(a[k]), segmentRank(b[k]); ra != rb {
+				return ra < rb
+			}
+		}
+		// "/posts/..."는 "/posts"에도 맞으니 뒤로
+		switch {
+		case len(a) > len(b):
+			return a[len(b)] != "..."
+		case len(a) < len(b):
+			return b[len(a)] == "..."
+		}
+		return rl.rules[i].key < rl.rules[j].key
+	})
+	return rl
+}
+
+func segmentRank(segment string) int {
+	switch {
+	case segment == "...":
+		return 2
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	}
+	return 0
+}
+
+func (rl *rateLimiter) match(method, path string) (rateLimitRule, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, rule := range rl.rules {
+		if rule.method == method && matchSegments(rule.segments, segments) {
+			return rule, true
+		}
+	}
+	return rateLimitRule{}, false
+}
+
+func matchSegments(pattern, path []string) bool {
+	for i, p := range pattern {
+		if p == "..." {
+			return true
+		}
+
+		if i >= len(path) {
+			return false
+		}
+
+		if !strings.HasPrefix(p, ":") && p != path[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(path)
+}
+
+// 요청 제한
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := rl.match(r.Method, r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		res, err := rl.store.Take(ctx, "ratelimit:"+rule.key+":"+rl.client(r), rule.limit)
+		if err != nil {
+			// 저장소 장애로 서비스를 막지는 않음
+			log.Printf("could not take rate limit token: %v\n", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(rule.limit.Requests))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rule.limit.Requests, ceilSeconds(rule.limit.Per)))
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			respond(w, errTooManyRequests, http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 요청 안에서 다른 라우트의 제한을 씀, GraphQL 뮤테이션은 같은 일을 하는 REST 라우트로
+// route는 "POST /posts/:post_id/comments"처럼 패턴 그대로
+func (rl *rateLimiter) routeLimit(r *http.Request) func(route string) error {
+	client := rl.client(r)
+	return func(route string) error {
+		parts := strings.SplitN(route, " ", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+
+		rule, ok := rl.match(parts[0], parts[1])
+		if !ok {
+			return nil
+		}
+
+		res, err := rl.store.Take(r.Context(), "ratelimit:"+rule.key+":"+client, rule.limit)
+		if err != nil {
+			log.Printf("could not take rate limit token: %v\n", err)
+			return nil
+		}
+
+		if !res.Allowed {
+			return errTooManyRequests
+		}
+		return nil
+	}
+}
+
+// 인증된 유저는 유저 아이디로 아니면 IP로 구분
+func (rl *rateLimiter) client(r *http.Request) string {
+	if uid, ok := r.Context().Value(service.KeyAuthUserID).(int64); ok {
+		return "user:" + strconv.FormatInt(uid, 10)
+	}
+	return "ip:" + rl.clientIP(r)
+}
+
+func (rl *rateLimiter) clientIP(r *http.Request) string {
+	// 프록시 뒤에 있을 때만 X-Forwarded-For를 믿음
+	if rl.trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			return strings.TrimSpace(strings.Split(xff, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// 메모리 저장소, 서버 하나일 때 사용
+// MemoryRateLimitStore keeps the buckets in process.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	sweep   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// NewMemoryRateLimitStore creates an in process store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*tokenBucket{}, sweep: time.Now()}
+}
+
+// Take one token from the bucket of the key.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, l RateLimit) (RateLimitResult, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 다시 가득 찬 버킷은 가끔씩 정리
+	if now.Sub(s.sweep) > time.Minute {
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.sweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.Requests), last: now}
+		s.buckets[key] = b
+	}
+
+	res := takeToken(&b.tokens, float64(l.Requests), l.rate(), now.Sub(b.last).Seconds())
+	b.last = now
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// 지난 시간만큼 토큰을 채우고 하나 꺼냄
+func takeToken(tokens *float64, capacity, rate, elapsed float64) RateLimitResult {
+	var res RateLimitResult
+	*tokens = math.Min(capacity, *tokens+elapsed*rate)
+	if *tokens >= 1 {
+		*tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - *tokens) / rate)
+	}
+
+	res.Remaining = int(*tokens)
+	res.Reset = seconds((capacity - *tokens) / rate)
+	return res
+}
+
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
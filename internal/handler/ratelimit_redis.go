@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// 토큰 버킷 Lua 스크립트, 여러 서버가 같은 시계를 쓰도록 Redis TIME 사용
+// KEYS[1] 버킷, ARGV[1] 최대 토큰, ARGV[2] 초당 토큰
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or capacity
+local ts = tonumber(b[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// Redis 호환 저장소 (Redis, Valkey, KeyDB ...), 여러 서버에서 버킷 공유
+// RedisRateLimitStore shares the buckets between instances through a Redis compatible server.
+type RedisRateLimitStore struct {
+	*redisClient
+}
+
+// NewRedisRateLimitStore from a redis://[:password@]host:port[/db] URL.
+func NewRedisRateLimitStore(rawurl string) (*RedisRateLimitStore, error) {
+	c, err := newRedisClient(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisRateLimitStore{c}, nil
+}
+
+// Take one token from the bucket of the key.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, l RateLimit) (RateLimitResult, error) {
+	var res RateLimitResult
+	reply, err := s.do(ctx, "EVAL", tokenBucketScript, "1", key,
+		strconv.Itoa(l.Requests), strconv.FormatFloat(l.rate(), 'f', -1, 64))
+	if err != nil {
+		return res, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return res, fmt.Errorf("unexpected rate limit script reply: %v", reply)
+	}
+
+	allowed, _ := values[0].(int64)
+	str, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return res, fmt.Errorf("could not parse rate limit tokens: %v", err)
+	}
+
+	res.Allowed = allowed == 1
+	res.Remaining = int(tokens)
+	res.Reset = seconds((float64(l.Requests) - tokens) / l.rate())
+	if !res.Allowed {
+		res.RetryAfter = seconds((1 - tokens) / l.rate())
+	}
+	return res, nil
+}
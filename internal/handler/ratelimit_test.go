@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]RateLimit
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: map[string]RateLimit{}},
+		{name: "rules", in: "POST /login=5/1m;GET /users=60/1h", want: map[string]RateLimit{
+			"POST /login": {Requests: 5, Per: time.Minute},
+			"GET /users":  {Requests: 60, Per: time.Hour},
+		}},
+		// 공백과 빈 규칙은 무시
+		{name: "spaces", in: " POST   /posts/:post_id/comments =30/30s ;; ", want: map[string]RateLimit{
+			"POST /posts/:post_id/comments": {Requests: 30, Per: time.Second * 30},
+		}},
+		{name: "missing limit", in: "POST /login", wantErr: true},
+		{name: "missing period", in: "POST /login=5", wantErr: true},
+		{name: "requests not a number", in: "POST /login=five/1m", wantErr: true},
+		{name: "zero requests", in: "POST /login=0/1m", wantErr: true},
+		{name: "negative requests", in: "POST /login=-1/1m", wantErr: true},
+		{name: "invalid period", in: "POST /login=5/minute", wantErr: true},
+		{name: "zero period", in: "POST /login=5/0s", wantErr: true},
+		{name: "one bad rule", in: "POST /login=5/1m;POST /users=5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimits(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("limits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitRuleOrder(t *testing.T) {
+	l := RateLimit{Requests: 1, Per: time.Minute}
+	rl := newRateLimiter(map[string]RateLimit{
+		"GET /posts/...":             l,
+		"GET /posts/:post_id":        l,
+		"GET /posts/feed":            l,
+		"GET /posts":                 l,
+		"GET /:username/...":         l,
+		"POST /posts/:post_id/likes": l,
+		"invalid":                    l,
+	}, NewMemoryRateLimitStore(), false)
+
+	if len(rl.rules) != 6 {
+		t.Errorf("%d rules, want 6 without the invalid one", len(rl.rules))
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/posts", "GET /posts"},
+		{"GET", "/posts/feed", "GET /posts/feed"},
+		{"GET", "/posts/12", "GET /posts/:post_id"},
+		{"GET", "/posts/12/comments", "GET /posts/..."},
+		{"GET", "/alice/posts", "GET /:username/..."},
+		{"POST", "/posts/12/likes", "POST /posts/:post_id/likes"},
+		{"POST", "/posts", ""},
+		{"DELETE", "/posts/12", ""},
+	}
+	for _, tt := range tests {
+		rule, ok := rl.match(tt.method, tt.path)
+		if ok != (tt.want != "") || rule.key != tt.want {
+			t.Errorf("match %s %s = %q, want %q", tt.method, tt.path, rule.key, tt.want)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern []string
+		path    []string
+		want    bool
+	}{
+		{[]string{"posts"}, []string{"posts"}, true},
+		{[]string{"posts"}, []string{"comments"}, false},
+		{[]string{"posts", ":post_id"}, []string{"posts", "12"}, true},
+		{[]string{"posts", ":post_id"}, []string{"posts"}, false},
+		{[]string{"posts", ":post_id"}, []string{"posts", "12", "comments"}, false},
+		{[]string{"posts", "..."}, []string{"posts"}, true},
+		{[]string{"posts", "..."}, []string{"posts", "12", "comments"}, true},
+		{[]string{"posts", "..."}, []string{"users", "12"}, false},
+		{[]string{"..."}, []string{""}, true},
+	}
+	for _, tt := range tests {
+		if got := matchSegments(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchSegments(%v, %v) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRateLimitStore()
+	l := RateLimit{Requests: 3, Per: time.Minute}
+
+	// 한 번에 Requests번까지
+	for i := 2; i >= 0; i-- {
+		res, err := s.Take(ctx, "a", l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !res.Allowed || res.Remaining != i {
+			t.Errorf("take = %+v, want allowed with %d remaining", res, i)
+		}
+	}
+
+	res, _ := s.Take(ctx, "a", l)
+	if res.Allowed || res.Remaining != 0 {
+		t.Errorf("take over burst = %+v, want denied", res)
+	}
+
+	// 토큰 하나는 20초마다
+	if res.RetryAfter <= time.Second*19 || res.RetryAfter > time.Second*20 {
+		t.Errorf("retry after = %v, want about 20s", res.RetryAfter)
+	}
+
+	if res.Reset <= time.Second*59 || res.Reset > time.Minute {
+		t.Errorf("reset = %v, want about 1m", res.Reset)
+	}
+
+	// 키마다 따로
+	if res, _ = s.Take(ctx, "b", l); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("take other key = %+v, want allowed with 2 remaining", res)
+	}
+
+	// 기다리면 다시 채워짐
+	fast := RateLimit{Requests: 1, Per: time.Millisecond * 50}
+	s.Take(ctx, "c", fast)
+	if res, _ = s.Take(ctx, "c", fast); res.Allowed {
+		t.Errorf("take before refill = %+v, want denied", res)
+	}
+
+	time.Sleep(time.Millisecond * 60)
+	if res, _ = s.Take(ctx, "c", fast); !res.Allowed {
+		t.Errorf("take after refill = %+v, want allowed", res)
+	}
+}
+
+func TestTakeToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    float64
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{name: "full", tokens: 10, allowed: true, remaining: 9},
+		{name: "empty", tokens: 0, retryAfter: time.Second},
+		{name: "half refilled", tokens: 0, elapsed: 0.5, retryAfter: time.Second / 2},
+		{name: "refilled", tokens: 0, elapsed: 1, allowed: true, remaining: 0},
+		// 가득 찬 뒤로는 더 쌓이지 않음
+		{name: "capped", tokens: 5, elapsed: 3600, allowed: true, remaining: 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := tt.tokens
+			res := takeToken(&tokens, 10, 1, tt.elapsed)
+			if res.Allowed != tt.allowed || res.Remaining != tt.remaining {
+				t.Errorf("take = %+v, want allowed %v with %d remaining", res, tt.allowed, tt.remaining)
+			}
+
+			if math.Abs(float64(res.RetryAfter-tt.retryAfter)) > float64(time.Millisecond) {
+				t.Errorf("retry after = %v, want %v", res.RetryAfter, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	rl := newRateLimiter(map[string]RateLimit{
+		"POST /login": {Requests: 1, Per: time.Minute},
+	}, NewMemoryRateLimitStore(), false)
+	h := rl.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(method, path, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("POST", "/login", "192.0.2.1"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("first login = %d %v, want 204 with 0 remaining", w.Code, w.Header())
+	}
+
+	w := serve("POST", "/login", "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second login = %d, want 429", w.Code)
+	}
+
+	want := map[string]string{
+		"Retry-After":      "60",
+		"RateLimit-Limit":  "1",
+		"RateLimit-Policy": "1;w=60",
+		"Content-Type":     "application/json; charset=utf-8",
+	}
+	for k, v := range want {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "too_many_requests" {
+		t.Errorf("body = %s, want too_many_requests", w.Body)
+	}
+
+	// 다른 IP와 제한 없는 라우트는 통과
+	if w = serve("POST", "/login", "192.0.2.2"); w.Code != http.StatusNoContent {
+		t.Errorf("login from other ip = %d, want 204", w.Code)
+	}
+
+	if w = serve("GET", "/login", "192.0.2.1"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unlimited route = %d %v, want 204 without rate limit headers", w.Code, w.Header())
+	}
+}
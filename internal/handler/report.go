@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+
+	"sodam/internal/service"
+)
+
+type createReportInput struct {
+	PostID    *int64  `json:"postId,omitempty"`
+	CommentID *int64  `json:"commentId,omitempty"`
+	Username  *string `json:"username,omitempty"`
+	ReviewID  *int64  `json:"reviewId,omitempty"`
+	Reason    string  `json:"reason"`
+	Note      string  `json:"note"`
+}
+
+// 신고 핸들러
+func (h *handler) createReport(w http.ResponseWriter, r *http.Request) {
+	var in createReportInput
+	defer r.Body.Close()
+
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	report, err := h.CreateReport(r.Context(), service.CreateReportInput(in))
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, report, http.StatusCreated)
+}
+
+// 관리자용 신고 목록 핸들러
+func (h *handler) reports(w http.ResponseWriter, r *http.Request) {
+	b := bind(r)
+	last := b.queryInt("last")
+	before := b.queryID("before")
+	status := b.query("status")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	rr, err := h.Reports(r.Context(), status, last, before)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+type resolveReportInput struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+// 신고 처리 핸들러
+func (h *handler) resolveReport(w http.ResponseWriter, r *http.Request) {
+	var in resolveReportInput
+	defer r.Body.Close()
+
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	b := bind(r)
+	reportID := b.pathID("report_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+	err := h.ResolveReport(ctx, reportID, in.Action, in.Note)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+)
+
+type createReviewInput struct {
+	Rating  int    `json:"rating"`
+	Content string `json:"content"`
+}
+
+// 주문한 게시물 후기 핸들러
+func (h *handler) createReview(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var in createReviewInput
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	b := bind(r)
+	postID := b.pathID("post_id")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	review, err := h.CreateReview(r.Context(), postID, in.Rating, in.Content)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, review, http.StatusCreated)
+}
+
+func (h *handler) reviews(w http.ResponseWriter, r *http.Request) {
+	b := bind(r)
+	postID := b.pathID("post_id")
+	last := b.queryInt("last")
+	before := b.queryID("before")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
+
+	rr, err := h.Reviews(r.Context(), postID, last, before)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
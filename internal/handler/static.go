@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 아바타는 web/static, 쇼핑몰 화면은 frontend에서 찾음 (앞에 있는 디렉토리 우선)
+	staticDirs = []string{filepath.Join("web", "static"), "frontend"}
+	// 없는 경로는 SPA처럼 index.html로 보냄
+	spaIndex = filepath.Join("frontend", "index.html")
+	// 이름에 해시가 들어간 파일 (app.3f2a9c1d.js 같은)
+	rxHashedName = regexp.MustCompile(`[._-][0-9a-fA-F]{8,}\.[0-9a-zA-Z]+$`)
+	// 업로드된 파일은 매번 새 이름으로 저장되기 때문에 바뀌지 않음
+	immutablePrefixes = []string{"/img/avatars/", "/img/media/"}
+)
+
+// 미리 압축해둔 파일 (kurly.mp4.br, base.css.gz ...)
+var precompressed = []struct {
+	encoding, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+type staticFiles struct {
+	dirs  []string
+	index string
+}
+
+func (sf staticFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upath := path.Clean("/" + r.URL.Path)
+
+	// 숨김 파일(.gitkeep 등)은 보여주지 않음
+	if strings.Contains(upath, "/.") {
+		http.NotFound(w, r)
+		return
+	}
+
+	for _, dir := range sf.dirs {
+		name := filepath.Join(dir, filepath.FromSlash(upath))
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+
+		if fi.IsDir() {
+			name = filepath.Join(name, "index.html")
+			if fi, err = os.Stat(name); err != nil || fi.IsDir() {
+				continue
+			}
+		}
+
+		serveFile(w, r, name, isImmutable(upath))
+		return
+	}
+
+	// 확장자가 없으면 화면 주소로 보고 index.html로 넘김
+	if path.Ext(upath) == "" && sf.index != "" {
+		serveFile(w, r, sf.index, false)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// serveFile with an ETag, cache headers and a precompressed variant when the client accepts it.
+// Range and conditional requests are handled by http.ServeContent, ranges always
+// come from the identity file.
+func serveFile(w http.ResponseWriter, r *http.Request, name string, immutable bool) {
+	f, err := os.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		respondError(w, fmt.Errorf("could not stat static file: %v", err))
+		return
+	}
+
+	// Range는 원래 파일의 바이트 위치라서 압축한 파일로는 보내지 않음
+	encoding := ""
+	accept := r.Header.Get("Accept-Encoding")
+	if r.Header.Get("Range") != "" {
+		accept = ""
+	}
+
+	for _, pc := range precompressed {
+		if !acceptsEncoding(accept, pc.encoding) {
+			continue
+		}
+
+		cf, err := os.Open(name + pc.ext)
+		if err != nil {
+			continue
+		}
+
+		cfi, err := cf.Stat()
+		if err != nil || cfi.IsDir() {
+			cf.Close()
+			continue
+		}
+
+		defer cf.Close()
+		f, fi, encoding = cf, cfi, pc.encoding
+		break
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	header.Set("ETag", fmt.Sprintf(`"%x-%x%s"`, fi.ModTime().UnixNano(), fi.Size(), encoding))
+	if immutable {
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	// 원래 이름을 넘겨야 .br, .gz가 아닌 원래 Content-Type이 나감
+	http.ServeContent(w, r, name, fi.ModTime(), f)
+}
+
+func isImmutable(upath string) bool {
+	for _, prefix := range immutablePrefixes {
+		if strings.HasPrefix(upath, prefix) {
+			return true
+		}
+	}
+
+	return rxHashedName.MatchString(path.Base(upath))
+}
+
+// Accept-Encoding: gzip, br;q=0 같은 값에서 q가 0이 아닌지 확인
+func acceptsEncoding(accept, encoding string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		if strings.TrimSpace(params[0]) != encoding {
+			continue
+		}
+
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err == nil && q == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
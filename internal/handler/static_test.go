@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 임시 디렉토리에 정적 파일을 만들고 그 디렉토리를 쓰는 핸들러
+func newTestStatic(t *testing.T, files map[string]string) staticFiles {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return staticFiles{dirs: []string{dir}, index: filepath.Join(dir, "index.html")}
+}
+
+func serveStatic(h http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestStaticETag(t *testing.T) {
+	h := newTestStatic(t, map[string]string{"css/base.css": "body{}"})
+
+	w := serveStatic(h, "/css/base.css", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != "body{}" {
+		t.Fatalf("status = %d, etag = %q, body = %q", w.Code, etag, w.Body)
+	}
+
+	if w = serveStatic(h, "/css/base.css", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %d, want 304", w.Code)
+	}
+
+	if w = serveStatic(h, "/css/base.css", map[string]string{"If-None-Match": `"other"`}); w.Code != http.StatusOK {
+		t.Errorf("stale If-None-Match status = %d, want 200", w.Code)
+	}
+}
+
+func TestStaticCacheControl(t *testing.T) {
+	h := newTestStatic(t, map[string]string{
+		"img/avatars/abc.jpg": "avatar",
+		"img/media/abc.jpg":   "media",
+		"js/app.3f2a9c1d.js":  "app",
+		"js/app.js":           "app",
+		"css/base.css":        "body{}",
+	})
+
+	tests := []struct {
+		path      string
+		immutable bool
+	}{
+		{"/img/avatars/abc.jpg", true},
+		{"/img/media/abc.jpg", true},
+		{"/js/app.3f2a9c1d.js", true},
+		{"/js/app.js", false},
+		{"/css/base.css", false},
+	}
+	for _, tt := range tests {
+		w := serveStatic(h, tt.path, nil)
+		got := w.Header().Get("Cache-Control")
+		if immutable := strings.Contains(got, "immutable"); w.Code != http.StatusOK || immutable != tt.immutable {
+			t.Errorf("%s: status = %d, Cache-Control = %q, want immutable %v", tt.path, w.Code, got, tt.immutable)
+		}
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	h := newTestStatic(t, map[string]string{
+		"css/base.css":    "body{}",
+		"css/base.css.br": "br",
+		"css/base.css.gz": "gz",
+		"css/main.css":    "main",
+		"css/main.css.gz": "gz",
+	})
+
+	tests := []struct {
+		path     string
+		accept   string
+		encoding string
+		body     string
+	}{
+		{"/css/base.css", "gzip, deflate, br", "br", "br"},
+		{"/css/base.css", "gzip", "gzip", "gz"},
+		{"/css/base.css", "gzip, br;q=0", "gzip", "gz"},
+		{"/css/base.css", "", "", "body{}"},
+		{"/css/main.css", "br", "", "main"},
+		{"/css/main.css", "br, gzip", "gzip", "gz"},
+	}
+	for _, tt := range tests {
+		w := serveStatic(h, tt.path, map[string]string{"Accept-Encoding": tt.accept})
+		if w.Header().Get("Content-Encoding") != tt.encoding || w.Body.String() != tt.body {
+			t.Errorf("%s with %q: Content-Encoding = %q, body = %q, want %q, %q",
+				tt.path, tt.accept, w.Header().Get("Content-Encoding"), w.Body, tt.encoding, tt.body)
+		}
+
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s with %q: Vary = %q", tt.path, tt.accept, w.Header().Get("Vary"))
+		}
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+			t.Errorf("%s with %q: Content-Type = %q, want text/css", tt.path, tt.accept, ct)
+		}
+	}
+}
+
+func TestStaticRange(t *testing.T) {
+	name := filepath.Join("..", "..", "frontend", "video", "kurly.mp4")
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := staticFiles{dirs: []string{filepath.Join("..", "..", "web", "static"), filepath.Join("..", "..", "frontend")}}
+	w := serveStatic(h, "/video/kurly.mp4", map[string]string{"Range": "bytes=100-199"})
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", w.Code)
+	}
+
+	if got := w.Header().Get("Content-Range"); got != "bytes 100-199/"+strconv.Itoa(len(b)) {
+		t.Errorf("Content-Range = %q", got)
+	}
+
+	if w.Body.String() != string(b[100:200]) {
+		t.Error("body is not bytes 100-199 of the file")
+	}
+}
+
+// 압축한 파일이 있어도 Range는 원래 파일에서
+func TestStaticRangeSkipsPrecompressed(t *testing.T) {
+	h := newTestStatic(t, map[string]string{
+		"video/clip.mp4":    "0123456789",
+		"video/clip.mp4.gz": "compressed",
+	})
+
+	w := serveStatic(h, "/video/clip.mp4", map[string]string{"Range": "bytes=2-4", "Accept-Encoding": "gzip"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("status = %d, Content-Encoding = %q, body = %q, want 206 identity 234",
+			w.Code, w.Header().Get("Content-Encoding"), w.Body)
+	}
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	h := newTestStatic(t, map[string]string{
+		"index.html":   "<html>index</html>",
+		"css/base.css": "body{}",
+		"shop/a.html":  "shop",
+		".gitkeep":     "",
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "<html>index</html>"},
+		{"/products/42", http.StatusOK, "<html>index</html>"},
+		{"/signup", http.StatusOK, "<html>index</html>"},
+		{"/shop/a.html", http.StatusOK, "shop"},
+		{"/css/missing.css", http.StatusNotFound, ""},
+		{"/img/avatars/missing.jpg", http.StatusNotFound, ""},
+		{"/.gitkeep", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serveStatic(h, tt.path, nil)
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s: status = %d, body = %q, want %d %q", tt.path, w.Code, w.Body, tt.status, tt.body)
+		}
+	}
+}
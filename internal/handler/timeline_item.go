@@ -2,21 +2,18 @@ package handler
 
 import (
 	"net/http"
-	"sodam/internal/service"
-	"strconv"
 )
 
 func (h *handler) timeline(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	q := r.URL.Query()
-	last, _ := strconv.Atoi(q.Get("last"))
-	before, _ := strconv.ParseInt(q.Get("before"), 10, 64)
-	tt, err := h.Timeline(ctx, last, before)
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	b := bind(r)
+	last := b.queryInt("last")
+	before := b.queryID("before")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
-
+	tt, err := h.Timeline(ctx, last, before)
 	if err != nil {
 		respondError(w, err)
 		return
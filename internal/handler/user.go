@@ -1,39 +1,28 @@
 package handler
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"net/http"
 	"sodam/internal/service"
-	"strconv"
 
 	"github.com/matryer/way"
 )
 
 type createUserInput struct {
-	Email, Username string
+	Email    string `json:"email"`
+	Username string `json:"username"`
 }
 
 func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
 	var in createUserInput
 	defer r.Body.Close()
 
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(w, r, &in); err != nil {
+		respondError(w, err)
 		return
 	}
 
 	err := h.CreateUser(r.Context(), in.Email, in.Username)
-	if err == service.ErrInvalidEmail || err == service.ErrInvalidUsername {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
-		return
-	}
-
-	if err == service.ErrEmailTaken || err == service.ErrUsernameTaken {
-		http.Error(w, err.Error(), http.StatusConflict)
-		return
-	}
-
 	if err != nil {
 		respondError(w, err)
 		return
@@ -45,10 +34,14 @@ func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
 //유저 검색 핸들러
 //user search handler
 func (h *handler) users(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
-	search := q.Get("search")
-	first, _ := strconv.Atoi(q.Get("first"))
-	after := q.Get("after")
+	b := bind(r)
+	search := b.query("search")
+	first := b.queryInt("first")
+	after := b.query("after")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
 	uu, err := h.Users(r.Context(), search, first, after)
 	if err != nil {
 		respondError(w, err)
@@ -64,16 +57,6 @@ func (h *handler) user(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	username := way.Param(ctx, "username")
 	u, err := h.User(ctx, username)
-	if err == service.ErrInvalidUsername {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
-		return
-	}
-
-	if err == service.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
 	if err != nil {
 		respondError(w, err)
 		return
@@ -83,27 +66,17 @@ func (h *handler) user(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) updateAvatar(w http.ResponseWriter, r *http.Request) {
-	// 아바타 용량 제한
-	r.Body = http.MaxBytesReader(w, r.Body, service.MaxAvatarBytes)
+	// 아바타 용량 제한, 한 바이트 더 받아서 서비스가 413으로 응답하도록
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxAvatarBytes+1)
 	defer r.Body.Close()
-	avatarURL, err := h.UpdateAvatar(r.Context(), r.Body)
-
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-
-	if err == service.ErrUnsupportedAvatarFormat {
-		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
-		return
-	}
+	avatar, err := h.UpdateAvatar(r.Context(), r.Body)
 
 	if err != nil {
 		respondError(w, err)
 		return
 	}
 
-	fmt.Fprint(w, avatarURL)
+	respond(w, avatar, http.StatusOK)
 }
 
 // 팔로우 핸들러
@@ -112,22 +85,27 @@ func (h *handler) toggleFollow(w http.ResponseWriter, r *http.Request) {
 	username := way.Param(ctx, "username")
 
 	out, err := h.ToggleFollow(ctx, username)
-	// 인증되지 않은 유저일 때
-	if err == service.ErrUnauthenticated {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	}
-	// 유저 이름이 맞지 않을 때
-	if err == service.ErrInvalidUsername {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
-		return
-	}
-	// 유저를 찾을 수 없을 때
-	if err == service.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	// 스스소를 팔로우 할 때
+	if err != nil {
+		respondError(w, err)
 		return
 	}
-	// 스스소를 팔로우 할 때
+
+	respond(w, out, http.StatusOK)
+}
+
+// PUT, DELETE는 여러 번 보내도 결과가 같음
+func (h *handler) follow(w http.ResponseWriter, r *http.Request) {
+	h.setFollow(w, r, h.Follow)
+}
+
+func (h *handler) unfollow(w http.ResponseWriter, r *http.Request) {
+	h.setFollow(w, r, h.Unfollow)
+}
+
+func (h *handler) setFollow(w http.ResponseWriter, r *http.Request, set func(context.Context, string) (service.ToggleFollowOutput, error)) {
+	ctx := r.Context()
+	out, err := set(ctx, way.Param(ctx, "username"))
 	if err != nil {
 		respondError(w, err)
 		return
@@ -140,16 +118,15 @@ func (h *handler) toggleFollow(w http.ResponseWriter, r *http.Request) {
 //follower search handler
 func (h *handler) followers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	q := r.URL.Query()
+	b := bind(r)
 	username := way.Param(ctx, "username")
-	first, _ := strconv.Atoi(q.Get("first"))
-	after := q.Get("after")
-	uu, err := h.Followers(ctx, username, first, after)
-
-	if err == service.ErrInvalidUsername {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	first := b.queryInt("first")
+	after := b.query("after")
+	if err := b.err(); err != nil {
+		respondError(w, err)
 		return
 	}
+	uu, err := h.Followers(ctx, username, first, after)
 
 	if err != nil {
 		respondError(w, err)
@@ -163,21 +140,50 @@ func (h *handler) followers(w http.ResponseWriter, r *http.Request) {
 //followee search handler
 func (h *handler) followees(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	q := r.URL.Query()
+	b := bind(r)
 	username := way.Param(ctx, "username")
-	first, _ := strconv.Atoi(q.Get("first"))
-	after := q.Get("after")
+	first := b.queryInt("first")
+	after := b.query("after")
+	if err := b.err(); err != nil {
+		respondError(w, err)
+		return
+	}
 	uu, err := h.Followees(ctx, username, first, after)
 
-	if err == service.ErrInvalidUsername {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	if err != nil {
+		respondError(w, err)
 		return
 	}
 
+	respond(w, uu, http.StatusOK)
+}
+
+//차단 핸들러
+//toggle block handler
+func (h *handler) toggleBlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := way.Param(ctx, "username")
+
+	out, err := h.ToggleBlock(ctx, username)
 	if err != nil {
 		respondError(w, err)
 		return
 	}
 
-	respond(w, uu, http.StatusOK)
+	respond(w, out, http.StatusOK)
+}
+
+//뮤트 핸들러
+//toggle mute handler
+func (h *handler) toggleMute(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := way.Param(ctx, "username")
+
+	out, err := h.ToggleMute(ctx, username)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	respond(w, out, http.StatusOK)
 }
@@ -2,11 +2,23 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+
+	"sodam/internal/service"
 )
 
+// multipart 요청을 메모리에 올려둘 최대 크기, 나머지는 임시 파일로 저장
+const maxMemory = 1 << 20 //1MB
+
+var errInternal = &service.Error{
+	Status:  http.StatusInternalServerError,
+	Code:    "internal",
+	Message: "internal server error",
+}
+
 func respond(w http.ResponseWriter, v interface{}, statusCode int) {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -19,7 +31,22 @@ func respond(w http.ResponseWriter, v interface{}, statusCode int) {
 	w.Write(b)
 }
 
+// 서비스 에러는 코드와 상태 그대로, 나머지는 로그를 남기고 500
 func respondError(w http.ResponseWriter, err error) {
-	log.Println(err)
-	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	var e *service.Error
+	if !errors.As(err, &e) {
+		log.Println(err)
+		e = errInternal
+	}
+
+	respond(w, e, e.Status)
+}
+
+// 요청 본문을 읽을 수 없을 때
+func badRequest(err error) error {
+	return &service.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "bad_request",
+		Message: err.Error(),
+	}
 }
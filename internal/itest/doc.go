@@ -0,0 +1,9 @@
+// Package itest runs the HTTP API end to end against a throwaway database.
+//
+// Everything lives in test files so none of it ends up in the server binary.
+// The tests are skipped unless SODAM_ITEST_DSN points at a cockroach (or
+// postgres) server, or COCKROACH_BIN at a cockroach binary to start a single
+// in-memory node from. Every test gets its own database.
+//
+//	SODAM_ITEST_DSN=postgresql://root@127.0.0.1:26257/?sslmode=disable go test ./internal/itest
+package itest
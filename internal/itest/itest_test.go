@@ -0,0 +1,236 @@
+package itest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sodam/internal/handler"
+	"sodam/internal/migrate"
+	"sodam/internal/service"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hako/branca"
+	_ "github.com/lib/pq"
+)
+
+// ErrNoDatabase is returned when neither SODAM_ITEST_DSN nor COCKROACH_BIN is set.
+var ErrNoDatabase = errors.New("itest: set SODAM_ITEST_DSN or COCKROACH_BIN to run")
+
+// 노드가 뜨기를 기다리는 시간
+const startTimeout = time.Second * 30
+
+// Cluster is a database server the harness creates databases in.
+type Cluster struct {
+	url   *url.URL
+	cmd   *exec.Cmd
+	dir   string
+	seq   int64
+	stamp int64
+}
+
+// Start connects to SODAM_ITEST_DSN if set,
+// or starts a single in-memory cockroach node from COCKROACH_BIN.
+func Start(ctx context.Context) (*Cluster, error) {
+	c := &Cluster{stamp: time.Now().Unix()}
+	if rawurl := os.Getenv("SODAM_ITEST_DSN"); rawurl != "" {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse SODAM_ITEST_DSN: %v", err)
+		}
+
+		c.url = u
+		return c, nil
+	}
+
+	bin := os.Getenv("COCKROACH_BIN")
+	if bin == "" {
+		return nil, ErrNoDatabase
+	}
+
+	dir, err := ioutil.TempDir("", "sodam-itest-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temp dir: %v", err)
+	}
+
+	c.dir = dir
+	urlFile := filepath.Join(dir, "url")
+	logFile, err := os.Create(filepath.Join(dir, "cockroach.log"))
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not create cockroach log: %v", err)
+	}
+
+	defer logFile.Close()
+
+	// 포트 0으로 띄우고 실제 주소는 listening-url-file에서 읽기
+	c.cmd = exec.Command(bin, "start-single-node",
+		"--insecure",
+		"--store=type=mem,size=1GiB",
+		"--listen-addr=127.0.0.1:0",
+		"--http-addr=127.0.0.1:0",
+		"--listening-url-file="+urlFile,
+	)
+	c.cmd.Dir = dir
+	c.cmd.Stdout = logFile
+	c.cmd.Stderr = logFile
+	if err = c.cmd.Start(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not start cockroach: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	for {
+		if b, err := ioutil.ReadFile(urlFile); err == nil && strings.HasSuffix(string(b), "\n") {
+			if c.url, err = url.Parse(strings.TrimSpace(string(b))); err != nil {
+				c.Close()
+				return nil, fmt.Errorf("could not parse cockroach url: %v", err)
+			}
+			return c, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			c.Close()
+			return nil, fmt.Errorf("cockroach did not start, see %s: %v", logFile.Name(), ctx.Err())
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+}
+
+// Close stops the node started by Start and removes its files.
+func (c *Cluster) Close() error {
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	}
+
+	if c.dir != "" {
+		return os.RemoveAll(c.dir)
+	}
+	return nil
+}
+
+// NewDB creates an empty database with a unique name and applies every migration.
+func (c *Cluster) NewDB(ctx context.Context) (*sql.DB, error) {
+	admin, err := sql.Open("postgres", c.url.String())
+	if err != nil {
+		return nil, fmt.Errorf("could not open db connection: %v", err)
+	}
+
+	defer admin.Close()
+
+	name := fmt.Sprintf("itest_%d_%d", c.stamp, atomic.AddInt64(&c.seq, 1))
+	if _, err = admin.ExecContext(ctx, "CREATE DATABASE "+name); err != nil {
+		return nil, fmt.Errorf("could not create database %s: %v", name, err)
+	}
+
+	u := *c.url
+	u.Path = "/" + name
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return nil, fmt.Errorf("could not open db connection: %v", err)
+	}
+
+	m, err := migrate.New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if _, err = m.Up(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not migrate %s: %v", name, err)
+	}
+
+	return db, nil
+}
+
+// Seed runs a fixtures file such as fixtures/dev.sql.
+func Seed(ctx context.Context, db *sql.DB, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read fixtures: %v", err)
+	}
+
+	if _, err = db.ExecContext(ctx, string(b)); err != nil {
+		return fmt.Errorf("could not seed fixtures: %v", err)
+	}
+	return nil
+}
+
+// Env is the API served over httptest on top of one store.
+type Env struct {
+	Service *service.Service
+	Server  *httptest.Server
+	dir     string
+
+	stopJobs context.CancelFunc
+	jobsDone sync.WaitGroup
+}
+
+// NewEnv serves handler.New over httptest, runs the background jobs
+// and relays the outbox events to pub, or to a service.EventBus that drops them if nil.
+// Uploaded files go to a temp dir that Close removes.
+func NewEnv(st service.Store, pub service.EventPublisher) (*Env, error) {
+	dir, err := ioutil.TempDir("", "sodam-itest-blobs-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temp dir: %v", err)
+	}
+
+	e := &Env{dir: dir}
+	e.Server = httptest.NewUnstartedServer(nil)
+	origin := "http://" + e.Server.Listener.Addr().String()
+
+	cdc := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	cdc.SetTTL(uint32(service.TokenLifespan.Seconds()))
+
+	e.Service = service.New(st, cdc, origin, service.NewLocalBlobStore(dir, origin+"/img"))
+	e.Server.Config.Handler = handler.New(e.Service)
+	e.Server.Start()
+
+	// 이벤트를 보지 않는 테스트는 받아서 버림
+	if pub == nil {
+		bus := service.NewEventBus()
+		bus.Subscribe(func(ctx context.Context, e service.Event) error { return nil })
+		pub = bus
+	}
+
+	var ctx context.Context
+	ctx, e.stopJobs = context.WithCancel(context.Background())
+	e.jobsDone.Add(2)
+	go func() {
+		defer e.jobsDone.Done()
+		e.Service.RunJobs(ctx, service.DefaultJobWorkers)
+	}()
+	go func() {
+		defer e.jobsDone.Done()
+		e.Service.RunOutbox(ctx, pub)
+	}()
+	return e, nil
+}
+
+// URL of the server, without the /api prefix.
+func (e *Env) URL() string {
+	return e.Server.URL
+}
+
+// Close stops the server, waits for the running jobs and the event relay
+// and removes uploaded files.
+func (e *Env) Close() error {
+	e.Server.Close()
+	e.stopJobs()
+	e.jobsDone.Wait()
+	return os.RemoveAll(e.dir)
+}
@@ -0,0 +1,115 @@
+package itest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"sodam/internal/service"
+)
+
+// 테스트 전체가 같이 쓰는 서버, 데이터베이스는 테스트마다 새로
+var cluster *Cluster
+
+func TestMain(m *testing.M) {
+	c, err := Start(context.Background())
+	if err != nil && !errors.Is(err, ErrNoDatabase) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	cluster = c
+	code := m.Run()
+	if cluster != nil {
+		cluster.Close()
+	}
+	os.Exit(code)
+}
+
+// 마이그레이션과 fixtures를 적용한 이 테스트만의 데이터베이스
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if cluster == nil {
+		t.Skip(ErrNoDatabase)
+	}
+
+	ctx := context.Background()
+	db, err := cluster.NewDB(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	if err = Seed(ctx, db, filepath.Join("..", "..", "fixtures", "dev.sql")); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// playground.http의 요청을 차례로 보내고 "# @expect" 상태 코드와 비교,
+// 요청이 남긴 이벤트가 NATS 대역까지 왔는지도 확인
+func TestPlayground(t *testing.T) {
+	p, err := ParsePlayground(filepath.Join("..", "..", "playground.http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t)
+
+	nats, err := StartNATS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer nats.Close()
+
+	pub, err := service.NewNATSPublisher(nats.URL(), "sodam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pub.Close()
+
+	e, err := NewEnv(service.NewPGStore(db), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer e.Close()
+
+	for _, r := range p.Run(context.Background(), e.URL()) {
+		if r.Expect == 0 {
+			t.Errorf("line %d %s %s: no # @expect status", r.Line, r.Method, r.URL)
+			continue
+		}
+
+		if r.Failed() {
+			t.Errorf("line %d %s %s: status %d, want %d: %v %s", r.Line, r.Method, r.URL, r.Status, r.Expect, r.Err, r.Body)
+		}
+	}
+
+	mm := nats.Wait(time.Second, time.Second*30)
+	if len(mm) == 0 {
+		t.Fatal("no events were published")
+	}
+
+	subjects := map[string]int{}
+	for _, m := range mm {
+		subjects[m.Subject]++
+	}
+
+	var ss []string
+	for subject, n := range subjects {
+		ss = append(ss, fmt.Sprintf("%s=%d", subject, n))
+	}
+	sort.Strings(ss)
+	t.Logf("events: %s", strings.Join(ss, " "))
+}
@@ -0,0 +1,89 @@
+package itest
+
+import (
+	"context"
+	"testing"
+
+	"sodam/internal/migrate"
+)
+
+// 유니크 인덱스 전에 쌓인 중복 공유는 0005에서 가장 오래된 공유로 합치고
+// 딸린 댓글, 좋아요, 타임라인은 지우지 않고 옮김
+func TestMigrateDuplicateReposts(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for _, s := range ss {
+		if s.Version >= 5 {
+			n++
+		}
+	}
+
+	if _, err = m.Down(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO users (id, email, username) VALUES
+			(9001, 'author@itest.local', 'itest_author'),
+			(9002, 'sharer@itest.local', 'itest_sharer'),
+			(9003, 'reader@itest.local', 'itest_reader');
+		INSERT INTO posts (id, user_id, content, reposts_count) VALUES (9101, 9001, 'original', 4);
+		INSERT INTO posts (id, user_id, content, repost_of_id, likes_count, comments_count) VALUES
+			(9102, 9002, '', 9101, 1, 0),
+			(9103, 9002, '', 9101, 2, 0),
+			(9104, 9002, 'quoted', 9101, 0, 0),
+			(9105, 9002, '', 9101, 0, 1);
+		INSERT INTO timeline (user_id, post_id) VALUES (9003, 9102), (9003, 9103), (9003, 9105);
+		INSERT INTO post_likes (user_id, post_id) VALUES (9003, 9102), (9003, 9103), (9001, 9103);
+		INSERT INTO comments (id, user_id, post_id, content) VALUES (9201, 9003, 9105, 'on a duplicate');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var posts, reposts, likes, comments, timeline int
+	query := "SELECT count(*) FROM posts WHERE id BETWEEN 9102 AND 9105"
+	if err = db.QueryRowContext(ctx, query).Scan(&posts); err != nil {
+		t.Fatal(err)
+	}
+
+	query = "SELECT reposts_count FROM posts WHERE id = 9101"
+	if err = db.QueryRowContext(ctx, query).Scan(&reposts); err != nil {
+		t.Fatal(err)
+	}
+
+	if posts != 2 || reposts != 2 {
+		t.Errorf("reposts left = %d, reposts_count = %d, want the first plain repost and the quote", posts, reposts)
+	}
+
+	query = "SELECT likes_count, comments_count, (SELECT count(*) FROM timeline WHERE post_id = 9102) FROM posts WHERE id = 9102"
+	if err = db.QueryRowContext(ctx, query).Scan(&likes, &comments, &timeline); err != nil {
+		t.Fatal(err)
+	}
+
+	// 9003의 좋아요 둘과 타임라인 셋은 하나로, 9001의 좋아요와 댓글은 옮김
+	if likes != 2 || comments != 1 || timeline != 1 {
+		t.Errorf("kept repost likes = %d, comments = %d, timeline rows = %d, want 2, 1, 1", likes, comments, timeline)
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO posts (user_id, content, repost_of_id) VALUES (9002, '', 9101)")
+	if err == nil {
+		t.Error("a second plain repost was saved after the migration")
+	}
+}
@@ -0,0 +1,119 @@
+package itest
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"sodam/internal/service"
+)
+
+// NATS 대역과 그곳에 연결할 발행자
+func newTestNATS(t *testing.T) (*NATSServer, *service.NATSPublisher) {
+	t.Helper()
+	nats, err := StartNATS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { nats.Close() })
+
+	pub, err := service.NewNATSPublisher(nats.URL(), "sodam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { pub.Close() })
+	return nats, pub
+}
+
+func TestNATSPublisher(t *testing.T) {
+	nats, pub := newTestNATS(t)
+	ctx := context.Background()
+
+	ee := []service.Event{
+		{ID: 1, Type: service.EventUserCreated, Payload: json.RawMessage(`{"user_id":1}`)},
+		{ID: 2, Type: service.EventPostCreated, Payload: json.RawMessage(`{"post_id":1}`)},
+		// 지우기 전에 죽은 릴레이가 다시 보낸 이벤트
+		{ID: 1, Type: service.EventUserCreated, Payload: json.RawMessage(`{"user_id":1}`)},
+	}
+	for _, e := range ee {
+		if err := pub.Publish(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mm := nats.Messages()
+	if len(mm) != 2 {
+		t.Fatalf("stored %d messages, want 2 without the duplicate", len(mm))
+	}
+
+	for i, m := range mm {
+		want := ee[i]
+		if m.Subject != "sodam."+want.Type || m.MsgID != strconv.FormatInt(want.ID, 10) {
+			t.Errorf("message %d = %s %s, want sodam.%s %d", i, m.Subject, m.MsgID, want.Type, want.ID)
+		}
+
+		var got service.Event
+		if err := json.Unmarshal(m.Data, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.ID != want.ID || got.Type != want.Type || string(got.Payload) != string(want.Payload) {
+			t.Errorf("message %d data = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestNATSPublisherReconnect(t *testing.T) {
+	nats, pub := newTestNATS(t)
+	ctx := context.Background()
+
+	if err := pub.Publish(ctx, service.Event{ID: 1, Type: service.EventUserCreated}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 끊긴 연결로 보낸 한 번은 실패할 수 있고, 다음에는 다시 연결해서 보냄
+	nats.Disconnect()
+	var err error
+	for i := 0; i < 2; i++ {
+		if err = pub.Publish(ctx, service.Event{ID: 2, Type: service.EventUserCreated}); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("publish after disconnect: %v", err)
+	}
+
+	if mm := nats.Messages(); len(mm) != 2 || mm[1].MsgID != "2" {
+		t.Errorf("messages = %+v, want events 1 and 2", mm)
+	}
+
+	nats.Close()
+	if err = pub.Publish(ctx, service.Event{ID: 3, Type: service.EventUserCreated}); err == nil {
+		t.Error("publish with the server down succeeded")
+	}
+}
+
+// 데이터베이스 없이 메모리 저장소의 outbox를 릴레이로 NATS까지
+func TestOutboxToNATS(t *testing.T) {
+	nats, pub := newTestNATS(t)
+
+	e, err := NewEnv(service.NewMemoryStore(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer e.Close()
+
+	if err = e.Service.CreateUser(context.Background(), "alice@example.org", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	mm := nats.Wait(time.Millisecond*200, time.Second*10)
+	if len(mm) != 1 || mm[0].Subject != "sodam."+service.EventUserCreated {
+		t.Errorf("messages = %+v, want one user.created", mm)
+	}
+}
@@ -0,0 +1,268 @@
+package itest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSMessage stored by NATSServer.
+type NATSMessage struct {
+	Subject string
+	MsgID   string
+	Data    []byte
+}
+
+// 컨테이너 없이 띄우는 NATS 대역
+// NATSServer is a stand-in for a NATS server with one JetStream stream that
+// captures every subject. Publishes with a reply subject get a PubAck, and a
+// Nats-Msg-Id seen before is acked as a duplicate without being stored again.
+// Messages only go back to subscribers on the publishing connection.
+type NATSServer struct {
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	msgs  []NATSMessage
+	ids   map[string]bool
+	last  time.Time
+}
+
+// StartNATS listens on a random local port.
+func StartNATS() (*NATSServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("could not listen for nats: %v", err)
+	}
+
+	s := &NATSServer{
+		ln:    ln,
+		conns: map[net.Conn]struct{}{},
+		ids:   map[string]bool{},
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns[c] = struct{}{}
+			s.mu.Unlock()
+
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(c)
+
+				s.mu.Lock()
+				delete(s.conns, c)
+				s.mu.Unlock()
+				c.Close()
+			}()
+		}
+	}()
+	return s, nil
+}
+
+// URL to give to service.NewNATSPublisher.
+func (s *NATSServer) URL() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+// Messages stored so far, in order.
+func (s *NATSServer) Messages() []NATSMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]NATSMessage(nil), s.msgs...)
+}
+
+// Wait until something was stored and then nothing for quiet, or until timeout.
+// It returns the messages.
+func (s *NATSServer) Wait(quiet, timeout time.Duration) []NATSMessage {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		started, idle := !s.last.IsZero(), time.Since(s.last)
+		s.mu.Unlock()
+		if started && idle >= quiet {
+			break
+		}
+		time.Sleep(quiet / 10)
+	}
+	return s.Messages()
+}
+
+// Disconnect drops the open connections and keeps listening.
+func (s *NATSServer) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		c.Close()
+	}
+}
+
+// Close stops listening and drops the connections.
+func (s *NATSServer) Close() error {
+	err := s.ln.Close()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *NATSServer) serve(c net.Conn) {
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	info := `{"server_id":"itest","version":"2.10.0","proto":1,"headers":true,"jetstream":true,"max_payload":1048576}`
+	if _, err := w.WriteString("INFO " + info + "\r\n"); err != nil || w.Flush() != nil {
+		return
+	}
+
+	// sid별 구독 주제
+	subs := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(f[0]) {
+		case "CONNECT", "PONG":
+		case "PING":
+			w.WriteString("PONG\r\n")
+		case "SUB":
+			// SUB <subject> [queue] <sid>
+			if len(f) < 3 {
+				w.WriteString("-ERR 'Unknown Protocol Operation'\r\n")
+				break
+			}
+			subs[f[len(f)-1]] = f[1]
+		case "UNSUB":
+			if len(f) > 1 {
+				delete(subs, f[1])
+			}
+		case "PUB", "HPUB":
+			reply, hdr, data, err := readNATSMessage(r, f)
+			if err != nil {
+				w.WriteString("-ERR 'Unknown Protocol Operation'\r\n")
+				w.Flush()
+				return
+			}
+
+			ack := s.store(f[1], natsMsgID(hdr), data)
+			if reply == "" {
+				break
+			}
+
+			for sid, subject := range subs {
+				if natsSubjectMatch(subject, reply) {
+					fmt.Fprintf(w, "MSG %s %s %d\r\n%s\r\n", reply, sid, len(ack), ack)
+				}
+			}
+		default:
+			w.WriteString("-ERR 'Unknown Protocol Operation'\r\n")
+		}
+
+		if err = w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *NATSServer) store(subject, msgID string, data []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	duplicate := msgID != "" && s.ids[msgID]
+	if !duplicate {
+		if msgID != "" {
+			s.ids[msgID] = true
+		}
+		s.msgs = append(s.msgs, NATSMessage{Subject: subject, MsgID: msgID, Data: data})
+		s.last = time.Now()
+	}
+
+	ack, _ := json.Marshal(map[string]interface{}{
+		"stream":    "EVENTS",
+		"seq":       len(s.msgs),
+		"duplicate": duplicate,
+	})
+	return ack
+}
+
+// PUB <subject> [reply] <#bytes>, HPUB <subject> [reply] <#header bytes> <#total bytes>
+func readNATSMessage(r *bufio.Reader, f []string) (reply string, hdr, data []byte, err error) {
+	hdrLen, n := 0, 3
+	if strings.ToUpper(f[0]) == "HPUB" {
+		n = 4
+	}
+
+	if len(f) != n && len(f) != n+1 {
+		return "", nil, nil, fmt.Errorf("invalid %s", f[0])
+	}
+
+	if len(f) == n+1 {
+		reply = f[2]
+	}
+
+	totalLen, err := strconv.Atoi(f[len(f)-1])
+	if err == nil && n == 4 {
+		hdrLen, err = strconv.Atoi(f[len(f)-2])
+	}
+	if err != nil || hdrLen > totalLen {
+		return "", nil, nil, fmt.Errorf("invalid %s size", f[0])
+	}
+
+	buf := make([]byte, totalLen+2)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return "", nil, nil, err
+	}
+
+	return reply, buf[:hdrLen], buf[hdrLen:totalLen], nil
+}
+
+func natsMsgID(hdr []byte) string {
+	for _, line := range strings.Split(string(hdr), "\r\n") {
+		if i := strings.Index(line, ":"); i != -1 && strings.EqualFold(strings.TrimSpace(line[:i]), "Nats-Msg-Id") {
+			return strings.TrimSpace(line[i+1:])
+		}
+	}
+	return ""
+}
+
+// "*"는 토큰 하나, ">"는 나머지 전부
+func natsSubjectMatch(pattern, subject string) bool {
+	pp, ss := strings.Split(pattern, "."), strings.Split(subject, ".")
+	for i, p := range pp {
+		if p == ">" {
+			return len(ss) > i
+		}
+		if i >= len(ss) || (p != "*" && p != ss[i]) {
+			return false
+		}
+	}
+	return len(pp) == len(ss)
+}
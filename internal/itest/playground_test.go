@@ -0,0 +1,338 @@
+package itest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 요청 하나에 걸리는 최대 시간, SSE 요청은 헤더까지만 기다림
+const requestTimeout = time.Second * 10
+
+var (
+	// {{login.response.body.token}}
+	rxVariable = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+	// @Host = http://localhost:3000
+	rxFileVariable = regexp.MustCompile(`^@(\w+)\s*=\s*(.*)$`)
+	// # @name login
+	rxRequestName = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\w+)`)
+	// # @expect 201
+	rxExpect = regexp.MustCompile(`^(?:#|//)\s*@expect\s+(\d{3})$`)
+)
+
+// Request is one entry of a REST Client .http file.
+type Request struct {
+	Name   string
+	Line   int
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+	// "# @expect" 상태 코드, 0이면 지정 안 됨
+	Expect int
+}
+
+// Result of sending a Request.
+type Result struct {
+	Request
+	Status int
+	Body   []byte
+	Err    error
+}
+
+// Failed reports whether the request errored or the server answered
+// with another status than the expected one, or 5xx if none is expected.
+func (r Result) Failed() bool {
+	if r.Err != nil {
+		return true
+	}
+
+	if r.Expect != 0 {
+		return r.Status != r.Expect
+	}
+	return r.Status >= http.StatusInternalServerError
+}
+
+// Playground is a parsed .http file like playground.http.
+type Playground struct {
+	Vars     map[string]string
+	Requests []Request
+	dir      string
+}
+
+// ParsePlayground reads a .http file.
+// Bodies of the form "< path" are read relative to the file.
+func ParsePlayground(path string) (*Playground, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read playground: %v", err)
+	}
+
+	p := &Playground{Vars: map[string]string{}, dir: filepath.Dir(path)}
+
+	var (
+		req    *Request
+		name   string
+		expect int
+		inBody bool
+		body   []string
+	)
+	flush := func() {
+		if req != nil {
+			req.Body = strings.TrimRight(strings.Join(body, "\n"), "\n")
+			p.Requests = append(p.Requests, *req)
+		}
+		req, name, expect, inBody, body = nil, "", 0, false, nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "###") {
+			flush()
+			continue
+		}
+
+		if req == nil {
+			if m := rxRequestName.FindStringSubmatch(trimmed); m != nil {
+				name = m[1]
+				continue
+			}
+
+			if m := rxExpect.FindStringSubmatch(trimmed); m != nil {
+				expect, _ = strconv.Atoi(m[1])
+				continue
+			}
+
+			if m := rxFileVariable.FindStringSubmatch(trimmed); m != nil {
+				p.Vars[m[1]] = strings.TrimSpace(m[2])
+				continue
+			}
+
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+				continue
+			}
+
+			parts := strings.Fields(trimmed)
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("invalid request line %d: %q", n, trimmed)
+			}
+
+			req = &Request{Name: name, Line: n, Method: parts[0], URL: parts[1], Header: http.Header{}, Expect: expect}
+			continue
+		}
+
+		if inBody {
+			body = append(body, line)
+			continue
+		}
+
+		if trimmed == "" {
+			inBody = true
+			continue
+		}
+
+		i := strings.Index(trimmed, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid header line %d: %q", n, trimmed)
+		}
+
+		req.Header.Add(strings.TrimSpace(trimmed[:i]), strings.TrimSpace(trimmed[i+1:]))
+	}
+
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan playground: %v", err)
+	}
+
+	flush()
+	return p, nil
+}
+
+// Run sends every request in order against baseURL, which replaces {{Host}}.
+// Named responses can be referenced by later requests as {{name.response.body.field}}.
+func (p *Playground) Run(ctx context.Context, baseURL string) []Result {
+	vars := map[string]string{}
+	for k, v := range p.Vars {
+		vars[k] = v
+	}
+	vars["Host"] = strings.TrimRight(baseURL, "/")
+
+	responses := map[string]interface{}{}
+	client := &http.Client{Timeout: requestTimeout}
+
+	var rr []Result
+	for _, req := range p.Requests {
+		res := Result{Request: req}
+		res.Status, res.Body, res.Err = p.send(ctx, client, req, vars, responses)
+		if res.Err == nil && req.Name != "" {
+			var v interface{}
+			if json.Unmarshal(res.Body, &v) == nil {
+				responses[req.Name] = v
+			}
+		}
+
+		rr = append(rr, res)
+	}
+	return rr
+}
+
+func (p *Playground) send(
+	ctx context.Context,
+	client *http.Client,
+	req Request,
+	vars map[string]string,
+	responses map[string]interface{},
+) (int, []byte, error) {
+	expand := func(s string) string {
+		return rxVariable.ReplaceAllStringFunc(s, func(m string) string {
+			key := rxVariable.FindStringSubmatch(m)[1]
+			if v, ok := vars[key]; ok {
+				return v
+			}
+			return lookupResponse(responses, key)
+		})
+	}
+
+	body, err := p.body(expand(req.Body), req.Header.Get("Content-Type"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, req.Method, expand(req.URL), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("could not create request: %v", err)
+	}
+
+	for k, vv := range req.Header {
+		for _, v := range vv {
+			r.Header.Add(k, expand(v))
+		}
+	}
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	// 구독은 끝나지 않으므로 상태 코드만 확인
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return resp.StatusCode, nil, nil
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("could not read response: %v", err)
+	}
+
+	return resp.StatusCode, b, nil
+}
+
+// "< path" 줄은 파일 내용으로 바꾸고, multipart는 CRLF로 보냄
+func (p *Playground) body(s, contentType string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	newline := "\n"
+	if strings.HasPrefix(contentType, "multipart/") {
+		newline = "\r\n"
+	}
+
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			buf.WriteString(newline)
+		}
+
+		if strings.HasPrefix(line, "< ") {
+			b, err := ioutil.ReadFile(filepath.Join(p.dir, strings.TrimSpace(line[2:])))
+			if err != nil {
+				return nil, fmt.Errorf("could not read request body file: %v", err)
+			}
+
+			buf.Write(b)
+			continue
+		}
+
+		buf.WriteString(line)
+	}
+
+	if strings.HasPrefix(contentType, "multipart/") {
+		buf.WriteString(newline)
+	}
+	return buf.Bytes(), nil
+}
+
+// login.response.body.token
+func lookupResponse(responses map[string]interface{}, key string) string {
+	parts := strings.Split(key, ".")
+	if len(parts) < 3 || parts[1] != "response" || parts[2] != "body" {
+		return ""
+	}
+
+	v, ok := responses[parts[0]]
+	if !ok {
+		return ""
+	}
+
+	for _, part := range parts[3:] {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v = t[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(t) {
+				return ""
+			}
+			v = t[i]
+		default:
+			return ""
+		}
+	}
+
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	default:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+}
+
+// WriteReport prints one line per result and returns the number of failures.
+func WriteReport(w io.Writer, rr []Result) int {
+	var failed int
+	for _, r := range rr {
+		mark := "ok  "
+		if r.Failed() {
+			mark = "FAIL"
+			failed++
+		}
+
+		status := strconv.Itoa(r.Status)
+		if r.Err != nil {
+			status = r.Err.Error()
+		} else if r.Expect != 0 && r.Status != r.Expect {
+			status += " (expected " + strconv.Itoa(r.Expect) + ")"
+		}
+
+		fmt.Fprintf(w, "%s line %-4d %-6s %s %s\n", mark, r.Line, r.Method, r.URL, status)
+	}
+	return failed
+}
@@ -0,0 +1,73 @@
+package itest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sodam/internal/service"
+)
+
+// 넣은 항목의 timeline 아이디가 합치는 게시물 아이디와 겹쳐도
+// before 커서가 항목을 건너뛰거나 되풀이하지 않음.
+// 합치는 게시물은 작성자 타임라인의 항목 아이디를 씀
+func TestTimelinePagesPushedAndPulled(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO users (id, email, username) VALUES
+			(9001, 'reader@itest.local', 'itest_reader'),
+			(9002, 'pushed@itest.local', 'itest_pushed'),
+			(9003, 'pulled@itest.local', 'itest_pulled');
+		INSERT INTO follows (follower_id, followee_id) VALUES (9001, 9002), (9001, 9003);
+		INSERT INTO posts (id, user_id, content, pulled, created_at) VALUES
+			(9101, 9002, 'pushed 1', false, '2021-01-01 00:00:01'),
+			(9102, 9003, 'pulled 1', true, '2021-01-01 00:00:02'),
+			(9103, 9002, 'pushed 2', false, '2021-01-01 00:00:03'),
+			(9104, 9003, 'pulled 2', true, '2021-01-01 00:00:04'),
+			(9105, 9002, 'pushed 3', false, '2021-01-01 00:00:05'),
+			(9106, 9003, 'pulled 3', true, '2021-01-01 00:00:06');
+		INSERT INTO timeline (id, user_id, post_id) VALUES
+			(9102, 9001, 9101),
+			(9104, 9001, 9103),
+			(9106, 9001, 9105),
+			(9201, 9003, 9102),
+			(9202, 9003, 9104),
+			(9203, 9003, 9106);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := service.NewPGStore(db)
+	want := []int64{9106, 9105, 9104, 9103, 9102, 9101}
+	wantIDs := []int64{9203, 9106, 9202, 9104, 9201, 9102}
+
+	var got, gotIDs []int64
+	var before int64
+	for page := 0; page < len(want); page++ {
+		tt, err := st.Timeline(ctx, 9001, 2, before)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tt) == 0 {
+			break
+		}
+
+		for _, ti := range tt {
+			got = append(got, ti.Post.ID)
+			gotIDs = append(gotIDs, ti.ID)
+		}
+		before = tt[len(tt)-1].ID
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("timeline posts = %v, want %v", got, want)
+	}
+
+	if fmt.Sprint(gotIDs) != fmt.Sprint(wantIDs) {
+		t.Errorf("timeline item ids = %v, want %v", gotIDs, wantIDs)
+	}
+}
@@ -0,0 +1,424 @@
+// Package migrate applies the versioned SQL migrations embedded in the binary.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+var (
+	// ErrLocked is returned when another instance kept the lock past the timeout.
+	ErrLocked = errors.New("migrations are locked by another instance")
+	// ErrLockLost is returned when the lock was taken over while migrations were running.
+	ErrLockLost = errors.New("migrations lock was lost while running")
+
+	// 0001_init.up.sql
+	rxFilename = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+const (
+	// 다른 인스턴스가 죽어서 남은 락은 이 시간이 지나면 무시
+	staleLock = time.Minute * 15
+	// 락을 잡은 동안 locked_at을 갱신하는 간격, staleLock보다 충분히 짧게
+	lockHeartbeat = time.Minute
+)
+
+// Migration is one numbered schema change.
+// Checksum is taken from the up SQL so edits to applied migrations are caught.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status of a migration in the database.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator runs migrations against a database.
+type Migrator struct {
+	db          *sql.DB
+	migrations  []Migration
+	holder      string
+	lockTimeout time.Duration
+	heartbeat   time.Duration
+}
+
+// New creates a Migrator with the embedded migrations.
+func New(db *sql.DB) (*Migrator, error) {
+	mm, err := Load(embedded)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:          db,
+		migrations:  mm,
+		holder:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		lockTimeout: time.Minute,
+		heartbeat:   lockHeartbeat,
+	}, nil
+}
+
+// Load reads NNNN_name.up.sql and NNNN_name.down.sql pairs from fsys in version order.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("could not list migrations: %v", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, name := range names {
+		match := rxFilename.FindStringSubmatch(path.Base(name))
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration filename %q", name)
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, m.Name, match[2])
+		}
+
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read migration %q: %v", name, err)
+		}
+
+		if match[3] == "up" {
+			m.Up = string(b)
+			sum := sha256.Sum256(b)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	mm := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+		mm = append(mm, *m)
+	}
+
+	sort.Slice(mm, func(i, j int) bool {
+		return mm[i].Version < mm[j].Version
+	})
+	return mm, nil
+}
+
+// Up applies every pending migration in order and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+
+			if err := m.run(ctx, mig, mig.Up, true); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last n applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d has no down file", mig.Version)
+			}
+
+			if err := m.run(ctx, mig, mig.Down, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every migration with the time it was applied, nil when pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTables(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		ss[i].Migration = mig
+		if a, ok := done[mig.Version]; ok {
+			appliedAt := a.appliedAt
+			ss[i].AppliedAt = &appliedAt
+		}
+	}
+	return ss, nil
+}
+
+// 마이그레이션과 기록을 한 트랜잭션으로
+func (m *Migrator) run(ctx context.Context, mig Migration, query string, up bool) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("could not run migration %d_%s: %v", mig.Version, mig.Name, err)
+	}
+
+	if up {
+		query = "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)"
+		_, err = tx.ExecContext(ctx, query, mig.Version, mig.Name, mig.Checksum)
+	} else {
+		query = "DELETE FROM schema_migrations WHERE version = $1"
+		_, err = tx.ExecContext(ctx, query, mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("could not record migration %d_%s: %v", mig.Version, mig.Name, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit migration %d_%s: %v", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// 적용된 마이그레이션을 읽고 파일과 다른지 확인
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("could not query select schema migrations: %v", err)
+	}
+
+	defer rows.Close()
+
+	done := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err = rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("could not scan schema migration: %v", err)
+		}
+		done[version] = a
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate schema migrations: %v", err)
+	}
+
+	known := map[int]bool{}
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		if a, ok := done[mig.Version]; ok && a.checksum != mig.Checksum {
+			return nil, fmt.Errorf("migration %d_%s was changed after it was applied", mig.Version, mig.Name)
+		}
+	}
+
+	for version := range done {
+		if !known[version] {
+			return nil, fmt.Errorf("migration %d is applied but missing from this build", version)
+		}
+	}
+
+	return done, nil
+}
+
+func (m *Migrator) createTables(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT NOT NULL PRIMARY KEY,
+			name VARCHAR NOT NULL,
+			checksum VARCHAR NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT now()
+		);
+		CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INT NOT NULL PRIMARY KEY,
+			holder VARCHAR NOT NULL,
+			locked_at TIMESTAMP NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema migrations tables: %v", err)
+	}
+	return nil
+}
+
+// 여러 인스턴스가 동시에 실행하지 않도록 락 행을 잡고 실행
+// cockroachDB에는 advisory lock이 없어서 행 하나로 대신함
+// fn이 staleLock보다 오래 걸려도 락이 풀리지 않도록 실행하는 동안 locked_at을 갱신하고,
+// 그래도 락을 잃으면 fn의 ctx를 취소함
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := m.createTables(ctx); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		locked, err := m.lock(ctx)
+		if err != nil {
+			return err
+		}
+
+		if locked {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	defer m.unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lost error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if lost = m.refreshLock(ctx); lost != nil {
+			cancel()
+		}
+	}()
+
+	err := fn(ctx)
+	cancel()
+	<-done
+
+	// 다 적용한 뒤에 알게 됐으면 결과는 그대로
+	if err != nil && lost != nil {
+		return lost
+	}
+	return err
+}
+
+// ctx가 끝날 때까지 locked_at을 갱신, 다른 인스턴스가 락을 가져갔으면 ErrLockLost
+func (m *Migrator) refreshLock(ctx context.Context) error {
+	t := time.NewTicker(m.heartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return nil
+		}
+
+		query := "UPDATE schema_migrations_lock SET locked_at = now() WHERE id = 1 AND holder = $1"
+		res, err := m.db.ExecContext(ctx, query, m.holder)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			// 잠깐의 연결 문제는 다음 갱신에서 다시 시도
+			log.Printf("could not refresh migrations lock: %v\n", err)
+			continue
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			log.Printf("could not check migrations lock: %v\n", err)
+			continue
+		}
+
+		if n == 0 {
+			return ErrLockLost
+		}
+	}
+}
+
+func (m *Migrator) lock(ctx context.Context) (bool, error) {
+	query := "DELETE FROM schema_migrations_lock WHERE id = 1 AND locked_at < now() - $1::INTERVAL"
+	stale := fmt.Sprintf("%d seconds", int(staleLock.Seconds()))
+	if _, err := m.db.ExecContext(ctx, query, stale); err != nil {
+		return false, fmt.Errorf("could not delete stale migrations lock: %v", err)
+	}
+
+	query = "INSERT INTO schema_migrations_lock (id, holder) VALUES (1, $1) ON CONFLICT DO NOTHING"
+	res, err := m.db.ExecContext(ctx, query, m.holder)
+	if err != nil {
+		return false, fmt.Errorf("could not insert migrations lock: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("could not check migrations lock: %v", err)
+	}
+	return n == 1, nil
+}
+
+// 실행 중 ctx가 취소돼도 락은 풀어야 함
+func (m *Migrator) unlock() {
+	query := "DELETE FROM schema_migrations_lock WHERE id = 1 AND holder = $1"
+	if _, err := m.db.Exec(query, m.holder); err != nil {
+		log.Printf("could not delete migrations lock: %v\n", err)
+	}
+}
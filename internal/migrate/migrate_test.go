@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 락 행만 흉내 내는 가짜 드라이버, taken이면 다른 인스턴스가 락을 가져간 것
+type lockTestConnector struct {
+	mu        sync.Mutex
+	taken     bool
+	refreshes int
+	unlocked  bool
+}
+
+func (c *lockTestConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return lockTestConn{c}, nil
+}
+
+func (c *lockTestConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (c *lockTestConnector) refreshCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshes
+}
+
+type lockTestConn struct{ c *lockTestConnector }
+
+func (c lockTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c lockTestConn) Close() error {
+	return nil
+}
+
+func (c lockTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("begin not supported")
+}
+
+func (c lockTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "UPDATE schema_migrations_lock"):
+		if c.c.taken {
+			return driver.RowsAffected(0), nil
+		}
+		c.c.refreshes++
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations_lock WHERE id = 1 AND holder"):
+		c.c.unlocked = true
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func TestWithLockHeartbeat(t *testing.T) {
+	tests := []struct {
+		name    string
+		taken   bool
+		wantErr error
+	}{
+		{name: "refreshed"},
+		// 락을 잃으면 실행 중인 마이그레이션의 ctx를 취소
+		{name: "taken over", taken: true, wantErr: ErrLockLost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &lockTestConnector{taken: tt.taken}
+			db := sql.OpenDB(c)
+			defer db.Close()
+
+			m := &Migrator{db: db, holder: "test", lockTimeout: time.Second, heartbeat: time.Millisecond * 10}
+			err := m.withLock(context.Background(), func(ctx context.Context) error {
+				// staleLock보다 오래 걸리는 실행 대신 갱신이 몇 번 일어날 때까지 기다림
+				deadline := time.After(time.Second * 5)
+				for c.refreshCount() < 3 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-deadline:
+						return errors.New("lock was not refreshed")
+					case <-time.After(m.heartbeat):
+					}
+				}
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if !c.unlocked {
+				t.Error("lock was not deleted after running")
+			}
+		})
+	}
+}
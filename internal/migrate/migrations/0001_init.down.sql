@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS shopping_basket;
+DROP TABLE IF EXISTS sell_record;
+DROP TABLE IF EXISTS buy_record;
+DROP TABLE IF EXISTS timeline;
+DROP TABLE IF EXISTS comment_likes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS post_likes;
+DROP TABLE IF EXISTS post_media;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS blocks;
+DROP TABLE IF EXISTS follow_requests;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS users;
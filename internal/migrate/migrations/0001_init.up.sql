@@ -0,0 +1,189 @@
+-- 기존 schema.sql로 만든 데이터베이스에서는 있는 테이블을 건너뛰고
+-- 그 뒤에 추가된 컬럼과 인덱스는 맨 아래에서 따로 추가
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL NOT NULL PRIMARY KEY,
+	email VARCHAR NOT NULL UNIQUE,
+	username VARCHAR NOT NULL UNIQUE,
+	avatar VARCHAR,
+	private BOOLEAN NOT NULL DEFAULT false,
+	admin BOOLEAN NOT NULL DEFAULT false,
+	suspended_at TIMESTAMP,
+	followers_count INT NOT NULL DEFAULT 0 CHECK (followers_count >= 0),
+	followees_count INT NOT NULL DEFAULT 0 CHECK (followees_count >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS follows (
+	follower_id INT NOT NULL REFERENCES users,
+	followee_id INT NOT NULL REFERENCES users,
+	PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+	follower_id INT NOT NULL REFERENCES users,
+	followee_id INT NOT NULL REFERENCES users,
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE TABLE IF NOT EXISTS blocks (
+	blocker_id INT NOT NULL REFERENCES users,
+	blocked_id INT NOT NULL REFERENCES users,
+	PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE TABLE IF NOT EXISTS mutes (
+	muter_id INT NOT NULL REFERENCES users,
+	muted_id INT NOT NULL REFERENCES users,
+	PRIMARY KEY (muter_id, muted_id)
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id SERIAL NOT NULL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users,
+	content VARCHAR NOT NULL,
+	spoiler_of VARCHAR,
+	nsfw BOOLEAN NOT NULL DEFAULT false,
+	likes_count INT NOT NULL DEFAULT 0 CHECK (likes_count >= 0),
+	comments_count INT NOT NULL DEFAULT 0 CHECK (comments_count >= 0),
+	reposts_count INT NOT NULL DEFAULT 0 CHECK (reposts_count >= 0),
+	repost_of_id INT REFERENCES posts,
+	hidden_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS reposts ON posts (repost_of_id, user_id);
+
+CREATE INDEX IF NOT EXISTS sorted_posts ON posts (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS post_media (
+	id SERIAL NOT NULL PRIMARY KEY,
+	post_id INT NOT NULL REFERENCES posts,
+	position INT NOT NULL,
+	name VARCHAR NOT NULL,
+	width INT NOT NULL,
+	height INT NOT NULL,
+	medium_width INT NOT NULL,
+	medium_height INT NOT NULL,
+	UNIQUE (post_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS post_likes (
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts,
+	PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id SERIAL NOT NULL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts,
+	parent_id INT REFERENCES comments,
+	content VARCHAR NOT NULL,
+	likes_count INT NOT NULL DEFAULT 0 CHECK (likes_count >= 0),
+	replies_count INT NOT NULL DEFAULT 0 CHECK (replies_count >= 0),
+	deleted_at TIMESTAMP,
+	hidden_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sorted_comments ON comments (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS comment_replies ON comments (parent_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS comment_likes (
+	user_id INT NOT NULL REFERENCES users,
+	comment_id INT NOT NULL REFERENCES comments,
+	PRIMARY KEY (user_id, comment_id)
+);
+
+CREATE TABLE IF NOT EXISTS timeline (
+	id SERIAL NOT NULL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS timeline_unique ON timeline (user_id, post_id);
+
+CREATE TABLE IF NOT EXISTS buy_record (
+	id SERIAL NOT NULL PRIMARY KEY,
+	quantity INT NOT NULL,
+	orderNum INT NOT NULL,
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts
+);
+
+CREATE TABLE IF NOT EXISTS sell_record (
+	id SERIAL NOT NULL PRIMARY KEY,
+	quantity INT NOT NULL,
+	orderNum INT NOT NULL,
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts
+);
+
+CREATE TABLE IF NOT EXISTS shopping_basket (
+	id SERIAL NOT NULL PRIMARY KEY,
+	quantity INT NOT NULL,
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id SERIAL NOT NULL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users,
+	actors VARCHAR[] NOT NULL,
+	type VARCHAR NOT NULL,
+	post_id INT REFERENCES posts,
+	read BOOLEAN NOT NULL DEFAULT false,
+	issued_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sorted_notifications ON notifications (issued_at DESC);
+
+CREATE TABLE IF NOT EXISTS reports (
+	id SERIAL NOT NULL PRIMARY KEY,
+	reporter_id INT REFERENCES users,
+	target_type VARCHAR NOT NULL,
+	target_id INT NOT NULL,
+	reason VARCHAR NOT NULL,
+	note VARCHAR NOT NULL DEFAULT '',
+	status VARCHAR NOT NULL DEFAULT 'open',
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	resolved_at TIMESTAMP,
+	UNIQUE (reporter_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS report_queue ON reports (status, id DESC);
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+	id SERIAL NOT NULL PRIMARY KEY,
+	moderator_id INT NOT NULL REFERENCES users,
+	report_id INT REFERENCES reports,
+	action VARCHAR NOT NULL,
+	target_type VARCHAR NOT NULL,
+	target_id INT NOT NULL,
+	note VARCHAR NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- schema.sql 이후에 추가된 컬럼과 인덱스
+-- 새 데이터베이스에서는 위에서 이미 만들어져 아무것도 하지 않음
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS private BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS reposts_count INT NOT NULL DEFAULT 0 CHECK (reposts_count >= 0);
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS repost_of_id INT REFERENCES posts;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES comments;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS replies_count INT NOT NULL DEFAULT 0 CHECK (replies_count >= 0);
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP;
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS post_id INT REFERENCES posts;
+
+CREATE INDEX IF NOT EXISTS reposts ON posts (repost_of_id, user_id);
+
+CREATE INDEX IF NOT EXISTS comment_replies ON comments (parent_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS pulled_posts;
+ALTER TABLE posts DROP COLUMN IF EXISTS pulled;
@@ -0,0 +1,5 @@
+-- 팔로워가 많은 계정의 게시물은 팔로워 타임라인에 넣지 않고 읽을 때 합침
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pulled BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS pulled_posts ON posts (user_id, id DESC) WHERE pulled;
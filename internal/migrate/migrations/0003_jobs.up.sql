@@ -0,0 +1,16 @@
+-- 백그라운드 작업 큐, 실패한 작업은 dead_at을 채워서 남겨둠
+
+CREATE TABLE IF NOT EXISTS jobs (
+	id SERIAL NOT NULL PRIMARY KEY,
+	kind VARCHAR NOT NULL,
+	payload JSONB NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL,
+	last_error VARCHAR NOT NULL DEFAULT '',
+	run_at TIMESTAMP NOT NULL DEFAULT now(),
+	locked_until TIMESTAMP,
+	dead_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS ready_jobs ON jobs (kind, run_at) WHERE dead_at IS NULL;
@@ -0,0 +1,9 @@
+-- 다른 서비스로 보낼 이벤트, 보내고 나면 지움
+
+CREATE TABLE IF NOT EXISTS outbox (
+	id SERIAL NOT NULL PRIMARY KEY,
+	type VARCHAR NOT NULL,
+	payload JSONB NOT NULL,
+	locked_until TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT now()
+);
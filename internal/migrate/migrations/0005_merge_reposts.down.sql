@@ -0,0 +1,2 @@
+-- 합친 중복 공유는 되돌릴 수 없음, 옮긴 댓글과 좋아요는 남겨둔 공유에 그대로 둠
+SELECT 1;
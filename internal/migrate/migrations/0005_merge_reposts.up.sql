@@ -0,0 +1,161 @@
+-- 같은 유저가 같은 게시물을 내용 없이 여러 번 공유한 중복을 가장 오래된 공유(kept) 하나로 합침
+-- 중복에 달린 댓글, 좋아요, 타임라인, 알림, 주문 기록은 지우지 않고 kept로 옮기고, 같은 유저의 좋아요와 타임라인처럼
+-- kept에 이미 있는 것만 하나로 합침. 비어 있는 중복 게시물 행은 지워지므로 되돌릴 수 없음(down은 아무것도 하지 않음)
+-- 유니크 인덱스는 스키마 변경이라 0006에서 따로
+
+-- 내용 없는 공유를 다시 공유하면 원본을 가리키므로 보통은 없지만 오래된 데이터를 위해 원본으로 돌림
+UPDATE posts SET repost_of_id = (SELECT plain.repost_of_id FROM posts AS plain WHERE plain.id = posts.repost_of_id)
+WHERE repost_of_id IN (SELECT id FROM posts WHERE content = '' AND repost_of_id IS NOT NULL);
+
+-- 같은 유저가 kept나 앞선 중복에도 누른 좋아요는 하나로
+DELETE FROM post_likes WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM post_likes AS other
+		INNER JOIN posts AS earlier ON earlier.id = other.post_id
+		WHERE other.user_id = post_likes.user_id
+			AND earlier.user_id = dup.user_id
+			AND earlier.repost_of_id = dup.repost_of_id
+			AND earlier.content = ''
+			AND earlier.id < dup.id
+	)
+);
+
+DELETE FROM timeline WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM timeline AS other
+		INNER JOIN posts AS earlier ON earlier.id = other.post_id
+		WHERE other.user_id = timeline.user_id
+			AND earlier.user_id = dup.user_id
+			AND earlier.repost_of_id = dup.repost_of_id
+			AND earlier.content = ''
+			AND earlier.id < dup.id
+	)
+);
+
+-- 나머지는 kept로 옮김, 공유에는 첨부가 없지만 post_media도 같이
+UPDATE post_likes SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = post_likes.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE timeline SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = timeline.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE comments SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = comments.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE post_media SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = post_media.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE notifications SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = notifications.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE buy_record SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = buy_record.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE sell_record SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = sell_record.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+UPDATE shopping_basket SET post_id = (
+	SELECT min(kept.id) FROM posts AS dup
+	INNER JOIN posts AS kept ON kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = ''
+	WHERE dup.id = shopping_basket.post_id
+)
+WHERE post_id IN (
+	SELECT dup.id FROM posts AS dup
+	WHERE dup.content = '' AND dup.repost_of_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM posts AS kept
+		WHERE kept.user_id = dup.user_id AND kept.repost_of_id = dup.repost_of_id AND kept.content = '' AND kept.id < dup.id
+	)
+);
+
+-- 이제 아무것도 가리키지 않는 중복 행을 지움
+DELETE FROM posts
+WHERE content = '' AND repost_of_id IS NOT NULL AND EXISTS (
+	SELECT 1 FROM posts AS kept
+	WHERE kept.user_id = posts.user_id AND kept.repost_of_id = posts.repost_of_id AND kept.content = '' AND kept.id < posts.id
+);
+
+-- 옮긴 좋아요와 댓글(자리만 남은 댓글은 빼고), 공유 수를 다시 셈
+UPDATE posts SET likes_count = (SELECT count(*) FROM post_likes WHERE post_likes.post_id = posts.id)
+WHERE content = '' AND repost_of_id IS NOT NULL
+	AND likes_count <> (SELECT count(*) FROM post_likes WHERE post_likes.post_id = posts.id);
+
+UPDATE posts SET comments_count = (
+	SELECT count(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL
+)
+WHERE content = '' AND repost_of_id IS NOT NULL
+	AND comments_count <> (SELECT count(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL);
+
+UPDATE posts SET reposts_count = (SELECT count(*) FROM posts AS reposts WHERE reposts.repost_of_id = posts.id)
+WHERE reposts_count <> (SELECT count(*) FROM posts AS reposts WHERE reposts.repost_of_id = posts.id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS unique_reposts CASCADE;
@@ -0,0 +1,4 @@
+-- 내용 없는 공유는 유저마다 게시물 하나에 한 번만, 동시에 공유해도 하나만 저장됨
+-- 이미 있던 중복은 0005에서 합침, cockroachDB는 한 트랜잭션에서 데이터 변경과 스키마 변경을 섞지 못해서 나눔
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_reposts ON posts (user_id, repost_of_id) WHERE content = '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS sell_record_user;
+DROP INDEX IF EXISTS buy_record_user;
+DROP INDEX IF EXISTS shopping_basket_unique CASCADE;
+DROP SEQUENCE IF EXISTS order_numbers;
@@ -0,0 +1,7 @@
+-- 주문 번호와 장바구니 담기(게시물마다 한 줄), 구매/판매 기록 목록용 인덱스
+
+CREATE SEQUENCE IF NOT EXISTS order_numbers;
+
+CREATE UNIQUE INDEX IF NOT EXISTS shopping_basket_unique ON shopping_basket (user_id, post_id);
+CREATE INDEX IF NOT EXISTS buy_record_user ON buy_record (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS sell_record_user ON sell_record (user_id, id DESC);
@@ -0,0 +1,14 @@
+-- 주문한 게시물의 후기, 유저마다 게시물 하나에 한 번
+
+CREATE TABLE IF NOT EXISTS reviews (
+	id SERIAL NOT NULL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users,
+	post_id INT NOT NULL REFERENCES posts,
+	rating INT NOT NULL,
+	content VARCHAR NOT NULL DEFAULT '',
+	hidden_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	UNIQUE (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_post ON reviews (post_id, id DESC);
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+type commentServer struct {
+	pb.UnimplementedCommentServiceServer
+	s *service.Service
+}
+
+func (c *commentServer) CreateComment(ctx context.Context, req *pb.CreateCommentRequest) (*pb.Comment, error) {
+	comment, err := c.s.CreateComment(ctx, req.PostId, req.Content, req.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	return commentPB(comment), nil
+}
+
+func (c *commentServer) ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error) {
+	cc, err := c.s.Comments(ctx, req.PostId, int(req.Page.GetLast()), req.Page.GetBefore())
+	if err != nil {
+		return nil, err
+	}
+	return commentsPB(cc), nil
+}
+
+func (c *commentServer) ListReplies(ctx context.Context, req *pb.ListRepliesRequest) (*pb.ListCommentsResponse, error) {
+	cc, err := c.s.Replies(ctx, req.CommentId, int(req.Page.GetLast()), req.Page.GetBefore())
+	if err != nil {
+		return nil, err
+	}
+	return commentsPB(cc), nil
+}
+
+func (c *commentServer) DeleteComment(ctx context.Context, req *pb.CommentRequest) (*emptypb.Empty, error) {
+	return empty(c.s.DeleteComment(ctx, req.CommentId))
+}
+
+func (c *commentServer) ToggleCommentLike(ctx context.Context, req *pb.CommentRequest) (*pb.ToggleLikeResponse, error) {
+	out, err := c.s.ToggleCommentLike(ctx, req.CommentId)
+	if err != nil {
+		return nil, err
+	}
+	return toggleLikePB(out), nil
+}
@@ -0,0 +1,142 @@
+package rpc
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+// 서비스 모델을 protobuf 메시지로
+
+func avatarPB(a *service.Avatar) *pb.Avatar {
+	if a == nil {
+		return nil
+	}
+
+	out := &pb.Avatar{Url: a.URL, Srcset: a.Srcset}
+	for _, v := range a.Variants {
+		out.Variants = append(out.Variants, &pb.AvatarVariant{Size: int32(v.Size), Url: v.URL})
+	}
+	return out
+}
+
+func userPB(u *service.User) *pb.User {
+	if u == nil {
+		return nil
+	}
+	return &pb.User{Id: u.ID, Username: u.UserName, Avatar: avatarPB(u.Avatar)}
+}
+
+func userProfilePB(u service.UserProfile) *pb.UserProfile {
+	return &pb.UserProfile{
+		User:           userPB(&u.User),
+		Email:          u.Email,
+		FollowersCount: int32(u.FollowersCount),
+		FolloweesCount: int32(u.FolloweesCount),
+		Me:             u.Me,
+		Following:      u.Following,
+		Followeed:      u.Followeed,
+		Private:        u.Private,
+		Requested:      u.Requested,
+	}
+}
+
+func userProfilesPB(uu []service.UserProfile) *pb.ListUserProfilesResponse {
+	out := &pb.ListUserProfilesResponse{Users: make([]*pb.UserProfile, len(uu))}
+	for i, u := range uu {
+		out.Users[i] = userProfilePB(u)
+	}
+	return out
+}
+
+func renditionPB(r service.MediaRendition) *pb.MediaRendition {
+	return &pb.MediaRendition{Url: r.URL, Width: int32(r.Width), Height: int32(r.Height)}
+}
+
+func postPB(p *service.Post) *pb.Post {
+	if p == nil {
+		return nil
+	}
+
+	out := &pb.Post{
+		Id:            p.ID,
+		UserId:        p.UserID,
+		Content:       p.Content,
+		SpoilerOf:     p.SpoilerOf,
+		Nsfw:          p.NSFW,
+		LikesCount:    int32(p.LikesCount),
+		CommentsCount: int32(p.CommentsCount),
+		RepostsCount:  int32(p.RepostsCount),
+		CreatedAt:     timestamppb.New(p.CreatedAt),
+		User:          userPB(p.User),
+		Mine:          p.Mine,
+		Liked:         p.Liked,
+		RepostOf:      postPB(p.RepostOf),
+		Held:          p.Held,
+	}
+	for _, m := range p.Media {
+		out.Media = append(out.Media, &pb.Media{
+			Thumbnail: renditionPB(m.Thumbnail),
+			Medium:    renditionPB(m.Medium),
+			Original:  renditionPB(m.Original),
+		})
+	}
+	return out
+}
+
+func timelineItemPB(ti service.TimelineItem) *pb.TimelineItem {
+	return &pb.TimelineItem{Id: ti.ID, Post: postPB(&ti.Post), RepostedBy: userPB(ti.RepostedBy)}
+}
+
+func commentPB(c service.Comment) *pb.Comment {
+	return &pb.Comment{
+		Id:           c.ID,
+		PostId:       c.PostID,
+		ParentId:     c.ParentID,
+		Content:      c.Content,
+		LikesCount:   int32(c.LikesCount),
+		RepliesCount: int32(c.RepliesCount),
+		Deleted:      c.Deleted,
+		CreatedAt:    timestamppb.New(c.CreatedAt),
+		User:         userPB(c.User),
+		Mine:         c.Mine,
+		Liked:        c.Liked,
+		Held:         c.Held,
+	}
+}
+
+func commentsPB(cc []service.Comment) *pb.ListCommentsResponse {
+	out := &pb.ListCommentsResponse{Comments: make([]*pb.Comment, len(cc))}
+	for i, c := range cc {
+		out.Comments[i] = commentPB(c)
+	}
+	return out
+}
+
+func notificationPB(n service.Notification) *pb.Notification {
+	return &pb.Notification{
+		Id:       n.ID,
+		Actors:   n.Actors,
+		Type:     n.Type,
+		PostId:   n.PostID,
+		Read:     n.Read,
+		IssuedAt: timestamppb.New(n.IssuedAt),
+	}
+}
+
+func toggleLikePB(out service.ToggleLikeOutput) *pb.ToggleLikeResponse {
+	return &pb.ToggleLikeResponse{Liked: out.Liked, LikesCount: int32(out.LikesCount)}
+}
+
+func basketItemPB(b service.BasketItem) *pb.BasketItem {
+	return &pb.BasketItem{Id: b.ID, PostId: b.PostID, Quantity: int32(b.Quantity)}
+}
+
+func orderItemsPB(items []service.OrderItem) *pb.ListOrderItemsResponse {
+	out := &pb.ListOrderItemsResponse{Items: make([]*pb.OrderItem, len(items))}
+	for i, item := range items {
+		out.Items[i] = &pb.OrderItem{Id: item.ID, OrderNum: item.OrderNum, PostId: item.PostID, Quantity: int32(item.Quantity)}
+	}
+	return out
+}
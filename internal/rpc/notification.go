@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+type notificationServer struct {
+	pb.UnimplementedNotificationServiceServer
+	s *service.Service
+}
+
+func (n *notificationServer) ListNotifications(ctx context.Context, req *pb.PageBefore) (*pb.ListNotificationsResponse, error) {
+	nn, err := n.s.Notifications(ctx, int(req.Last), req.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pb.ListNotificationsResponse{Notifications: make([]*pb.Notification, len(nn))}
+	for i, notification := range nn {
+		out.Notifications[i] = notificationPB(notification)
+	}
+	return out, nil
+}
+
+func (n *notificationServer) MarkNotificationAsRead(ctx context.Context, req *pb.MarkNotificationAsReadRequest) (*emptypb.Empty, error) {
+	return empty(n.s.MarkNotificationAsRead(ctx, req.NotificationId))
+}
+
+func (n *notificationServer) MarkNotificationsAsRead(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	return empty(n.s.MarkNotificationsAsRead(ctx))
+}
+
+// 스트림은 클라이언트가 끊을 때까지 유지
+func (n *notificationServer) WatchNotifications(_ *emptypb.Empty, stream pb.NotificationService_WatchNotificationsServer) error {
+	ctx := stream.Context()
+	nn, err := n.s.SubscribeToNotifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 구독한 뒤에 헤더를 보내서 클라이언트가 이후 항목을 놓치지 않게 함
+	if err = stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case notification := <-nn:
+			if err := stream.Send(notificationPB(notification)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
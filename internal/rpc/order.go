@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+type orderServer struct {
+	pb.UnimplementedOrderServiceServer
+	s *service.Service
+}
+
+func (o *orderServer) AddToBasket(ctx context.Context, req *pb.AddToBasketRequest) (*pb.BasketItem, error) {
+	item, err := o.s.AddToBasket(ctx, req.PostId, int(req.Quantity))
+	if err != nil {
+		return nil, err
+	}
+	return basketItemPB(item), nil
+}
+
+func (o *orderServer) ListBasket(ctx context.Context, _ *emptypb.Empty) (*pb.ListBasketResponse, error) {
+	items, err := o.s.Basket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pb.ListBasketResponse{Items: make([]*pb.BasketItem, len(items))}
+	for i, item := range items {
+		out.Items[i] = basketItemPB(item)
+	}
+	return out, nil
+}
+
+func (o *orderServer) RemoveFromBasket(ctx context.Context, req *pb.BasketItemRequest) (*emptypb.Empty, error) {
+	return empty(o.s.RemoveFromBasket(ctx, req.ItemId))
+}
+
+func (o *orderServer) PlaceOrder(ctx context.Context, _ *emptypb.Empty) (*pb.Order, error) {
+	order, err := o.s.PlaceOrder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Order{Num: order.Num, Items: orderItemsPB(order.Items).Items}, nil
+}
+
+func (o *orderServer) ListOrders(ctx context.Context, req *pb.PageBefore) (*pb.ListOrderItemsResponse, error) {
+	items, err := o.s.Orders(ctx, int(req.Last), req.Before)
+	if err != nil {
+		return nil, err
+	}
+	return orderItemsPB(items), nil
+}
+
+func (o *orderServer) ListSales(ctx context.Context, req *pb.PageBefore) (*pb.ListOrderItemsResponse, error) {
+	items, err := o.s.Sales(ctx, int(req.Last), req.Before)
+	if err != nil {
+		return nil, err
+	}
+	return orderItemsPB(items), nil
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+type postServer struct {
+	pb.UnimplementedPostServiceServer
+	s *service.Service
+}
+
+// 이미지 첨부는 REST(multipart)로만 가능
+func (p *postServer) CreatePost(ctx context.Context, req *pb.CreatePostRequest) (*pb.TimelineItem, error) {
+	ti, err := p.s.CreatePost(ctx, req.Content, req.SpoilerOf, req.Nsfw, nil)
+	if err != nil {
+		return nil, err
+	}
+	return timelineItemPB(ti), nil
+}
+
+func (p *postServer) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.Post, error) {
+	post, err := p.s.Post(ctx, req.PostId)
+	if err != nil {
+		return nil, err
+	}
+	return postPB(&post), nil
+}
+
+func (p *postServer) ListPosts(ctx context.Context, req *pb.ListPostsRequest) (*pb.ListPostsResponse, error) {
+	pp, err := p.s.Posts(ctx, req.Username, int(req.Page.GetLast()), req.Page.GetBefore())
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pb.ListPostsResponse{Posts: make([]*pb.Post, len(pp))}
+	for i := range pp {
+		out.Posts[i] = postPB(&pp[i])
+	}
+	return out, nil
+}
+
+func (p *postServer) TogglePostLike(ctx context.Context, req *pb.GetPostRequest) (*pb.ToggleLikeResponse, error) {
+	out, err := p.s.TogglePostLike(ctx, req.PostId)
+	if err != nil {
+		return nil, err
+	}
+	return toggleLikePB(out), nil
+}
+
+func (p *postServer) Repost(ctx context.Context, req *pb.RepostRequest) (*pb.TimelineItem, error) {
+	ti, err := p.s.Repost(ctx, req.PostId, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	return timelineItemPB(ti), nil
+}
+
+func (p *postServer) Timeline(ctx context.Context, req *pb.PageBefore) (*pb.TimelineResponse, error) {
+	tt, err := p.s.Timeline(ctx, int(req.Last), req.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pb.TimelineResponse{Items: make([]*pb.TimelineItem, len(tt))}
+	for i, ti := range tt {
+		out.Items[i] = timelineItemPB(ti)
+	}
+	return out, nil
+}
+
+// 스트림은 클라이언트가 끊을 때까지 유지
+func (p *postServer) WatchTimeline(_ *emptypb.Empty, stream pb.PostService_WatchTimelineServer) error {
+	ctx := stream.Context()
+	tt, err := p.s.SubscribeToTimeline(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 구독한 뒤에 헤더를 보내서 클라이언트가 이후 항목을 놓치지 않게 함
+	if err = stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case ti := <-tt:
+			if err := stream.Send(timelineItemPB(ti)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
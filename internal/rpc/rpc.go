@@ -0,0 +1,147 @@
+// Package rpc serves the gRPC API for internal consumers on top of service.Service.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+type server struct {
+	s *service.Service
+}
+
+// New creates a gRPC server with every service registered.
+// Authenticated calls carry the same branca token as the REST API
+// in the "authorization: Bearer <token>" metadata.
+func New(s *service.Service, opts ...grpc.ServerOption) *grpc.Server {
+	srv := &server{s: s}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(srv.unaryAuth),
+		grpc.ChainStreamInterceptor(srv.streamAuth),
+	)
+
+	g := grpc.NewServer(opts...)
+	pb.RegisterUserServiceServer(g, &userServer{s: s})
+	pb.RegisterPostServiceServer(g, &postServer{s: s})
+	pb.RegisterCommentServiceServer(g, &commentServer{s: s})
+	pb.RegisterNotificationServiceServer(g, &notificationServer{s: s})
+	pb.RegisterOrderServiceServer(g, &orderServer{s: s})
+	return g
+}
+
+// withAuth와 같음, 토큰이 없으면 그냥 지나감
+func (srv *server) auth(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	a := md.Get("authorization")
+	if len(a) == 0 || !strings.HasPrefix(a[0], "Bearer ") {
+		return ctx, nil
+	}
+
+	uid, err := srv.s.AuthUserID(ctx, a[0][7:])
+	if err != nil {
+		return ctx, toStatus(err)
+	}
+
+	return context.WithValue(ctx, service.KeyAuthUserID, uid), nil
+}
+
+func (srv *server) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := srv.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := handler(ctx, req)
+	return res, toStatus(err)
+}
+
+func (srv *server) streamAuth(v interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := srv.auth(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return toStatus(handler(v, &authStream{ServerStream: ss, ctx: ctx}))
+}
+
+// 인증된 유저 아이디가 담긴 컨텍스트를 스트림 핸들러에 넘김
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// HTTP 상태에 맞는 gRPC 코드
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+}
+
+// 서비스 에러는 코드와 필드를 ErrorInfo에 담고, 나머지는 로그를 남기고 Internal
+// toStatus converts service errors into gRPC status errors.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var e *service.Error
+	if !errors.As(err, &e) {
+		log.Println(err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	code, ok := statusCodes[e.Status]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	info := &errdetails.ErrorInfo{Reason: e.Code, Domain: "sodam"}
+	if e.Field != "" {
+		info.Metadata = map[string]string{"field": e.Field}
+	}
+
+	details := []protoiface.MessageV1{info}
+	if len(e.Details) != 0 {
+		br := &errdetails.BadRequest{}
+		for _, d := range e.Details {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       d.Field,
+				Description: d.Message,
+			})
+		}
+		details = append(details, br)
+	}
+
+	st, err := status.New(code, e.Message).WithDetails(details...)
+	if err != nil {
+		return status.Error(code, e.Message)
+	}
+	return st.Err()
+}
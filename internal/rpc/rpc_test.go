@@ -0,0 +1,217 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hako/branca"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+// 메모리 저장소 서비스에 bufconn으로 연결한 클라이언트
+func newTestConn(t *testing.T) (*service.Service, *grpc.ClientConn) {
+	t.Helper()
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	blobs := service.NewLocalBlobStore(t.TempDir(), "http://localhost:3000/img")
+	s := service.New(service.NewMemoryStore(), codec, "http://localhost:3000", blobs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunJobs(ctx, nil)
+	}()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := New(s)
+	go srv.Serve(lis)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+		cancel()
+		<-done
+	})
+	return s, conn
+}
+
+// 사용자를 만들고 토큰을 메타데이터에 담은 context
+func newTestUser(t *testing.T, conn *grpc.ClientConn, username string) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	users := pb.NewUserServiceClient(conn)
+	if _, err := users.CreateUser(ctx, &pb.CreateUserRequest{Email: username + "@example.org", Username: username}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := users.Login(ctx, &pb.LoginRequest{Email: username + "@example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+out.Token)
+}
+
+func TestAuth(t *testing.T) {
+	_, conn := newTestConn(t)
+	aliceCtx := newTestUser(t, conn, "alice")
+	users := pb.NewUserServiceClient(conn)
+
+	u, err := users.AuthUser(aliceCtx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Username != "alice" {
+		t.Errorf("auth user = %q, want alice", u.Username)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		reason string
+	}{
+		{"missing", "", "unauthenticated"},
+		{"not bearer", "Basic abc", "unauthenticated"},
+		{"bad token", "Bearer abc", "invalid_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.header)
+			}
+
+			_, err := users.AuthUser(ctx, &emptypb.Empty{})
+			assertStatus(t, err, codes.Unauthenticated, tt.reason)
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	_, conn := newTestConn(t)
+	aliceCtx := newTestUser(t, conn, "alice")
+	posts := pb.NewPostServiceClient(conn)
+	orders := pb.NewOrderServiceClient(conn)
+
+	_, err := posts.GetPost(aliceCtx, &pb.GetPostRequest{PostId: 100})
+	assertStatus(t, err, codes.NotFound, "post_not_found")
+
+	_, err = pb.NewUserServiceClient(conn).CreateUser(context.Background(), &pb.CreateUserRequest{Email: "alice@example.org", Username: "alice2"})
+	assertStatus(t, err, codes.AlreadyExists, "email_taken")
+
+	_, err = orders.AddToBasket(aliceCtx, &pb.AddToBasketRequest{PostId: 100, Quantity: 0})
+	st := assertStatus(t, err, codes.InvalidArgument, "invalid_quantity")
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Metadata["field"] != "quantity" {
+			t.Errorf("field = %q, want quantity", info.Metadata["field"])
+		}
+	}
+
+	_, err = orders.PlaceOrder(aliceCtx, &emptypb.Empty{})
+	assertStatus(t, err, codes.InvalidArgument, "empty_basket")
+}
+
+func TestStatusCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{service.ErrUnauthenticated, codes.Unauthenticated},
+		{service.ErrMediaTooLarge, codes.ResourceExhausted},
+		{service.ErrAvatarTooLarge, codes.ResourceExhausted},
+		{service.ErrPostNotFound, codes.NotFound},
+		{service.ErrInvalidQuantity, codes.InvalidArgument},
+		{status.Error(codes.Canceled, "canceled"), codes.Canceled},
+		{context.DeadlineExceeded, codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(toStatus(tt.err)); got != tt.want {
+			t.Errorf("code of %v = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWatchTimeline(t *testing.T) {
+	_, conn := newTestConn(t)
+	authorCtx := newTestUser(t, conn, "author")
+	readerCtx := newTestUser(t, conn, "reader")
+	posts := pb.NewPostServiceClient(conn)
+
+	if _, err := pb.NewUserServiceClient(conn).ToggleFollow(readerCtx, &pb.UsernameRequest{Username: "author"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(readerCtx, time.Second*5)
+	defer cancel()
+
+	stream, err := posts.WatchTimeline(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 헤더가 오면 구독이 끝난 것
+	if _, err = stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := posts.CreatePost(authorCtx, &pb.CreatePostRequest{Content: "new post"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ti, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ti.Post.GetId() != created.Post.GetId() || ti.Post.GetContent() != "new post" {
+		t.Errorf("streamed item = %v, want post %d", ti, created.Post.GetId())
+	}
+
+	// 로그인하지 않으면 스트림을 열 수 없음
+	anon, err := posts.WatchTimeline(context.Background(), &emptypb.Empty{})
+	if err == nil {
+		_, err = anon.Recv()
+	}
+	assertStatus(t, err, codes.Unauthenticated, "unauthenticated")
+}
+
+func assertStatus(t *testing.T, err error, code codes.Code, reason string) *status.Status {
+	t.Helper()
+	st, _ := status.FromError(err)
+	if st.Code() != code {
+		t.Errorf("code = %v, want %v (%v)", st.Code(), code, err)
+	}
+
+	got := ""
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			got = info.Reason
+		}
+	}
+	if got != reason {
+		t.Errorf("reason = %q, want %q", got, reason)
+	}
+	return st
+}
@@ -0,0 +1,129 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"sodam/internal/rpc/pb"
+	"sodam/internal/service"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	s *service.Service
+}
+
+func (u *userServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*emptypb.Empty, error) {
+	return empty(u.s.CreateUser(ctx, req.Email, req.Username))
+}
+
+func (u *userServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	out, err := u.s.Login(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LoginResponse{
+		Token:     out.Token,
+		ExpiresAt: timestamppb.New(out.ExpiresAt),
+		AuthUser:  userPB(&out.AuthUser),
+	}, nil
+}
+
+func (u *userServer) AuthUser(ctx context.Context, _ *emptypb.Empty) (*pb.User, error) {
+	user, err := u.s.AuthUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return userPB(&user), nil
+}
+
+func (u *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserProfile, error) {
+	user, err := u.s.User(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	return userProfilePB(user), nil
+}
+
+func (u *userServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUserProfilesResponse, error) {
+	uu, err := u.s.Users(ctx, req.Search, int(req.Page.GetFirst()), req.Page.GetAfter())
+	if err != nil {
+		return nil, err
+	}
+	return userProfilesPB(uu), nil
+}
+
+func (u *userServer) ListFollowers(ctx context.Context, req *pb.ListFollowsRequest) (*pb.ListUserProfilesResponse, error) {
+	uu, err := u.s.Followers(ctx, req.Username, int(req.Page.GetFirst()), req.Page.GetAfter())
+	if err != nil {
+		return nil, err
+	}
+	return userProfilesPB(uu), nil
+}
+
+func (u *userServer) ListFollowees(ctx context.Context, req *pb.ListFollowsRequest) (*pb.ListUserProfilesResponse, error) {
+	uu, err := u.s.Followees(ctx, req.Username, int(req.Page.GetFirst()), req.Page.GetAfter())
+	if err != nil {
+		return nil, err
+	}
+	return userProfilesPB(uu), nil
+}
+
+func (u *userServer) ToggleFollow(ctx context.Context, req *pb.UsernameRequest) (*pb.ToggleFollowResponse, error) {
+	out, err := u.s.ToggleFollow(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ToggleFollowResponse{
+		Following:      out.Following,
+		Requested:      out.Requested,
+		FollowersCount: int32(out.FollowersCount),
+	}, nil
+}
+
+func (u *userServer) SetPrivate(ctx context.Context, req *pb.SetPrivateRequest) (*emptypb.Empty, error) {
+	return empty(u.s.SetPrivate(ctx, req.Private))
+}
+
+func (u *userServer) ListFollowRequests(ctx context.Context, req *pb.PageAfter) (*pb.ListUserProfilesResponse, error) {
+	uu, err := u.s.FollowRequests(ctx, int(req.First), req.After)
+	if err != nil {
+		return nil, err
+	}
+	return userProfilesPB(uu), nil
+}
+
+func (u *userServer) ApproveFollowRequest(ctx context.Context, req *pb.UsernameRequest) (*emptypb.Empty, error) {
+	return empty(u.s.ApproveFollowRequest(ctx, req.Username))
+}
+
+func (u *userServer) DenyFollowRequest(ctx context.Context, req *pb.UsernameRequest) (*emptypb.Empty, error) {
+	return empty(u.s.DenyFollowRequest(ctx, req.Username))
+}
+
+func (u *userServer) ToggleBlock(ctx context.Context, req *pb.UsernameRequest) (*pb.ToggleBlockResponse, error) {
+	out, err := u.s.ToggleBlock(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ToggleBlockResponse{Blocked: out.Blocked}, nil
+}
+
+func (u *userServer) ToggleMute(ctx context.Context, req *pb.UsernameRequest) (*pb.ToggleMuteResponse, error) {
+	out, err := u.s.ToggleMute(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ToggleMuteResponse{Muted: out.Muted}, nil
+}
+
+func empty(err error) (*emptypb.Empty, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
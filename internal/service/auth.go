@@ -2,9 +2,8 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -19,7 +18,9 @@ const (
 
 var (
 	// ErrUnauthenticated used when ther is no authenticated user in context
-	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrUnauthenticated = newError(http.StatusUnauthorized, "unauthenticated", "unauthenticated")
+	// ErrInvalidToken used when the token could not be decoded or has expired
+	ErrInvalidToken = newError(http.StatusUnauthorized, "invalid_token", "invalid token")
 )
 
 type key string
@@ -32,16 +33,27 @@ type LoginOutput struct {
 }
 
 //AuthUser ID from Token
-func (s *Service) AuthUserID(token string) (int64, error) {
+func (s *Service) AuthUserID(ctx context.Context, token string) (int64, error) {
 	str, err := s.codec.DecodeToString(token)
 	if err != nil {
-		return 0, fmt.Errorf("could not decode token: %v", err)
+		return 0, ErrInvalidToken
 	}
 
 	i, err := strconv.ParseInt(str, 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("could not parse auth user id from token: %v", err)
+		return 0, ErrInvalidToken
 	}
+
+	// 정지된 유저는 이미 받은 토큰도 사용 불가
+	flags, err := s.store.UserFlags(ctx, i)
+	if err != nil {
+		return 0, err
+	}
+
+	if flags.Suspended {
+		return 0, ErrUserSuspended
+	}
+
 	return i, nil
 }
 
@@ -56,23 +68,23 @@ func (s *Service) Login(ctx context.Context, email string) (LoginOutput, error)
 		return out, ErrInvalidEmail
 	}
 
-	var avatar sql.NullString
-	query := "SELECT id, username, avatar FROM users WHERE email = $1"
-	err := s.db.QueryRowContext(ctx, query, email).Scan(&out.AuthUser.ID, &out.AuthUser.UserName, &avatar)
-
-	if err == sql.ErrNoRows {
-		return out, ErrUserNotFound
+	var err error
+	out.AuthUser, err = s.store.UserByEmail(ctx, email)
+	if err != nil {
+		return out, err
 	}
 
+	flags, err := s.store.UserFlags(ctx, out.AuthUser.ID)
 	if err != nil {
-		return out, fmt.Errorf("could not query select user: %v\n", err)
+		return out, err
 	}
 
-	if avatar.Valid {
-		avatarURL := s.origin + "/img/avatars/" + avatar.String
-		out.AuthUser.AvatarURL = &avatarURL
+	if flags.Suspended {
+		return out, ErrUserSuspended
 	}
 
+	s.userAvatar(&out.AuthUser)
+
 	//유저 아이디 토큰화
 	out.Token, err = s.codec.EncodeToString(strconv.FormatInt(out.AuthUser.ID, 10))
 	if err != nil {
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // gif 아바타 허용
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	gonanoid "github.com/matoous/go-nanoid"
+	_ "golang.org/x/image/webp" // webp 아바타 허용
+)
+
+// 아바타 용량 제한
+// MaxAvatarBytes to read
+const MaxAvatarBytes = 5 << 20 //5MB
+
+// 디코딩 전에 헤더로 확인하는 픽셀 수 제한
+// MaxAvatarPixels of an avatar, checked on the header before decoding.
+const MaxAvatarPixels = 40_000_000
+
+// 아바타 크기 (가장 큰 크기가 기본 이미지)
+var avatarSizes = []int{48, 96, 400}
+
+// 크기별로 저장한 아바타 이름의 표시 ("abc.s.jpg"), 나노아이디에 없는 "."으로 구분
+// 표시가 없는 예전 아바타는 400px 파일 하나뿐
+const sizedAvatarMark = ".s"
+
+// 아바타 모델, <img srcset>에 그대로 사용할 수 있음
+// Avatar with its sized variants.
+type Avatar struct {
+	URL      string          `json:"url"`
+	Srcset   string          `json:"srcset"`
+	Variants []AvatarVariant `json:"variants"`
+}
+
+// AvatarVariant of a square avatar.
+type AvatarVariant struct {
+	Size int    `json:"size"`
+	URL  string `json:"url"`
+}
+
+// 아바타를 읽어서 크기별로 저장하고 저장된 이름 반환
+// saveAvatar decodes, auto-orients and stores every avatar size.
+func (s *Service) saveAvatar(ctx context.Context, r io.Reader) (string, error) {
+	// 아바타 용량 제한, 한 바이트 더 읽어서 넘었는지 확인
+	b, err := ioutil.ReadAll(io.LimitReader(r, MaxAvatarBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("could not read avatar: %v", err)
+	}
+
+	if len(b) > MaxAvatarBytes {
+		return "", ErrAvatarTooLarge
+	}
+
+	// 형식 제한, 모르는 형식이나 잘리거나 깨진 파일은 모두 415
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil || (format != "png" && format != "jpeg" && format != "gif" && format != "webp") {
+		return "", ErrUnsupportedAvatarFormat
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > MaxAvatarPixels {
+		return "", ErrAvatarDimensionsTooLarge
+	}
+
+	// 휴대폰 사진의 EXIF 회전 정보 적용
+	// 다시 인코딩하기 때문에 위치 정보 같은 메타데이터는 저장되지 않음
+	img, err := imaging.Decode(bytes.NewReader(b), imaging.AutoOrientation(true))
+	if err != nil {
+		return "", ErrUnsupportedAvatarFormat
+	}
+
+	// 투명도가 있을 수 있는 형식은 png로 저장
+	switch {
+	case s.avatarWebP:
+		format = "webp"
+	case format != "jpeg":
+		format = "png"
+	}
+
+	// 유저 이름에 맞는 아바타 자동 생성
+	avatar, err := gonanoid.Nanoid()
+	if err != nil {
+		return "", fmt.Errorf("could not generate avatar filename: %v", err)
+	}
+
+	// 추가한 아바타 사진 이름에 형식 추가
+	avatar += sizedAvatarMark + imageExt(format)
+
+	for i, size := range avatarSizes {
+		// 이미지 크기 변환
+		variant := imaging.Fill(img, size, size, imaging.Center, imaging.Lanczos)
+		if err = s.putImage(ctx, avatarBlob(avatarVariantName(avatar, size)), variant, format); err != nil {
+			for _, written := range avatarSizes[:i] {
+				s.deleteBlob(avatarBlob(avatarVariantName(avatar, written)))
+			}
+			return "", err
+		}
+	}
+
+	return avatar, nil
+}
+
+func (s *Service) removeAvatar(avatar string) {
+	for _, size := range sizesOfAvatar(avatar) {
+		s.deleteBlob(avatarBlob(avatarVariantName(avatar, size)))
+	}
+}
+
+// 저장소에서 받은 아바타 이름을 URL로
+func (s *Service) userAvatar(u *User) {
+	if u != nil && u.avatarName != "" {
+		u.Avatar = s.avatar(u.avatarName)
+	}
+}
+
+func (s *Service) avatar(avatar string) *Avatar {
+	a := &Avatar{}
+	sizes := sizesOfAvatar(avatar)
+	srcset := make([]string, len(sizes))
+	for i, size := range sizes {
+		url := s.blobs.URL(avatarBlob(avatarVariantName(avatar, size)))
+		a.Variants = append(a.Variants, AvatarVariant{Size: size, URL: url})
+		srcset[i] = url + " " + strconv.Itoa(size) + "w"
+		a.URL = url
+	}
+	a.Srcset = strings.Join(srcset, ", ")
+	return a
+}
+
+// 예전 아바타는 가장 큰 크기 하나
+func sizesOfAvatar(avatar string) []int {
+	if !strings.HasSuffix(strings.TrimSuffix(avatar, path.Ext(avatar)), sizedAvatarMark) {
+		return avatarSizes[len(avatarSizes)-1:]
+	}
+	return avatarSizes
+}
+
+// 가장 큰 크기는 원래 이름 그대로 저장 ("abc.s.jpg", "abc.s_96.jpg", "abc.s_48.jpg")
+func avatarVariantName(avatar string, size int) string {
+	if size == avatarSizes[len(avatarSizes)-1] {
+		return avatar
+	}
+	return renditionName(avatar, strconv.Itoa(size))
+}
+
+// 저장소 안에서의 아바타 이름
+func avatarBlob(avatar string) string {
+	return "avatars/" + avatar
+}
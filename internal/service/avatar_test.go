@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateAvatar(t *testing.T) {
+	s := newTestService(t)
+	dir := s.blobs.(*LocalBlobStore).dir
+	ctx, _ := newTestUser(t, s, "author")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 500, 300))); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := s.UpdateAvatar(ctx, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Variants) != len(avatarSizes) {
+		t.Fatalf("variants = %+v, want %d sizes", a.Variants, len(avatarSizes))
+	}
+
+	for _, v := range a.Variants {
+		name := filepath.Base(v.URL)
+		if _, err := os.Stat(filepath.Join(dir, "avatars", name)); err != nil {
+			t.Errorf("%dpx variant %s was not stored: %v", v.Size, name, err)
+		}
+	}
+
+	if a.URL != a.Variants[len(a.Variants)-1].URL {
+		t.Errorf("url = %s, want the largest variant", a.URL)
+	}
+}
+
+func TestLegacyAvatar(t *testing.T) {
+	s := newTestService(t)
+	a := s.avatar("JZ7KU9ke0ZZZChpScbghe.jpg")
+	want := "http://localhost:3000/img/avatars/JZ7KU9ke0ZZZChpScbghe.jpg"
+	if a.URL != want || a.Srcset != want+" 400w" || len(a.Variants) != 1 || a.Variants[0].Size != 400 {
+		t.Errorf("avatar = %+v, want only the 400px file", a)
+	}
+
+	a = s.avatar("JZ7KU9ke0ZZZChpScbghe.s.jpg")
+	if len(a.Variants) != len(avatarSizes) || a.Variants[0].URL != "http://localhost:3000/img/avatars/JZ7KU9ke0ZZZChpScbghe.s_48.jpg" {
+		t.Errorf("avatar = %+v, want every size", a)
+	}
+}
+
+func TestUpdateAvatarErrors(t *testing.T) {
+	s := newTestService(t)
+	ctx, _ := newTestUser(t, s, "author")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		avatar []byte
+		want   *Error
+	}{
+		{"not an image", []byte("hello"), ErrUnsupportedAvatarFormat},
+		{"truncated png", buf.Bytes()[:buf.Len()/2], ErrUnsupportedAvatarFormat},
+		{"too large", bytes.Repeat([]byte{0}, MaxAvatarBytes+1), ErrAvatarTooLarge},
+		{"too many pixels", pngWithSize(t, buf.Bytes(), 8000, 5001), ErrAvatarDimensionsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UpdateAvatar(ctx, bytes.NewReader(tt.avatar))
+			assertError(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 아바타, 게시물 이미지 같은 파일 저장소
+// BlobStore persists uploaded files under slash separated names
+// like "avatars/abc.jpg" and tells where they can be fetched from.
+type BlobStore interface {
+	Put(ctx context.Context, name string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, name string) error
+	URL(name string) string
+}
+
+// 로컬 디스크 저장소
+// LocalBlobStore writes blobs to a directory served over http at baseURL.
+type LocalBlobStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalBlobStore with the directory to write into and the public base URL of that directory.
+func NewLocalBlobStore(dir, baseURL string) *LocalBlobStore {
+	return &LocalBlobStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Put writes the blob to disk, creating parent directories as needed.
+func (s *LocalBlobStore) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	p := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("could not create blob dir: %v", err)
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return fmt.Errorf("could not create blob: %v", err)
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(p)
+		return fmt.Errorf("could not write blob to disk: %v", err)
+	}
+
+	if err = f.Close(); err != nil {
+		os.Remove(p)
+		return fmt.Errorf("could not close blob: %v", err)
+	}
+
+	return nil
+}
+
+// Delete removes the blob. Missing blobs are not an error.
+func (s *LocalBlobStore) Delete(ctx context.Context, name string) error {
+	err := os.Remove(s.path(name))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not delete blob: %v", err)
+	}
+
+	return nil
+}
+
+// URL of the blob under the base URL.
+func (s *LocalBlobStore) URL(name string) string {
+	return s.baseURL + "/" + name
+}
+
+// 상위 디렉토리로 빠져나가지 못하도록 정리
+func (s *LocalBlobStore) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+// 요청이 끝난 뒤에도 지울 수 있도록 별도의 context 사용, 실패는 로그만 남김
+func (s *Service) deleteBlob(name string) {
+	if err := s.blobs.Delete(context.Background(), name); err != nil {
+		log.Printf("could not delete blob %q: %v\n", name, err)
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3DateFormat      = "20060102"
+	s3TimeFormat      = "20060102T150405Z"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3 호환 저장소 설정 (AWS S3, MinIO 등)
+// S3Config for an S3 compatible object storage like AWS S3 or MinIO.
+type S3Config struct {
+	// Endpoint like "https://s3.ap-northeast-2.amazonaws.com" or "http://localhost:9000".
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL of the bucket when served behind a CDN. Defaults to Endpoint/Bucket.
+	PublicURL string
+	// SignTTL makes URL return presigned GET URLs valid for the given duration
+	// so the bucket can stay private. Zero means plain public URLs.
+	SignTTL time.Duration
+	Client  *http.Client
+}
+
+// S3BlobStore stores blobs in a bucket using path-style requests signed with AWS signature v4.
+type S3BlobStore struct {
+	cfg S3Config
+	now func() time.Time
+}
+
+// NewS3BlobStore with the given config.
+func NewS3BlobStore(cfg S3Config) *S3BlobStore {
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &S3BlobStore{cfg: cfg, now: time.Now}
+}
+
+// Put uploads the blob with a single PUT request.
+func (s *S3BlobStore) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	// 서명에 본문 해시가 필요하기 때문에 전부 읽음 (이미지라서 크지 않음)
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("could not read blob: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(name), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("could not create put object request: %v", err)
+	}
+
+	req.ContentLength = int64(len(b))
+	req.Header.Set("Content-Type", contentType)
+	return s.do(ctx, req, sha256Hex(b))
+}
+
+// Delete removes the blob. S3 does not fail on missing keys.
+func (s *S3BlobStore) Delete(ctx context.Context, name string) error {
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(name), nil)
+	if err != nil {
+		return fmt.Errorf("could not create delete object request: %v", err)
+	}
+
+	return s.do(ctx, req, sha256Hex(nil))
+}
+
+// URL of the blob, presigned when SignTTL is set.
+func (s *S3BlobStore) URL(name string) string {
+	if s.cfg.SignTTL <= 0 {
+		if s.cfg.PublicURL != "" {
+			return s.cfg.PublicURL + "/" + s3EscapePath(name)
+		}
+		return s.objectURL(name)
+	}
+
+	u, err := url.Parse(s.objectURL(name))
+	if err != nil {
+		return s.objectURL(name)
+	}
+
+	t := s.now().UTC()
+	scope := s.scope(t)
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	q.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(s.cfg.SignTTL.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		s3CanonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	q.Set("X-Amz-Signature", s.signature(t, scope, canonical))
+	u.RawQuery = s3CanonicalQuery(q)
+	return u.String()
+}
+
+func (s *S3BlobStore) objectURL(name string) string {
+	return s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + s3EscapePath(name)
+}
+
+func (s *S3BlobStore) do(ctx context.Context, req *http.Request, payloadHash string) error {
+	s.sign(req, payloadHash)
+	resp, err := s.cfg.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not do %s object request: %v", req.Method, err)
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("could not %s object: %s: %s", req.Method, resp.Status, b)
+	}
+
+	return nil
+}
+
+// sign adds the signature v4 Authorization header.
+func (s *S3BlobStore) sign(req *http.Request, payloadHash string) {
+	t := s.now().UTC()
+	scope := s.scope(t)
+	req.Header.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vv := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(vv, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, s.signature(t, scope, canonical)))
+}
+
+func (s *S3BlobStore) scope(t time.Time) string {
+	return t.Format(s3DateFormat) + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3BlobStore) signature(t time.Time, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format(s3TimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), t.Format(s3DateFormat))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// "/"는 그대로 두고 각 경로 조각을 인코딩
+func s3EscapePath(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = s3Escape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+func s3CanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vv := append([]string(nil), q[k]...)
+		sort.Strings(vv)
+		for _, v := range vv {
+			pairs = append(pairs, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything except the RFC 3986 unreserved characters.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrForbiddenBlock is used when you try to block yourself
+	ErrForbiddenBlock = newError(http.StatusConflict, "forbidden_block", "cannot block yourself")
+	// ErrForbiddenMute is used when you try to mute yourself
+	ErrForbiddenMute = newError(http.StatusConflict, "forbidden_mute", "cannot mute yourself")
+)
+
+// 차단 결과
+// ToggleBlockOutput response
+type ToggleBlockOutput struct {
+	Blocked bool `json:"blocked"`
+}
+
+// 뮤트 결과
+// ToggleMuteOutput response
+type ToggleMuteOutput struct {
+	Muted bool `json:"muted"`
+}
+
+// 차단 기능
+// ToggleBlock between two users. Blocking removes the follows in both directions,
+// and hides posts, comments, profiles and notifications from each other.
+func (s *Service) ToggleBlock(ctx context.Context, username string) (ToggleBlockOutput, error) {
+	var out ToggleBlockOutput
+	blockerID, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !rxUsername.MatchString(username) {
+		return out, ErrInvalidUsername
+	}
+
+	err := s.store.Tx(ctx, func(st Store) error {
+		blockedID, err := st.UserIDByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		if blockedID == blockerID {
+			return ErrForbiddenBlock
+		}
+
+		if out.Blocked, err = st.Blocking(ctx, blockerID, blockedID); err != nil {
+			return err
+		}
+
+		if out.Blocked {
+			if err = st.DeleteBlock(ctx, blockerID, blockedID); err != nil {
+				return err
+			}
+			return emitEvent(ctx, st, EventUnblocked, RelationEvent{UserID: blockerID, TargetID: blockedID})
+		}
+
+		if err = st.InsertBlock(ctx, blockerID, blockedID); err != nil {
+			return err
+		}
+
+		// 서로의 팔로우와 팔로우 요청 끊기
+		// unfollow in both directions
+		for _, pair := range [][2]int64{{blockerID, blockedID}, {blockedID, blockerID}} {
+			unfollowed, err := st.DeleteFollow(ctx, pair[0], pair[1])
+			if err != nil {
+				return err
+			}
+
+			if unfollowed {
+				if err = emitEvent(ctx, st, EventUnfollowed, RelationEvent{UserID: pair[0], TargetID: pair[1]}); err != nil {
+					return err
+				}
+			}
+
+			if err = st.DeleteFollowRequest(ctx, pair[0], pair[1]); err != nil {
+				return err
+			}
+
+			// 서로에게 받은 알림에서 지우기
+			// drop each other from the notifications
+			if err = st.DropNotificationActor(ctx, pair[0], pair[1]); err != nil {
+				return err
+			}
+		}
+
+		return emitEvent(ctx, st, EventBlocked, RelationEvent{UserID: blockerID, TargetID: blockedID})
+	})
+	if err != nil {
+		return out, err
+	}
+
+	s.wakeOutbox()
+
+	out.Blocked = !out.Blocked
+	return out, nil
+}
+
+// 뮤트 기능, 타임라인에서만 숨김
+// ToggleMute hides the posts of the user from the authenticated user timeline.
+func (s *Service) ToggleMute(ctx context.Context, username string) (ToggleMuteOutput, error) {
+	var out ToggleMuteOutput
+	muterID, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !rxUsername.MatchString(username) {
+		return out, ErrInvalidUsername
+	}
+
+	err := s.store.Tx(ctx, func(st Store) error {
+		mutedID, err := st.UserIDByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		if mutedID == muterID {
+			return ErrForbiddenMute
+		}
+
+		if out.Muted, err = st.Muting(ctx, muterID, mutedID); err != nil {
+			return err
+		}
+
+		event := EventMuted
+		if out.Muted {
+			err = st.DeleteMute(ctx, muterID, mutedID)
+			event = EventUnmuted
+		} else {
+			err = st.InsertMute(ctx, muterID, mutedID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return emitEvent(ctx, st, event, RelationEvent{UserID: muterID, TargetID: mutedID})
+	})
+	if err != nil {
+		return out, err
+	}
+
+	s.wakeOutbox()
+
+	out.Muted = !out.Muted
+	return out, nil
+}
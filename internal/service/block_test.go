@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestToggleBlock(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	aliceCtx, aliceID := newTestUser(t, s, "alice")
+	bobCtx, bobID := newTestUser(t, s, "bob")
+	carolCtx, _ := newTestUser(t, s, "carol")
+
+	for _, f := range []struct {
+		ctx      context.Context
+		username string
+	}{{aliceCtx, "bob"}, {bobCtx, "alice"}} {
+		if _, err := s.Follow(f.ctx, f.username); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bobPost, err := s.CreatePost(bobCtx, "bob post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carolPost, err := s.CreatePost(carolCtx, "carol post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.CreateComment(bobCtx, carolPost.Post.ID, "bob comment", nil); err != nil {
+		t.Fatal(err)
+	}
+	runTestJobs(t, s)
+
+	out, err := s.ToggleBlock(aliceCtx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !out.Blocked {
+		t.Fatalf("block = %+v, want blocked", out)
+	}
+
+	// 서로의 팔로우가 끊김
+	for _, pair := range [][2]int64{{aliceID, bobID}, {bobID, aliceID}} {
+		if following, _ := s.store.Following(ctx, pair[0], pair[1]); following {
+			t.Errorf("user %d still follows %d", pair[0], pair[1])
+		}
+	}
+
+	if n := outboxCount(s, EventUnfollowed); n != 2 {
+		t.Errorf("%d unfollowed events, want 2", n)
+	}
+
+	// 서로 받은 팔로우 알림도 지워짐
+	if nn, _ := s.Notifications(aliceCtx, 0, 0); len(nn) != 0 {
+		t.Errorf("notifications of alice = %+v, want none", nn)
+	}
+
+	// 게시물은 양쪽 모두 안 보임
+	_, err = s.Post(aliceCtx, bobPost.Post.ID)
+	assertError(t, err, ErrPostNotFound)
+
+	if tt, _ := s.Timeline(aliceCtx, 0, 0); len(tt) != 0 {
+		t.Errorf("timeline of alice = %+v, want no posts of bob", tt)
+	}
+
+	// 다른 사람 게시물의 댓글도 안 보임
+	cc, err := s.Comments(aliceCtx, carolPost.Post.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cc) != 0 {
+		t.Errorf("comments seen by alice = %+v, want none of bob", cc)
+	}
+
+	if cc, _ = s.Comments(carolCtx, carolPost.Post.ID, 0, 0); len(cc) != 1 {
+		t.Errorf("comments seen by carol = %d, want 1", len(cc))
+	}
+
+	// 차단한 사이는 다시 팔로우할 수 없음
+	_, err = s.Follow(bobCtx, "alice")
+	assertError(t, err, ErrUserNotFound)
+
+	_, err = s.Follow(aliceCtx, "bob")
+	assertError(t, err, ErrUserNotFound)
+
+	// 차단을 풀어도 팔로우는 돌아오지 않음
+	if out, err = s.ToggleBlock(aliceCtx, "bob"); err != nil || out.Blocked {
+		t.Fatalf("unblock = %+v, %v", out, err)
+	}
+
+	if following, _ := s.store.Following(ctx, aliceID, bobID); following {
+		t.Error("follow restored after unblock")
+	}
+
+	if _, err = s.Post(aliceCtx, bobPost.Post.ID); err != nil {
+		t.Errorf("post after unblock: %v", err)
+	}
+}
+
+func TestToggleBlockFollowRequest(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	bobCtx, _ := newTestUser(t, s, "bob")
+	if err := s.SetPrivate(aliceCtx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := s.Follow(bobCtx, "alice"); err != nil || !out.Requested {
+		t.Fatalf("follow request = %+v, %v", out, err)
+	}
+
+	if _, err := s.ToggleBlock(aliceCtx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 대기 중인 요청은 지워지고 새로 보낼 수 없음
+	if uu, _ := s.FollowRequests(aliceCtx, 0, ""); len(uu) != 0 {
+		t.Errorf("follow requests = %+v, want none", uu)
+	}
+
+	_, err := s.Follow(bobCtx, "alice")
+	assertError(t, err, ErrUserNotFound)
+
+	err = s.ApproveFollowRequest(aliceCtx, "bob")
+	assertError(t, err, ErrFollowRequestNotFound)
+}
+
+func TestToggleBlockErrors(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		username string
+		want     *Error
+	}{
+		{"unauthenticated", context.Background(), "alice", ErrUnauthenticated},
+		{"invalid username", aliceCtx, "no spaces", ErrInvalidUsername},
+		{"missing user", aliceCtx, "nobody", ErrUserNotFound},
+		{"self", aliceCtx, "alice", ErrForbiddenBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ToggleBlock(tt.ctx, tt.username)
+			assertError(t, err, tt.want)
+
+			_, err = s.ToggleMute(tt.ctx, tt.username)
+			if tt.want == ErrForbiddenBlock {
+				tt.want = ErrForbiddenMute
+			}
+			assertError(t, err, tt.want)
+		})
+	}
+
+	// 팔로우처럼 자기 자신은 409
+	for _, err := range []*Error{ErrForbiddenBlock, ErrForbiddenMute} {
+		if err.Status != http.StatusConflict {
+			t.Errorf("%s status = %d, want 409", err.Code, err.Status)
+		}
+	}
+}
+
+func TestToggleMute(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, aliceID := newTestUser(t, s, "alice")
+	bobCtx, bobID := newTestUser(t, s, "bob")
+	carolCtx, _ := newTestUser(t, s, "carol")
+
+	for _, username := range []string{"bob", "carol"} {
+		if _, err := s.Follow(aliceCtx, username); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bobPost, err := s.CreatePost(bobCtx, "bob post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	carolPost, err := s.CreatePost(carolCtx, "carol post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runTestJobs(t, s)
+
+	timeline := func() []int64 {
+		t.Helper()
+		tt, err := s.Timeline(aliceCtx, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]int64, len(tt))
+		for i, ti := range tt {
+			ids[i] = ti.Post.ID
+		}
+		return ids
+	}
+
+	out, err := s.ToggleMute(aliceCtx, "bob")
+	if err != nil || !out.Muted {
+		t.Fatalf("mute = %+v, %v", out, err)
+	}
+
+	// 타임라인에서만 빠지고 팔로우와 게시물은 그대로
+	if ids := timeline(); len(ids) != 1 || ids[0] != carolPost.Post.ID {
+		t.Errorf("timeline after mute = %v, want only post %d", ids, carolPost.Post.ID)
+	}
+
+	if _, err = s.Post(aliceCtx, bobPost.Post.ID); err != nil {
+		t.Errorf("muted post: %v", err)
+	}
+
+	if following, _ := s.store.Following(context.Background(), aliceID, bobID); !following {
+		t.Error("mute removed the follow")
+	}
+
+	if out, err = s.ToggleMute(aliceCtx, "bob"); err != nil || out.Muted {
+		t.Fatalf("unmute = %+v, %v", out, err)
+	}
+
+	if ids := timeline(); len(ids) != 2 {
+		t.Errorf("timeline after unmute = %v, want both posts", ids)
+	}
+}
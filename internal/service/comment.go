@@ -2,31 +2,37 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
+	"net/http"
 	"strings"
 	"time"
-	"errors"
 )
 
-// ErrCommentNotFound denotes that hte comment was not found.
-var ErrCommentNotFound = errors.New("comment not found")
+var (
+	// ErrCommentNotFound denotes that hte comment was not found.
+	ErrCommentNotFound = newError(http.StatusNotFound, "comment_not_found", "comment not found")
+	// ErrPermissionDenied is used when the authenticated user does not own the resource.
+	ErrPermissionDenied = newError(http.StatusForbidden, "permission_denied", "permission denied")
+)
 
 // Comment model
 type Comment struct {
-	ID         int64     `json:"id"`
-	UserID     int64     `json:"-"`
-	PostID     int64     `json:"-"`
-	Content    string    `json:"content"`
-	LikesCount int       `json:"likes_count"`
-	CreatedAt  time.Time `json:"created_at"`
-	User       *User     `json:"user,omitempty"`
-	Mine       bool      `json:"mine"`
-	Liked      bool      `json:"liked"`
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"-"`
+	PostID       int64     `json:"-"`
+	ParentID     *int64    `json:"parent_id,omitempty"`
+	Content      string    `json:"content"`
+	LikesCount   int       `json:"likes_count"`
+	RepliesCount int       `json:"replies_count"`
+	Deleted      bool      `json:"deleted"`
+	CreatedAt    time.Time `json:"created_at"`
+	User         *User     `json:"user,omitempty"`
+	Mine         bool      `json:"mine"`
+	Liked        bool      `json:"liked"`
+	Held         bool      `json:"held,omitempty"`
 }
 
-// CreateComment on a post.
-func (s *Service) CreateComment(ctx context.Context, postID int64, content string) (Comment, error) {
+// CreateComment on a post. With a parentID it replies to that comment.
+func (s *Service) CreateComment(ctx context.Context, postID int64, content string, parentID *int64) (Comment, error) {
 	var c Comment
 	uid, ok := ctx.Value(KeyAuthUserID).(int64)
 	if !ok {
@@ -38,169 +44,297 @@ func (s *Service) CreateComment(ctx context.Context, postID int64, content strin
 		return c, ErrInvalidContent
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	// 댓글에는 nsfw 표시가 없어서 검토 대기로 처리
+	// comments have no nsfw flag so they are held instead
+	verdict, err := s.filterContent(ctx, "comments", uid, content)
 	if err != nil {
-		return c, fmt.Errorf("could not begin tx: %v", err)
+		return c, err
 	}
 
-	defer tx.Rollback()
-
-	query := `
-		INSERT INTO comments (user_id, post_id, content) VALUES ($1, $2, $3)
-		RETURNING id, created_at`
-	err = tx.QueryRowContext(ctx, query, uid, postID, content).Scan(&c.ID, &c.CreatedAt)
-	if isForeignKeyViolation(err) {
-		return c, ErrPostNotFound
+	switch verdict.action {
+	case FilterReject:
+		return c, ErrContentRejected
+	case FilterNSFW:
+		verdict.action = FilterHold
 	}
 
-	if err != nil {
-		return c, fmt.Errorf("could not insert comment: %v", err)
-	}
+	var parentAuthorID int64
+	err = s.store.Tx(ctx, func(st Store) error {
+		c = Comment{}
 
-	c.UserID = uid
-	c.PostID = postID
-	c.Content = content
-	c.Mine = true
+		// 볼 수 없는 게시물(숨김, 차단, 팔로우하지 않은 비공개 계정)에는 댓글 금지
+		// no comments on posts the user can not see
+		if _, err := st.Post(ctx, uid, postID); err != nil {
+			return err
+		}
 
-	query = "UPDATE posts SET comments_count = comments_count + 1 WHERE id = $1"
-	if _, err = tx.ExecContext(ctx, query, postID); err != nil {
-		return c, fmt.Errorf("could not update and increment post comments count: %v", err)
-	}
+		// 답글은 같은 게시물의 지워지지 않은 댓글에만
+		// replies go to a live comment of the same post
+		if parentID != nil {
+			parent, err := st.Comment(ctx, uid, *parentID)
+			if err != nil {
+				return err
+			}
+
+			if parent.PostID != postID || parent.Deleted {
+				return ErrCommentNotFound
+			}
+
+			parentAuthorID = parent.UserID
+			if err = st.AddRepliesCount(ctx, *parentID, 1); err != nil {
+				return err
+			}
+		}
+
+		c.UserID = uid
+		c.PostID = postID
+		c.ParentID = parentID
+		c.Content = content
+		c.Mine = true
+		if err = st.InsertComment(ctx, &c); err != nil {
+			return err
+		}
 
-	if err = tx.Commit(); err != nil {
-		return c, fmt.Errorf("could not commit to create comment: %v", err)
+		if verdict.action == FilterHold {
+			if err = holdForReview(ctx, st, ReportTargetComment, c.ID, verdict.reason); err != nil {
+				return err
+			}
+			c.Held = true
+		}
+
+		if err = st.AddCommentsCount(ctx, postID, 1); err != nil {
+			return err
+		}
+
+		err = emitEvent(ctx, st, EventCommentCreated, CommentEvent{
+			CommentID: c.ID,
+			PostID:    postID,
+			UserID:    uid,
+			ParentID:  parentID,
+			Content:   content,
+			Held:      c.Held,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 내 댓글에 답글이 달렸을 때 알림
+		if parentID == nil || parentAuthorID == uid {
+			return nil
+		}
+		return enqueueJob(ctx, st, JobNotifyPost, notifyPostJob{Type: "comment_reply", ActorID: uid, UserID: parentAuthorID, PostID: postID})
+	})
+	if err != nil {
+		return c, err
 	}
+
+	s.wakeJobs(JobNotifyPost)
+	s.wakeOutbox()
+
 	return c, nil
 }
 
 // Comments from a post in descending order with backward pagination.
+// Only top level comments are returned, replies are paginated with Replies.
 func (s *Service) Comments(ctx context.Context, postID int64, last int, before int64) ([]Comment, error) {
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
-	last = normailizePageSize(last)
-	query, args, err := buildQuery(`
-	SELECT comments.id, content, likes_count, created_at, username, avatar
-	{{if .auth}}
-	, comments.user_id = @uid AS mine
-	, likes.user_id IS NOT NULL AS liked
-	{{end}}
-	FROM comments
-	INNER JOIN users ON comments.user_id = users.id
-	{{if .auth}}
-	LEFT JOIN comment_likes AS likes
-		ON likes.comment_id = comments.id AND likes.user_id = @uid
-	{{end}}
-	WHERE comments.post_id = @post_id
-	{{if .before}}AND comments.id < @before{{end}}
-	ORDER BY created_at DESC
-	LIMIT @last`, map[string]interface{}{
-		"auth":    auth,
-		"uid":     uid,
-		"post_id": postID,
-		"before":  before,
-		"last":    last,
-	})
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
+	cc, err := s.store.Comments(ctx, uid, postID, normailizePageSize(last), before)
 	if err != nil {
-		return nil, fmt.Errorf("could not build comments sql query: %v", err)
+		return nil, err
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	s.comments(cc)
+	return cc, nil
+}
+
+// PostsComments is Comments of several posts at once, keyed by the post ID.
+func (s *Service) PostsComments(ctx context.Context, postIDs []int64, last int, before int64) (map[int64][]Comment, error) {
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
+	pc, err := s.store.PostsComments(ctx, uid, postIDs, normailizePageSize(last), before)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cc := range pc {
+		s.comments(cc)
+	}
+	return pc, nil
+}
+
+// Replies to a comment in descending order with backward pagination.
+func (s *Service) Replies(ctx context.Context, commentID int64, last int, before int64) ([]Comment, error) {
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
+	cc, err := s.store.Replies(ctx, uid, commentID, normailizePageSize(last), before)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select comments: %v", err)
+		return nil, err
+	}
+
+	s.comments(cc)
+	return cc, nil
+}
+
+// 지워진 댓글은 답글을 위해 자리만 남김
+func (s *Service) comments(cc []Comment) {
+	for i := range cc {
+		c := &cc[i]
+		if c.Deleted {
+			c.Mine = false
+			c.User = nil
+			continue
+		}
+
+		s.userAvatar(c.User)
+	}
+}
+
+// 댓글 삭제, 답글이 있으면 내용만 지우고 자리를 남김
+// DeleteComment of the authenticated user. Comments with replies are
+// kept as a tombstone so the thread stays in place, until their last reply goes.
+func (s *Service) DeleteComment(ctx context.Context, commentID int64) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return ErrUnauthenticated
 	}
 
-	defer rows.Close()
+	err := s.store.Tx(ctx, func(st Store) error {
+		c, err := st.CommentByID(ctx, commentID)
+		if err != nil {
+			return err
+		}
 
-	cc := make([]Comment, 0, last)
-	for rows.Next() {
-		var c Comment
-		var u User
-		var avatar sql.NullString
-		dest := []interface{}{
-			&c.ID,
-			&c.Content,
-			&c.LikesCount,
-			&c.CreatedAt,
-			&u.UserName,
-			&avatar,
+		if c.Deleted {
+			return ErrCommentNotFound
 		}
-		if auth {
-			dest = append(dest, &c.Mine, &c.Liked)
+
+		if c.UserID != uid {
+			return ErrPermissionDenied
 		}
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan comment: %v", err)
+
+		if c.RepliesCount > 0 {
+			err = st.TombstoneComment(ctx, commentID)
+		} else {
+			if err = st.DeleteComment(ctx, commentID); err != nil {
+				return err
+			}
+
+			err = removeTombstones(ctx, st, c.ParentID)
+		}
+		if err != nil {
+			return err
 		}
 
-		if avatar.Valid {
-			avatarURL := s.origin + "/img/avatars/" + avatar.String
-			u.AvatarURL = &avatarURL
+		if err = st.AddCommentsCount(ctx, c.PostID, -1); err != nil {
+			return err
 		}
-		c.User = &u
-		cc = append(cc, c)
-	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate comment rows: %v", err)
+		return emitEvent(ctx, st, EventCommentDeleted, CommentEvent{
+			CommentID: commentID,
+			PostID:    c.PostID,
+			UserID:    uid,
+			ParentID:  c.ParentID,
+		})
+	})
+	if err != nil {
+		return err
 	}
 
-	return cc, nil
+	s.wakeOutbox()
+	return nil
+}
+
+// 답글이 지워지면 부모의 답글 수를 줄이고, 마지막 답글을 잃은 자리만 남은 부모도 지움
+// the count was already taken off comments_count when the parent was tombstoned
+func removeTombstones(ctx context.Context, st Store, parentID *int64) error {
+	for parentID != nil {
+		if err := st.AddRepliesCount(ctx, *parentID, -1); err != nil {
+			return err
+		}
+
+		parent, err := st.CommentByID(ctx, *parentID)
+		if err != nil {
+			return err
+		}
+
+		if !parent.Deleted || parent.RepliesCount > 0 {
+			return nil
+		}
+
+		if err = st.DeleteComment(ctx, parent.ID); err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+	return nil
 }
 
 // ToggleCommentLike
 func (s *Service) ToggleCommentLike(ctx context.Context, commentID int64) (ToggleLikeOutput, error) {
+	return s.setCommentLike(ctx, commentID, nil)
+}
+
+// LikeComment is the idempotent form of ToggleCommentLike.
+func (s *Service) LikeComment(ctx context.Context, commentID int64) (ToggleLikeOutput, error) {
+	liked := true
+	return s.setCommentLike(ctx, commentID, &liked)
+}
+
+// UnlikeComment is the idempotent form of ToggleCommentLike.
+func (s *Service) UnlikeComment(ctx context.Context, commentID int64) (ToggleLikeOutput, error) {
+	liked := false
+	return s.setCommentLike(ctx, commentID, &liked)
+}
+
+// like가 nil이면 토글
+func (s *Service) setCommentLike(ctx context.Context, commentID int64, like *bool) (ToggleLikeOutput, error) {
 	var out ToggleLikeOutput
 	uid, ok := ctx.Value(KeyAuthUserID).(int64)
 	if !ok {
 		return out, ErrUnauthenticated
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return out, fmt.Errorf("could not begin tx: %v", err)
-	}
-
-	defer tx.Rollback()
-
-	query := `
-		SELECT EXISTS (
-			SELECT 1 FROM comment_likes WHERE user_id = $1 AND comment_id = $2
-		)
-	`
-	if err := tx.QueryRowContext(ctx, query, uid, commentID).Scan(&out.Liked); err != nil {
-		return out, fmt.Errorf("could not query select comment like existenct: %v", err)
-	}
-
-	if out.Liked {
-		query := "DELETE FROM comment_likes WHERE user_id = $1 AND comment_id = $2"
-		if _, err = tx.ExecContext(ctx, query, uid, commentID); err != nil {
-			return out, fmt.Errorf("could not delete comment like: %v", err)
+	err := s.store.Tx(ctx, func(st Store) error {
+		// 볼 수 없거나 지워진 댓글은 없는 것과 같음
+		c, err := st.Comment(ctx, uid, commentID)
+		if err != nil {
+			return err
 		}
 
-		query = "UPDATE comments SET likes_count = likes_count - 1 WHERE id = $1 RETURNING likes_count"
-		if err = tx.QueryRowContext(ctx, query, commentID).Scan(&out.LikesCount); err != nil {
-			return out, fmt.Errorf("could not update and decrement comment likes count: %v", err)
-		}
-	} else {
-		query = "INSERT INTO comment_likes (user_id, comment_id) VALUES ($1, $2)"
-		_, err = tx.ExecContext(ctx, query, uid, commentID)
-		if isForeignKeyViolation(err) {
-			return out, ErrCommentNotFound
+		if c.Deleted {
+			return ErrCommentNotFound
 		}
 
+		liked, err := st.CommentLiked(ctx, uid, commentID)
 		if err != nil {
-			return out, fmt.Errorf("could not insert comment like: %v", err)
+			return err
 		}
 
-		query = "UPDATE comments SET likes_count = likes_count + 1 WHERE id = $1 RETURNING likes_count"
-		if err = tx.QueryRowContext(ctx, query, commentID).Scan(&out.LikesCount); err != nil {
-			return out, fmt.Errorf("could not update and increment comment likes count: %v", err)
+		out.Liked = !liked
+		if like != nil {
+			out.Liked = *like
 		}
-	}
 
-	if err = tx.Commit(); err != nil {
-		return out, fmt.Errorf("could not commit to toogle comment like: %v", err)
+		event := EventCommentUnliked
+		switch {
+		case out.Liked == liked:
+			out.LikesCount, err = st.CommentLikesCount(ctx, commentID)
+			return err
+		case out.Liked:
+			out.LikesCount, err = st.InsertCommentLike(ctx, uid, commentID)
+			event = EventCommentLiked
+		default:
+			out.LikesCount, err = st.DeleteCommentLike(ctx, uid, commentID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return emitEvent(ctx, st, event, LikeEvent{UserID: uid, CommentID: commentID, LikesCount: out.LikesCount})
+	})
+	if err != nil {
+		return out, err
 	}
 
-	out.Liked = !out.Liked
+	s.wakeOutbox()
 
 	return out, nil
 }
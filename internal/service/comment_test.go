@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCreateComment(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	bobCtx, bobID := newTestUser(t, s, "bob")
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.CreateComment(bobCtx, p.Post.ID, "  nice  ", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.ID == 0 || c.Content != "nice" || c.UserID != bobID || c.PostID != p.Post.ID || !c.Mine {
+		t.Errorf("comment = %+v", c)
+	}
+
+	reply, err := s.CreateComment(authorCtx, p.Post.ID, "thanks", &c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.ParentID == nil || *reply.ParentID != c.ID {
+		t.Errorf("reply parent = %v, want %d", reply.ParentID, c.ID)
+	}
+
+	post, err := s.Post(authorCtx, p.Post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if post.CommentsCount != 2 {
+		t.Errorf("comments count = %d, want 2", post.CommentsCount)
+	}
+
+	// 답글 알림은 작업으로
+	runTestJobs(t, s)
+	nn, err := s.Notifications(bobCtx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nn) != 1 || nn[0].Type != "comment_reply" {
+		t.Errorf("notifications = %+v, want one comment_reply", nn)
+	}
+}
+
+func TestCreateCommentErrors(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	bobCtx, _ := newTestUser(t, s, "bob")
+	blockedCtx, _ := newTestUser(t, s, "blocked")
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := s.CreatePost(authorCtx, "other post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.CreateComment(bobCtx, p.Post.ID, "comment", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := s.CreateComment(bobCtx, p.Post.ID, "deleted", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.DeleteComment(bobCtx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.ToggleBlock(authorCtx, "blocked"); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := c.ID + 1000
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		postID   int64
+		content  string
+		parentID *int64
+		want     *Error
+	}{
+		{"unauthenticated", context.Background(), p.Post.ID, "hi", nil, ErrUnauthenticated},
+		{"empty", bobCtx, p.Post.ID, " ", nil, ErrInvalidContent},
+		{"missing post", bobCtx, missing, "hi", nil, ErrPostNotFound},
+		{"blocked", blockedCtx, p.Post.ID, "hi", nil, ErrPostNotFound},
+		{"missing parent", bobCtx, p.Post.ID, "hi", &missing, ErrCommentNotFound},
+		{"parent on another post", bobCtx, other.Post.ID, "hi", &c.ID, ErrCommentNotFound},
+		{"deleted parent", bobCtx, p.Post.ID, "hi", &deleted.ID, ErrCommentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateComment(tt.ctx, tt.postID, tt.content, tt.parentID)
+			assertError(t, err, tt.want)
+		})
+	}
+}
+
+// 자리만 남은 댓글은 마지막 답글과 함께 사라짐, 위로 이어진 자리도
+func TestDeleteCommentRemovesTombstones(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	bobCtx, _ := newTestUser(t, s, "bob")
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.CreateComment(bobCtx, p.Post.ID, "comment", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := s.CreateComment(authorCtx, p.Post.ID, "reply", &c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nested, err := s.CreateComment(bobCtx, p.Post.ID, "nested", &reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.DeleteComment(bobCtx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.DeleteComment(authorCtx, reply.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	cc, err := s.Comments(authorCtx, p.Post.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cc) != 1 || !cc[0].Deleted || cc[0].RepliesCount != 1 {
+		t.Fatalf("comments = %+v, want the tombstone with one reply", cc)
+	}
+
+	if err = s.DeleteComment(bobCtx, nested.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if cc, err = s.Comments(authorCtx, p.Post.ID, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cc) != 0 {
+		t.Errorf("comments = %+v, want the tombstones gone", cc)
+	}
+
+	for _, id := range []int64{c.ID, reply.ID} {
+		_, err = s.store.CommentByID(context.Background(), id)
+		assertError(t, err, ErrCommentNotFound)
+	}
+
+	post, err := s.Post(authorCtx, p.Post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if post.CommentsCount != 0 {
+		t.Errorf("comments count = %d, want 0", post.CommentsCount)
+	}
+}
+
+func TestLikeCommentIdempotent(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	bobCtx, _ := newTestUser(t, s, "bob")
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.CreateComment(authorCtx, p.Post.ID, "comment", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name    string
+		fn      func(context.Context, int64) (ToggleLikeOutput, error)
+		liked   bool
+		count   int
+		likes   int
+		unlikes int
+	}{
+		{"like", s.LikeComment, true, 1, 1, 0},
+		{"like again", s.LikeComment, true, 1, 1, 0},
+		{"unlike", s.UnlikeComment, false, 0, 1, 1},
+		{"unlike again", s.UnlikeComment, false, 0, 1, 1},
+	}
+	for _, step := range steps {
+		out, err := step.fn(bobCtx, c.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if out.Liked != step.liked || out.LikesCount != step.count {
+			t.Errorf("%s = %+v, want liked %v with %d likes", step.name, out, step.liked, step.count)
+		}
+
+		if likes, unlikes := outboxCount(s, EventCommentLiked), outboxCount(s, EventCommentUnliked); likes != step.likes || unlikes != step.unlikes {
+			t.Errorf("%s: %d liked and %d unliked events, want %d and %d", step.name, likes, unlikes, step.likes, step.unlikes)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import "net/http"
+
+// 서비스 에러, API 응답에 그대로 쓰임
+// Error returned by the service with a stable machine-readable code.
+// Field names the invalid input and Details lists every invalid field
+// when more than one failed validation.
+type Error struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Field   string   `json:"field,omitempty"`
+	Details []*Error `json:"details,omitempty"`
+	Status  int      `json:"-"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions adds the code and field to GraphQL errors.
+func (e *Error) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.Code}
+	if e.Field != "" {
+		ext["field"] = e.Field
+	}
+	if len(e.Details) != 0 {
+		ext["details"] = e.Details
+	}
+	return ext
+}
+
+func newError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func newFieldError(status int, code, message, field string) *Error {
+	return &Error{Status: status, Code: code, Message: message, Field: field}
+}
+
+// ErrInvalidInput is used when more than one field failed validation.
+var ErrInvalidInput = newError(http.StatusUnprocessableEntity, "invalid_input", "invalid input")
+
+// 검사에 실패한 입력을 모아서 하나의 에러로
+// invalid returns nil, the only failed field or ErrInvalidInput with every failed field.
+func invalid(errs ...*Error) error {
+	var failed []*Error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	}
+
+	return &Error{
+		Status:  ErrInvalidInput.Status,
+		Code:    ErrInvalidInput.Code,
+		Message: ErrInvalidInput.Message,
+		Details: failed,
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// 다른 서비스(검색, 분석 ...)로 보내는 이벤트 종류
+// Types of the domain events written to the outbox.
+const (
+	EventUserCreated          = "user.created"
+	EventAvatarUpdated        = "user.avatar_updated"
+	EventPrivacyChanged       = "user.privacy_changed"
+	EventFollowed             = "user.followed"
+	EventUnfollowed           = "user.unfollowed"
+	EventFollowRequested      = "user.follow_requested"
+	EventFollowRequestDeleted = "user.follow_request_deleted"
+	EventBlocked              = "user.blocked"
+	EventUnblocked            = "user.unblocked"
+	EventMuted                = "user.muted"
+	EventUnmuted              = "user.unmuted"
+	EventPostCreated          = "post.created"
+	EventPostLiked            = "post.liked"
+	EventPostUnliked          = "post.unliked"
+	EventCommentCreated       = "comment.created"
+	EventCommentDeleted       = "comment.deleted"
+	EventCommentLiked         = "comment.liked"
+	EventCommentUnliked       = "comment.unliked"
+	EventReportCreated        = "report.created"
+	EventReportResolved       = "report.resolved"
+	EventOrderPlaced          = "order.placed"
+)
+
+// 아웃박스 전달
+const (
+	outboxBatch        = 100
+	outboxLease        = time.Second * 30
+	outboxPollInterval = time.Second
+	minOutboxBackoff   = time.Second
+)
+
+// eventMetrics counts published events and failed publishes. Published as the "events" expvar.
+var eventMetrics = expvar.NewMap("events")
+
+// Event written to the outbox in the same transaction as the change it describes.
+// Delivery is at-least-once, consumers drop the IDs they already handled.
+type Event struct {
+	ID         int64           `json:"id,string"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// UserEvent is the payload of user.created, user.avatar_updated and user.privacy_changed.
+type UserEvent struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
+	Private  *bool  `json:"private,omitempty"`
+}
+
+// RelationEvent is the payload of the follow, block and mute events.
+// UserID is who followed, blocked or muted TargetID.
+type RelationEvent struct {
+	UserID   int64 `json:"user_id"`
+	TargetID int64 `json:"target_id"`
+}
+
+// PostEvent is the payload of post.created. Held posts wait for moderation.
+type PostEvent struct {
+	PostID     int64   `json:"post_id"`
+	UserID     int64   `json:"user_id"`
+	Content    string  `json:"content"`
+	SpoilerOf  *string `json:"spoiler_of,omitempty"`
+	NSFW       bool    `json:"nsfw"`
+	RepostOfID *int64  `json:"repost_of_id,omitempty"`
+	Held       bool    `json:"held,omitempty"`
+}
+
+func postEvent(p Post) PostEvent {
+	return PostEvent{
+		PostID:     p.ID,
+		UserID:     p.UserID,
+		Content:    p.Content,
+		SpoilerOf:  p.SpoilerOf,
+		NSFW:       p.NSFW,
+		RepostOfID: p.RepostOfID,
+		Held:       p.Held,
+	}
+}
+
+// CommentEvent is the payload of comment.created and comment.deleted.
+type CommentEvent struct {
+	CommentID int64  `json:"comment_id"`
+	PostID    int64  `json:"post_id"`
+	UserID    int64  `json:"user_id"`
+	ParentID  *int64 `json:"parent_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Held      bool   `json:"held,omitempty"`
+}
+
+// LikeEvent is the payload of the post and comment like events.
+type LikeEvent struct {
+	UserID     int64 `json:"user_id"`
+	PostID     int64 `json:"post_id,omitempty"`
+	CommentID  int64 `json:"comment_id,omitempty"`
+	LikesCount int   `json:"likes_count"`
+}
+
+// ReportEvent is the payload of report.created and report.resolved.
+// Action is the moderation action of report.resolved.
+type ReportEvent struct {
+	ReportID   int64  `json:"report_id"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	Reason     string `json:"reason,omitempty"`
+	Action     string `json:"action,omitempty"`
+}
+
+// OrderEvent is the payload of order.placed.
+type OrderEvent struct {
+	OrderNum int64            `json:"order_num"`
+	UserID   int64            `json:"user_id"`
+	Items    []OrderEventItem `json:"items"`
+}
+
+// OrderEventItem is a post of an order. SellerID is the author of the post.
+type OrderEventItem struct {
+	PostID   int64 `json:"post_id"`
+	SellerID int64 `json:"seller_id"`
+	Quantity int   `json:"quantity"`
+}
+
+// errNoSubscribers keeps the events in the outbox while nobody listens.
+var errNoSubscribers = errors.New("no event subscribers")
+
+// 이벤트를 받는 곳 (같은 프로세스, NATS ...)
+// EventPublisher delivers the outbox events. Publish returns once the event
+// is accepted, an error makes the relay send it again later.
+type EventPublisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// 같은 프로세스 안의 구독자에게 전달
+// EventBus is the in-process EventPublisher. Every subscriber gets every event,
+// when one fails the event is sent again to all of them.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers []func(ctx context.Context, e Event) error
+}
+
+// NewEventBus without subscribers. Publish fails until there is one,
+// so the relay leaves the events in the outbox instead of dropping them.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe adds a handler for every event.
+func (b *EventBus) Subscribe(h func(ctx context.Context, e Event) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish calls the subscribers in order.
+func (b *EventBus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.handlers) == 0 {
+		return errNoSubscribers
+	}
+
+	for _, h := range b.handlers {
+		if err := h(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 트랜잭션 안에서 이벤트 기록, 커밋되어야 보내짐
+// emitEvent writes the event to the outbox through st.
+func emitEvent(ctx context.Context, st Store, typ string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal %s event: %v", typ, err)
+	}
+
+	return st.InsertEvent(ctx, &Event{Type: typ, Payload: b})
+}
+
+// 커밋 후 릴레이를 깨움
+func (s *Service) wakeOutbox() {
+	select {
+	case s.outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOutbox relays the outbox events to pub until ctx is done, oldest first in batches.
+// The batch being published when ctx is done is finished before it returns.
+// Several instances can run it, each batch is leased to one of them.
+func (s *Service) RunOutbox(ctx context.Context, pub EventPublisher) {
+	failures := 0
+	for ctx.Err() == nil {
+		n, err := s.relayEvents(ctx, pub)
+		wait := outboxPollInterval
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+
+			failures++
+			wait = backoff(failures, minOutboxBackoff, outboxLease)
+			log.Printf("could not relay events: %v\n", err)
+		case n == outboxBatch:
+			// 남은 이벤트가 더 있음
+			failures = 0
+			continue
+		default:
+			failures = 0
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+		case <-s.outboxWake:
+		case <-t.C:
+		}
+		t.Stop()
+	}
+}
+
+func (s *Service) relayEvents(ctx context.Context, pub EventPublisher) (int, error) {
+	ee, err := s.store.ClaimEvents(ctx, outboxBatch, outboxLease)
+	if err != nil || len(ee) == 0 {
+		return 0, err
+	}
+
+	// 가져온 묶음은 종료 중에도 끝까지, 임대 시간 안에
+	pubCtx, cancel := context.WithTimeout(context.Background(), outboxLease)
+	defer cancel()
+
+	var published []int64
+	for i, e := range ee {
+		if err = pub.Publish(pubCtx, e); err != nil {
+			eventMetrics.Add("failures", 1)
+			err = fmt.Errorf("could not publish event %d: %v", e.ID, err)
+
+			// 남은 이벤트는 임대 시간을 기다리지 않고 다시 가져갈 수 있게
+			rest := make([]int64, 0, len(ee)-i)
+			for _, e := range ee[i:] {
+				rest = append(rest, e.ID)
+			}
+			if uerr := s.store.UnlockEvents(pubCtx, rest); uerr != nil {
+				log.Printf("could not unlock events: %v\n", uerr)
+			}
+			break
+		}
+
+		published = append(published, e.ID)
+	}
+
+	if len(published) != 0 {
+		eventMetrics.Add("published", int64(len(published)))
+		// 지우지 못하면 임대 시간 뒤에 다시 보내짐
+		if derr := s.store.DeleteEvents(pubCtx, published); derr != nil {
+			return len(ee), derr
+		}
+	}
+
+	return len(ee), err
+}
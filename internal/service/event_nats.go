@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid"
+)
+
+// NATS JetStream으로 이벤트 발행, 연결 하나를 재사용
+// NATSPublisher publishes each event to JetStream on "<prefix>.<event type>"
+// with the event ID as Nats-Msg-Id, so the stream drops duplicates inside its
+// duplicate window. A stream must capture those subjects or Publish fails.
+type NATSPublisher struct {
+	addr    string
+	user    string
+	pass    string
+	token   string
+	prefix  string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	conn  *natsConn
+	inbox string
+	seq   int64
+}
+
+// NewNATSPublisher with a nats://[user:pass@|token@]host:port URL.
+func NewNATSPublisher(rawurl, prefix string) (*NATSPublisher, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse nats url: %v", err)
+	}
+
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported nats url scheme %q", u.Scheme)
+	}
+
+	p := &NATSPublisher{
+		addr:    u.Host,
+		prefix:  strings.TrimSuffix(prefix, "."),
+		timeout: time.Second * 5,
+	}
+	if u.Port() == "" {
+		p.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			p.user, p.pass = u.User.Username(), pass
+		} else {
+			p.token = u.User.Username()
+		}
+	}
+
+	return p, nil
+}
+
+// Publish waits for the stream to store the event.
+func (p *NATSPublisher) Publish(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > p.timeout {
+		deadline = time.Now().Add(p.timeout)
+	}
+	c.SetDeadline(deadline)
+
+	p.seq++
+	reply := p.inbox + "." + strconv.FormatInt(p.seq, 10)
+	if err = c.publish(p.prefix+"."+e.Type, reply, strconv.FormatInt(e.ID, 10), b); err == nil {
+		err = c.ack(reply)
+	}
+
+	if err != nil {
+		// 응답이 늦게 와도 섞이지 않게 연결을 버림
+		c.Close()
+		p.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// Close the connection, the next Publish opens a new one.
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+func (p *NATSPublisher) connect(ctx context.Context) (*natsConn, error) {
+	if p.conn != nil {
+		return p.conn, nil
+	}
+
+	d := net.Dialer{Timeout: p.timeout}
+	nc, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial nats: %v", err)
+	}
+
+	c := &natsConn{Conn: nc, r: bufio.NewReader(nc)}
+	c.SetDeadline(time.Now().Add(p.timeout))
+	if err = p.handshake(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	p.conn = c
+	return c, nil
+}
+
+// INFO을 읽고 CONNECT, PING/PONG으로 인증 확인 후 응답용 inbox 구독
+func (p *NATSPublisher) handshake(c *natsConn) error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+
+	var info struct {
+		Headers bool `json:"headers"`
+	}
+	if !strings.HasPrefix(line, "INFO ") || json.Unmarshal([]byte(line[5:]), &info) != nil {
+		return fmt.Errorf("invalid nats info %q", line)
+	}
+
+	if !info.Headers {
+		return fmt.Errorf("nats server does not support headers")
+	}
+
+	connect, err := json.Marshal(map[string]interface{}{
+		"verbose":       false,
+		"pedantic":      false,
+		"headers":       true,
+		"no_responders": true,
+		"lang":          "go",
+		"name":          "sodam",
+		"protocol":      1,
+		"user":          p.user,
+		"pass":          p.pass,
+		"auth_token":    p.token,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal nats connect: %v", err)
+	}
+
+	if p.inbox == "" {
+		id, err := gonanoid.Generate("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", 22)
+		if err != nil {
+			return fmt.Errorf("could not generate nats inbox: %v", err)
+		}
+		p.inbox = "_INBOX." + id
+	}
+
+	if err = c.write("CONNECT " + string(connect) + "\r\nPING\r\nSUB " + p.inbox + ".* 1\r\n"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return natsError(line)
+		}
+	}
+}
+
+// NATS 텍스트 프로토콜 연결
+type natsConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+type natsError string
+
+func (e natsError) Error() string {
+	return "nats: " + strings.TrimSpace(strings.TrimPrefix(string(e), "-ERR"))
+}
+
+func (c *natsConn) write(s string) error {
+	if _, err := io.WriteString(c, s); err != nil {
+		return fmt.Errorf("could not write to nats: %v", err)
+	}
+	return nil
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("could not read from nats: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *natsConn) publish(subject, reply, msgID string, payload []byte) error {
+	hdr := "NATS/1.0\r\nNats-Msg-Id: " + msgID + "\r\n\r\n"
+	return c.write(fmt.Sprintf("HPUB %s %s %d %d\r\n%s%s\r\n", subject, reply, len(hdr), len(hdr)+len(payload), hdr, payload))
+}
+
+// reply로 오는 PubAck을 기다림, 스트림이 없으면 503 상태만 옴
+func (c *natsConn) ack(reply string) error {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		var hdrLen, totalLen int
+		var subject string
+		switch {
+		case line == "PING":
+			if err = c.write("PONG\r\n"); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(line, "-ERR"):
+			return natsError(line)
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply] <#bytes>
+			f := strings.Fields(line)
+			if len(f) < 4 {
+				return fmt.Errorf("invalid nats message %q", line)
+			}
+			subject = f[1]
+			if totalLen, err = strconv.Atoi(f[len(f)-1]); err != nil {
+				return fmt.Errorf("invalid nats message %q", line)
+			}
+		case strings.HasPrefix(line, "HMSG "):
+			// HMSG <subject> <sid> [reply] <#header bytes> <#total bytes>
+			f := strings.Fields(line)
+			if len(f) < 5 {
+				return fmt.Errorf("invalid nats message %q", line)
+			}
+			subject = f[1]
+			hdrLen, err = strconv.Atoi(f[len(f)-2])
+			if err == nil {
+				totalLen, err = strconv.Atoi(f[len(f)-1])
+			}
+			if err != nil || hdrLen > totalLen {
+				return fmt.Errorf("invalid nats message %q", line)
+			}
+		default:
+			// PONG, +OK, INFO
+			continue
+		}
+
+		buf := make([]byte, totalLen+2)
+		if _, err = io.ReadFull(c.r, buf); err != nil {
+			return fmt.Errorf("could not read nats message: %v", err)
+		}
+
+		// 이전 발행의 늦은 응답
+		if subject != reply {
+			continue
+		}
+
+		// "NATS/1.0 503" 같은 상태 줄
+		if status := strings.Fields(strings.SplitN(string(buf[:hdrLen]), "\r\n", 2)[0]); len(status) > 1 {
+			if status[1] == "503" {
+				return fmt.Errorf("no nats stream for the subject")
+			}
+			return fmt.Errorf("nats status %s", strings.Join(status[1:], " "))
+		}
+
+		var pubAck struct {
+			Stream string `json:"stream"`
+			Error  *struct {
+				Code        int    `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err = json.Unmarshal(buf[hdrLen:totalLen], &pubAck); err != nil {
+			return fmt.Errorf("could not parse nats ack: %v", err)
+		}
+
+		if pubAck.Error != nil {
+			return fmt.Errorf("nats stream error %d: %s", pubAck.Error.Code, pubAck.Error.Description)
+		}
+
+		if pubAck.Stream == "" {
+			return fmt.Errorf("invalid nats ack %q", buf[hdrLen:totalLen])
+		}
+		return nil
+	}
+}
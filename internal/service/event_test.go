@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 받은 이벤트를 모으고 fail번째부터 실패하는 발행자
+type testPublisher struct {
+	events []Event
+	fail   int
+}
+
+func (p *testPublisher) Publish(ctx context.Context, e Event) error {
+	if p.fail != 0 && len(p.events)+1 >= p.fail {
+		return errors.New("publish failed")
+	}
+
+	p.events = append(p.events, e)
+	return nil
+}
+
+// 테스트용 이벤트 n개를 outbox에
+func insertTestEvents(t *testing.T, s *Service, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		e := Event{Type: "test.event", Payload: json.RawMessage(`{}`)}
+		if err := s.store.InsertEvent(context.Background(), &e); err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// outbox에 남은 이벤트 ID
+func outboxIDs(s *Service) map[int64]bool {
+	m := s.store.(*memoryStore)
+	defer m.lock()()
+
+	ids := map[int64]bool{}
+	for id := range m.outbox {
+		ids[id] = true
+	}
+	return ids
+}
+
+// outbox에 남은 typ 이벤트 수
+func outboxCount(s *Service, typ string) int {
+	m := s.store.(*memoryStore)
+	defer m.lock()()
+
+	n := 0
+	for _, e := range m.outbox {
+		if e.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRelayEvents(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	ids := insertTestEvents(t, s, 3)
+
+	published := metricValue(eventMetrics, "published")
+	pub := &testPublisher{}
+	n, err := s.relayEvents(ctx, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 || len(pub.events) != 3 {
+		t.Fatalf("relayed %d, published %d, want 3", n, len(pub.events))
+	}
+
+	// 오래된 것부터
+	for i, e := range pub.events {
+		if e.ID != ids[i] {
+			t.Errorf("event %d = %d, want %d", i, e.ID, ids[i])
+		}
+	}
+
+	if got := metricValue(eventMetrics, "published") - published; got != 3 {
+		t.Errorf("published metric +%d, want +3", got)
+	}
+
+	if left := outboxIDs(s); len(left) != 0 {
+		t.Errorf("outbox after relay = %v, want empty", left)
+	}
+
+	// 보낼 게 없으면 아무것도 안 함
+	if n, err = s.relayEvents(ctx, pub); n != 0 || err != nil {
+		t.Errorf("empty relay = %d, %v", n, err)
+	}
+}
+
+func TestRelayEventsFailure(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	ids := insertTestEvents(t, s, 4)
+
+	failures := metricValue(eventMetrics, "failures")
+	published := metricValue(eventMetrics, "published")
+
+	// 세 번째에서 실패하면 앞의 둘만 지워짐
+	pub := &testPublisher{fail: 3}
+	if _, err := s.relayEvents(ctx, pub); err == nil {
+		t.Fatal("relay with failing publisher succeeded")
+	}
+
+	left := outboxIDs(s)
+	if len(left) != 2 || !left[ids[2]] || !left[ids[3]] {
+		t.Errorf("outbox after failure = %v, want %v", left, ids[2:])
+	}
+
+	if got := metricValue(eventMetrics, "failures") - failures; got != 1 {
+		t.Errorf("failures metric +%d, want +1", got)
+	}
+
+	if got := metricValue(eventMetrics, "published") - published; got != 2 {
+		t.Errorf("published metric +%d, want +2", got)
+	}
+
+	// 남은 이벤트는 임대 시간을 기다리지 않고 바로 다시 보내짐
+	pub.fail = 0
+	n, err := s.relayEvents(ctx, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 || len(pub.events) != 4 || pub.events[2].ID != ids[2] {
+		t.Errorf("retried %d, published %v", n, pub.events)
+	}
+}
+
+func TestClaimEventsLease(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	insertTestEvents(t, s, 2)
+
+	ee, err := s.store.ClaimEvents(ctx, 1, time.Millisecond*50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ee) != 1 {
+		t.Fatalf("claimed %d events, want 1", len(ee))
+	}
+
+	// 임대 중인 이벤트는 다른 릴레이가 가져가지 못함
+	other, err := s.store.ClaimEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(other) != 1 || other[0].ID == ee[0].ID {
+		t.Errorf("second claim = %v, want only the other event", other)
+	}
+
+	// 임대 시간이 지나면 다시 가져감
+	time.Sleep(time.Millisecond * 60)
+	again, err := s.store.ClaimEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(again) != 1 || again[0].ID != ee[0].ID {
+		t.Errorf("claim after lease = %v, want event %d", again, ee[0].ID)
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	insertTestEvents(t, s, 1)
+
+	// 구독자가 없으면 지우지 않음
+	bus := NewEventBus()
+	if _, err := s.relayEvents(ctx, bus); err == nil {
+		t.Error("relay without subscribers succeeded")
+	}
+
+	if len(outboxIDs(s)) != 1 {
+		t.Error("event dropped without subscribers")
+	}
+
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e Event) error {
+		got = append(got, e.Type)
+		return nil
+	})
+	if _, err := s.relayEvents(ctx, bus); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0] != "test.event" || len(outboxIDs(s)) != 0 {
+		t.Errorf("subscriber got %v, outbox %v", got, outboxIDs(s))
+	}
+}
+
+func TestOrderPlacedEvent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	sellerCtx, sellerID := newTestUser(t, s, "seller")
+	buyerCtx, buyerID := newTestUser(t, s, "buyer")
+
+	p, err := s.CreatePost(sellerCtx, "for sale", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.AddToBasket(buyerCtx, p.Post.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := s.PlaceOrder(buyerCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &testPublisher{}
+	if _, err = s.relayEvents(ctx, pub); err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, e := range pub.events {
+		if e.Type != EventOrderPlaced {
+			continue
+		}
+
+		found = true
+		var payload OrderEvent
+		if err = json.Unmarshal(e.Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+
+		want := OrderEventItem{PostID: p.Post.ID, SellerID: sellerID, Quantity: 2}
+		if payload.OrderNum != o.Num || payload.UserID != buyerID || len(payload.Items) != 1 || payload.Items[0] != want {
+			t.Errorf("order event = %+v, want order %d of %d with %+v", payload, o.Num, buyerID, want)
+		}
+	}
+
+	if !found {
+		t.Errorf("no %s event in %v", EventOrderPlaced, pub.events)
+	}
+}
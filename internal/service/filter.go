@@ -0,0 +1,368 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 필터 규칙에 걸렸을 때의 처리
+// FilterAction taken when a filter rule matches.
+type FilterAction string
+
+// 강한 순서대로 reject > hold > nsfw
+const (
+	// FilterReject refuses the content.
+	FilterReject FilterAction = "reject"
+	// FilterHold publishes the content hidden until an admin reviews it.
+	FilterHold FilterAction = "hold"
+	// FilterNSFW publishes the content flagged as nsfw.
+	FilterNSFW FilterAction = "nsfw"
+)
+
+// ErrContentRejected is used when the content filter refuses the content.
+var ErrContentRejected = newFieldError(http.StatusUnprocessableEntity, "content_rejected", "content rejected", "content")
+
+// 게시물과 댓글 작성 전에 실행되는 필터 설정
+// ContentFilter runs on posts and comments before they are inserted.
+type ContentFilter struct {
+	// 금칙어, 띄어쓰기와 자모 분리에 상관없이 찾음
+	Words []FilterWord `json:"words"`
+	// 허용된 도메인, 비어 있으면 모든 도메인 허용
+	AllowDomains []string `json:"allow_domains"`
+	// 허용 목록에 없는 링크의 처리
+	UnlistedLinkAction FilterAction `json:"unlisted_link_action"`
+	// 금지된 도메인 (하위 도메인 포함)
+	DenyDomains []FilterDomain `json:"deny_domains"`
+	// 같은 내용 반복
+	Repeat *FilterRepeat `json:"repeat"`
+}
+
+// 금칙어를 찾는 방법
+const (
+	// FilterMatchContains matches the word anywhere inside a word of the text. It is the default.
+	FilterMatchContains = "contains"
+	// FilterMatchWord only matches a whole word of the text.
+	FilterMatchWord = "word"
+)
+
+// FilterWord of the word list. Words of the text are compared one by one,
+// Except lists the words that contain Word but are allowed ("시발점").
+type FilterWord struct {
+	Word   string       `json:"word"`
+	Action FilterAction `json:"action"`
+	Match  string       `json:"match"`
+	Except []string     `json:"except"`
+}
+
+// FilterDomain of the deny list.
+type FilterDomain struct {
+	Domain string       `json:"domain"`
+	Action FilterAction `json:"action"`
+}
+
+// FilterRepeat matches when the user already wrote the same content
+// Limit times within Window.
+type FilterRepeat struct {
+	Limit  int            `json:"limit"`
+	Window FilterDuration `json:"window"`
+	Action FilterAction   `json:"action"`
+}
+
+// FilterDuration for json configs ("10m", "1h").
+type FilterDuration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a time.ParseDuration string.
+func (d *FilterDuration) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("could not parse duration: %v", err)
+	}
+	d.Duration = v
+	return nil
+}
+
+// 필터 결과
+type filterVerdict struct {
+	action FilterAction
+	reason string
+}
+
+// 더 강한 처리만 남김
+func (v *filterVerdict) escalate(action FilterAction, reason string) {
+	if filterActionRank[action] > filterActionRank[v.action] {
+		v.action = action
+		v.reason = reason
+	}
+}
+
+var filterActionRank = map[FilterAction]int{
+	FilterNSFW:   1,
+	FilterHold:   2,
+	FilterReject: 3,
+}
+
+// ParseContentFilter reads a JSON filter config and validates it.
+func ParseContentFilter(b []byte) (ContentFilter, error) {
+	var f ContentFilter
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return f, fmt.Errorf("could not parse content filter: %v", err)
+	}
+
+	return f, f.Validate()
+}
+
+// Validate checks the actions and the match of every rule.
+// An unknown action would never fire.
+func (f ContentFilter) Validate() error {
+	for _, w := range f.Words {
+		if normalizeText(w.Word) == "" {
+			return fmt.Errorf("invalid filter word %q", w.Word)
+		}
+
+		if err := validFilterAction(w.Action, "word "+w.Word); err != nil {
+			return err
+		}
+
+		if w.Match != "" && w.Match != FilterMatchContains && w.Match != FilterMatchWord {
+			return fmt.Errorf("invalid match %q of filter word %q", w.Match, w.Word)
+		}
+	}
+
+	if len(f.AllowDomains) != 0 {
+		if err := validFilterAction(f.UnlistedLinkAction, "unlisted_link_action"); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range f.DenyDomains {
+		if err := validFilterAction(d.Action, "domain "+d.Domain); err != nil {
+			return err
+		}
+	}
+
+	if f.Repeat != nil && f.Repeat.Limit > 0 {
+		if f.Repeat.Window.Duration <= 0 {
+			return fmt.Errorf("invalid filter repeat window %s", f.Repeat.Window.Duration)
+		}
+
+		if err := validFilterAction(f.Repeat.Action, "repeat"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validFilterAction(action FilterAction, rule string) error {
+	if _, ok := filterActionRank[action]; !ok {
+		return fmt.Errorf("invalid action %q of filter %s", action, rule)
+	}
+	return nil
+}
+
+// WithContentFilter runs the given filter on new posts and comments.
+// It panics when f is not valid, configs should go through ParseContentFilter.
+func WithContentFilter(f ContentFilter) Option {
+	if err := f.Validate(); err != nil {
+		panic(err)
+	}
+
+	words := make([]FilterWord, len(f.Words))
+	for i, w := range f.Words {
+		w.Word = normalizeText(w.Word)
+		except := make([]string, 0, len(w.Except))
+		for _, e := range w.Except {
+			if e = normalizeText(e); e != "" {
+				except = append(except, e)
+			}
+		}
+		w.Except = except
+		words[i] = w
+	}
+	f.Words = words
+
+	return func(s *Service) {
+		s.filter = &f
+	}
+}
+
+var rxLink = regexp.MustCompile(`(?i)\b(?:https?://)?((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,})(?:[/:?#]\S*)?`)
+
+// 내용 검사, table은 반복 검사에 사용하는 posts 또는 comments
+func (s *Service) filterContent(ctx context.Context, table string, userID int64, texts ...string) (filterVerdict, error) {
+	var v filterVerdict
+	if s.filter == nil {
+		return v, nil
+	}
+
+	text := strings.Join(texts, "\n")
+	tokens := normalizeWords(text)
+	for _, w := range s.filter.Words {
+		if matchWord(tokens, w) {
+			v.escalate(w.Action, "word")
+		}
+	}
+
+	for _, host := range linkHosts(text) {
+		listed := len(s.filter.AllowDomains) == 0
+		for _, d := range s.filter.AllowDomains {
+			if matchDomain(host, d) {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			v.escalate(s.filter.UnlistedLinkAction, "link")
+		}
+
+		for _, d := range s.filter.DenyDomains {
+			if matchDomain(host, d.Domain) {
+				v.escalate(d.Action, "link")
+			}
+		}
+	}
+
+	if r := s.filter.Repeat; r != nil && r.Limit > 0 {
+		n, err := s.store.RepeatedContent(ctx, table, userID, texts[0], r.Window.Duration)
+		if err != nil {
+			return v, err
+		}
+
+		if n >= r.Limit {
+			v.escalate(r.Action, "repeat")
+		}
+	}
+
+	return v, nil
+}
+
+// 텍스트의 링크 도메인
+func linkHosts(text string) []string {
+	var hosts []string
+	for _, m := range rxLink.FindAllStringSubmatch(text, -1) {
+		hosts = append(hosts, strings.ToLower(m[1]))
+	}
+	return hosts
+}
+
+func matchDomain(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// 단어마다 금칙어 확인, 허용 단어 부분은 빼고 찾음
+func matchWord(tokens []string, w FilterWord) bool {
+	for _, t := range tokens {
+		for _, e := range w.Except {
+			t = strings.ReplaceAll(t, e, "\x00")
+		}
+
+		if w.Match == FilterMatchWord {
+			if t == w.Word {
+				return true
+			}
+			continue
+		}
+
+		if strings.Contains(t, w.Word) {
+			return true
+		}
+	}
+	return false
+}
+
+// 공백으로 단어를 나누고 단어마다 정규화
+// 한 글자짜리 단어가 이어지면 ("시 발", "ㅅ ㅣ ㅂ ㅏ ㄹ") 띄어쓰기 우회로 보고 합침
+func normalizeWords(text string) []string {
+	var tokens []string
+	var run strings.Builder
+	flush := func() {
+		if run.Len() != 0 {
+			tokens = append(tokens, run.String())
+			run.Reset()
+		}
+	}
+
+	for _, field := range strings.Fields(text) {
+		t := normalizeText(field)
+		if t == "" {
+			continue
+		}
+
+		if letterCount(field) == 1 {
+			run.WriteString(t)
+			continue
+		}
+
+		flush()
+		tokens = append(tokens, t)
+	}
+	flush()
+	return tokens
+}
+
+func letterCount(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// 한글 정규화
+// 완성형 글자를 자모로 분리하고 (시발 → ㅅㅣㅂㅏㄹ) 글자가 아닌 문자를 모두 지워서
+// "시1발", "시.발", "ㅅㅣㅂㅏㄹ" 같은 우회를 같은 문자열로 만듦, 단어 하나에 사용
+func normalizeText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		// 전각 영문, 숫자
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+
+		switch {
+		case r >= hangulBase && r <= hangulLast:
+			i := r - hangulBase
+			b.WriteRune(choseong[i/(21*28)])
+			b.WriteRune(jungseong[i%(21*28)/28])
+			if t := i % 28; t != 0 {
+				b.WriteRune(jongseong[t])
+			}
+		case r >= 0x1100 && r <= 0x1112:
+			b.WriteRune(choseong[r-0x1100])
+		case r >= 0x1161 && r <= 0x1175:
+			b.WriteRune(jungseong[r-0x1161])
+		case r >= 0x11A8 && r <= 0x11C2:
+			b.WriteRune(jongseong[r-0x11A7])
+		case unicode.IsLetter(r):
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+const (
+	hangulBase = 0xAC00
+	hangulLast = 0xD7A3
+)
+
+// 호환용 자모
+var (
+	choseong  = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+	jungseong = []rune("ㅏㅐㅑㅒㅓㅔㅕㅖㅗㅘㅙㅚㅛㅜㅝㅞㅟㅠㅡㅢㅣ")
+	jongseong = []rune(" ㄱㄲㄳㄴㄵㄶㄷㄹㄺㄻㄼㄽㄾㄿㅀㅁㅂㅄㅅㅆㅇㅈㅊㅋㅌㅍㅎ")
+)
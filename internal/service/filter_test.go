@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"시발", "ㅅㅣㅂㅏㄹ"},
+		{"ㅅㅣㅂㅏㄹ", "ㅅㅣㅂㅏㄹ"},
+		{"시1발", "ㅅㅣㅂㅏㄹ"},
+		{"시.발!", "ㅅㅣㅂㅏㄹ"},
+		{"닭", "ㄷㅏㄺ"},
+		// 조합형 자모 (U+1100 ~)
+		{"시발", "ㅅㅣㅂㅏㄹ"},
+		{"ＳＰＡＭ", "spam"},
+		{"SpAm", "spam"},
+		{"1234 !?", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeText(tt.text); got != tt.want {
+			t.Errorf("normalizeText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"hello world", []string{"hello", "world"}},
+		{"시 발", []string{"ㅅㅣㅂㅏㄹ"}},
+		{"ㅅ ㅣ ㅂ ㅏ ㄹ", []string{"ㅅㅣㅂㅏㄹ"}},
+		{"s p a m now", []string{"spam", "now"}},
+		// 두 글자 이상인 단어 사이의 한 글자는 앞뒤와 합치지 않음
+		{"아이 시 발표", []string{"ㅇㅏㅇㅣ", "ㅅㅣ", "ㅂㅏㄹㅍㅛ"}},
+		{"시1 발2", []string{"ㅅㅣㅂㅏㄹ"}},
+		{"!! 123 ..", nil},
+	}
+	for _, tt := range tests {
+		if got := normalizeWords(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWord(t *testing.T) {
+	s := newTestService(t, WithContentFilter(ContentFilter{
+		Words: []FilterWord{
+			{Word: "시발", Action: FilterReject, Except: []string{"시발점", "시발역"}},
+			{Word: "야동", Action: FilterNSFW, Match: FilterMatchWord},
+		},
+	}))
+	contains, word := s.filter.Words[0], s.filter.Words[1]
+
+	tests := []struct {
+		text string
+		w    FilterWord
+		want bool
+	}{
+		{"시발", contains, true},
+		{"이런 시발", contains, true},
+		{"시1발", contains, true},
+		{"ㅅㅣㅂㅏㄹ", contains, true},
+		{"시 발", contains, true},
+		{"ㅅ ㅣ ㅂ ㅏ ㄹ", contains, true},
+		{"아시발놈", contains, true},
+		// 허용 단어
+		{"시발점", contains, false},
+		{"시발점에서 만나", contains, false},
+		{"시발역", contains, false},
+		{"시 발 점", contains, false},
+		// 허용 단어 밖에 남은 부분은 걸림
+		{"시발점시발", contains, true},
+		{"시발점에서 시발", contains, true},
+		// 다른 단어에 걸쳐 있으면 안 걸림
+		{"아이 시 발표", contains, false},
+		{"시 발표", contains, false},
+		{"발시", contains, false},
+		{"야동", word, true},
+		{"야 동", word, true},
+		{"야동이", word, false},
+		{"정말 야동", word, true},
+	}
+	for _, tt := range tests {
+		if got := matchWord(normalizeWords(tt.text), tt.w); got != tt.want {
+			t.Errorf("matchWord(%q, %q) = %v, want %v", tt.text, tt.w.Word, got, tt.want)
+		}
+	}
+}
+
+func TestLinkHosts(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"visit https://Sub.Spam.Example.com/path?q=1 now", []string{"sub.spam.example.com"}},
+		{"bit.ly/abc and http://example.org:8080", []string{"bit.ly", "example.org"}},
+		{"pi is 3.14", nil},
+		{"no links here", nil},
+	}
+	for _, tt := range tests {
+		if got := linkHosts(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("linkHosts(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		host   string
+		domain string
+		want   bool
+	}{
+		{"spam.example.com", "spam.example.com", true},
+		{"a.b.spam.example.com", "spam.example.com", true},
+		{"bit.ly", ".bit.ly", true},
+		{"bit.ly", "BIT.LY", true},
+		{"notspam.example.com", "spam.example.com", false},
+		{"example.com", "spam.example.com", false},
+		{"spam.example.com.evil.org", "spam.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := matchDomain(tt.host, tt.domain); got != tt.want {
+			t.Errorf("matchDomain(%q, %q) = %v, want %v", tt.host, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestFilterContentExample(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("..", "..", "content_filter.example.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ParseContentFilter(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 반복 검사는 저장소를 봐서 여기서는 뺌
+	f.Repeat = nil
+	s := newTestService(t, WithContentFilter(f))
+
+	tests := []struct {
+		text string
+		want FilterAction
+	}{
+		{"오늘 시발점에서 출발", ""},
+		{"시 발 진짜", FilterReject},
+		{"병1신", FilterHold},
+		{"야동", FilterNSFW},
+		{"야동이 아니라 야동", FilterNSFW},
+		{"see https://spam.example.com/x", FilterReject},
+		{"see bit.ly/x", FilterHold},
+		// allow_domains가 비어 있으면 모든 도메인 허용
+		{"see https://example.org", ""},
+		// 가장 강한 처리만
+		{"병신 https://spam.example.com", FilterReject},
+	}
+	for _, tt := range tests {
+		v, err := s.filterContent(context.Background(), "posts", 1, tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v.action != tt.want {
+			t.Errorf("filterContent(%q) = %q, want %q", tt.text, v.action, tt.want)
+		}
+	}
+}
+
+func TestParseContentFilterErrors(t *testing.T) {
+	tests := []string{
+		`{"words": [{"word": "spam", "action": "delete"}]}`,
+		`{"words": [{"word": "!!", "action": "reject"}]}`,
+		`{"words": [{"word": "spam", "action": "reject", "match": "prefix"}]}`,
+		`{"allow_domains": ["example.org"]}`,
+		`{"deny_domains": [{"domain": "bit.ly"}]}`,
+		`{"repeat": {"limit": 3, "action": "hold"}}`,
+		`{"unknown": true}`,
+	}
+	for _, tt := range tests {
+		if _, err := ParseContentFilter([]byte(tt)); err == nil {
+			t.Errorf("ParseContentFilter(%s) = nil error", tt)
+		}
+	}
+}
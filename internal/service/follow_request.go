@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ErrFollowRequestNotFound is used when there is no pending follow request from that user.
+var ErrFollowRequestNotFound = newError(http.StatusNotFound, "follow_request_not_found", "follow request not found")
+
+// 비공개 계정 설정
+// SetPrivate makes the account of the authenticated user private or public.
+// Going public approves every pending follow request.
+func (s *Service) SetPrivate(ctx context.Context, private bool) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	var followerIDs []int64
+	err := s.store.Tx(ctx, func(st Store) error {
+		if err := st.SetPrivate(ctx, uid, private); err != nil {
+			return err
+		}
+
+		if err := emitEvent(ctx, st, EventPrivacyChanged, UserEvent{UserID: uid, Private: &private}); err != nil {
+			return err
+		}
+
+		if private {
+			return nil
+		}
+
+		var err error
+		if followerIDs, err = st.FollowRequesterIDs(ctx, uid); err != nil {
+			return err
+		}
+
+		for _, followerID := range followerIDs {
+			if err = approveFollow(ctx, st, followerID, uid); err != nil {
+				return err
+			}
+
+			if err = enqueueJob(ctx, st, JobNotifyFollow, notifyFollowJob{FollowerID: followerID, FolloweeID: uid}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wakeJobs(JobNotifyFollow)
+	s.wakeOutbox()
+
+	return nil
+}
+
+// 받은 팔로우 요청 목록
+// FollowRequests sent to the authenticated user in ascending order with forward pagination.
+func (s *Service) FollowRequests(ctx context.Context, first int, after string) ([]UserProfile, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	first = normailizePageSize(first)
+	after = strings.TrimSpace(after)
+	uu, err := s.store.FollowRequests(ctx, uid, first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range uu {
+		s.userAvatar(&uu[i].User)
+	}
+	return uu, nil
+}
+
+// 팔로우 요청 승인
+// ApproveFollowRequest from the given user to the authenticated user.
+func (s *Service) ApproveFollowRequest(ctx context.Context, username string) error {
+	return s.answerFollowRequest(ctx, username, true)
+}
+
+// 팔로우 요청 거절
+// DenyFollowRequest from the given user to the authenticated user.
+func (s *Service) DenyFollowRequest(ctx context.Context, username string) error {
+	return s.answerFollowRequest(ctx, username, false)
+}
+
+func (s *Service) answerFollowRequest(ctx context.Context, username string, approve bool) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !rxUsername.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	var followerID int64
+	err := s.store.Tx(ctx, func(st Store) error {
+		var err error
+		followerID, err = st.UserIDByUsername(ctx, username)
+		if err == ErrUserNotFound {
+			return ErrFollowRequestNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		requested, err := st.FollowRequested(ctx, followerID, uid)
+		if err != nil {
+			return err
+		}
+
+		if !requested {
+			return ErrFollowRequestNotFound
+		}
+
+		if !approve {
+			if err = st.DeleteFollowRequest(ctx, followerID, uid); err != nil {
+				return err
+			}
+			return emitEvent(ctx, st, EventFollowRequestDeleted, RelationEvent{UserID: followerID, TargetID: uid})
+		}
+
+		if err = approveFollow(ctx, st, followerID, uid); err != nil {
+			return err
+		}
+		return enqueueJob(ctx, st, JobNotifyFollow, notifyFollowJob{FollowerID: followerID, FolloweeID: uid})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wakeJobs(JobNotifyFollow)
+	s.wakeOutbox()
+
+	return nil
+}
+
+// 요청을 지우고 팔로우로 바꾸기
+func approveFollow(ctx context.Context, st Store, followerID, followeeID int64) error {
+	if err := st.DeleteFollowRequest(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+
+	if err := st.InsertFollow(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, st, EventFollowed, RelationEvent{UserID: followerID, TargetID: followeeID})
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 백그라운드 작업 종류
+// Kinds of the background jobs run by RunJobs.
+const (
+	JobDispatchPost = "dispatch_post"
+	JobNotifyFollow = "notify_follow"
+	JobNotifyPost   = "notify_post"
+)
+
+// 작업 재시도와 임대 시간
+const (
+	maxJobAttempts  = 10
+	minJobBackoff   = time.Second
+	maxJobBackoff   = time.Hour
+	jobLease        = time.Minute * 5
+	jobTimeout      = time.Minute
+	jobPollInterval = time.Second
+)
+
+// jobMetrics counts finished, retried and dead jobs. Published as the "jobs" expvar.
+var jobMetrics = expvar.NewMap("jobs")
+
+// 종류별 워커 수 기본값
+// DefaultJobWorkers is the number of workers per kind of job.
+var DefaultJobWorkers = map[string]int{
+	JobDispatchPost: 4,
+	JobNotifyFollow: 2,
+	JobNotifyPost:   2,
+}
+
+// ParseJobWorkers reads "dispatch_post=8;notify_post=0" over DefaultJobWorkers.
+// 0 runs no workers of that kind on this instance.
+func ParseJobWorkers(s string) (map[string]int, error) {
+	workers := map[string]int{}
+	for kind, n := range DefaultJobWorkers {
+		workers[kind] = n
+	}
+
+	for _, rule := range strings.Split(s, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		eq := strings.Index(rule, "=")
+		if eq == -1 {
+			return nil, fmt.Errorf("invalid job workers %q", rule)
+		}
+
+		kind := strings.TrimSpace(rule[:eq])
+		if _, ok := DefaultJobWorkers[kind]; !ok {
+			return nil, fmt.Errorf("unknown job kind %q", kind)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(rule[eq+1:]))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid number of job workers %q", rule)
+		}
+
+		workers[kind] = n
+	}
+	return workers, nil
+}
+
+// Job queued in the store. Payload is the JSON input of the job kind.
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempts    int
+	MaxAttempts int
+	CreatedAt   time.Time
+}
+
+type jobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// 작업별 입력
+type dispatchPostJob struct {
+	PostID int64 `json:"post_id"`
+	UserID int64 `json:"user_id"`
+	Held   bool  `json:"held,omitempty"`
+}
+
+type notifyFollowJob struct {
+	FollowerID int64 `json:"follower_id"`
+	FolloweeID int64 `json:"followee_id"`
+}
+
+type notifyPostJob struct {
+	Type    string `json:"type"`
+	ActorID int64  `json:"actor_id"`
+	UserID  int64  `json:"user_id"`
+	PostID  int64  `json:"post_id"`
+}
+
+// 새 작업 종류는 여기에 추가
+func (s *Service) jobHandlers() map[string]jobHandler {
+	return map[string]jobHandler{
+		JobDispatchPost: func(ctx context.Context, payload json.RawMessage) error {
+			var in dispatchPostJob
+			if err := json.Unmarshal(payload, &in); err != nil {
+				return err
+			}
+			return s.dispatchPost(ctx, in.PostID, in.UserID, in.Held)
+		},
+		JobNotifyFollow: func(ctx context.Context, payload json.RawMessage) error {
+			var in notifyFollowJob
+			if err := json.Unmarshal(payload, &in); err != nil {
+				return err
+			}
+			return s.notifyFollow(ctx, in.FollowerID, in.FolloweeID)
+		},
+		JobNotifyPost: func(ctx context.Context, payload json.RawMessage) error {
+			var in notifyPostJob
+			if err := json.Unmarshal(payload, &in); err != nil {
+				return err
+			}
+			return s.notifyPostActivity(ctx, in.Type, in.ActorID, in.UserID, in.PostID)
+		},
+	}
+}
+
+// 트랜잭션 안에서 작업 추가, 커밋되어야 실행됨
+// enqueueJob adds a job through st so it is only run if the transaction commits.
+func enqueueJob(ctx context.Context, st Store, kind string, in interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("could not marshal %s job: %v", kind, err)
+	}
+
+	return st.InsertJob(ctx, &Job{Kind: kind, Payload: payload, MaxAttempts: maxJobAttempts})
+}
+
+// 커밋 후 쉬고 있는 워커를 깨움, 다른 인스턴스는 폴링으로 가져감
+func (s *Service) wakeJobs(kind string) {
+	select {
+	case s.jobWake[kind] <- struct{}{}:
+	default:
+	}
+}
+
+// RunJobs claims and runs the queued jobs until ctx is done, with the given
+// number of workers per kind. Kinds left out of workers get one worker.
+// Jobs already running are not cancelled with ctx, RunJobs waits for them
+// so cancelling ctx drains the workers on shutdown.
+// Failed jobs are retried with backoff and kept as dead after their last attempt.
+func (s *Service) RunJobs(ctx context.Context, workers map[string]int) error {
+	handlers := s.jobHandlers()
+	for kind := range workers {
+		if _, ok := handlers[kind]; !ok {
+			return fmt.Errorf("unknown job kind %q", kind)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for kind, h := range handlers {
+		n, ok := workers[kind]
+		if !ok {
+			n = 1
+		}
+
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(kind string, h jobHandler) {
+				defer wg.Done()
+				s.jobWorker(ctx, kind, h)
+			}(kind, h)
+		}
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (s *Service) jobWorker(ctx context.Context, kind string, h jobHandler) {
+	for ctx.Err() == nil {
+		j, ok, err := s.store.ClaimJob(ctx, kind, jobLease)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("could not claim %s job: %v\n", kind, err)
+		}
+
+		if err != nil || !ok {
+			t := time.NewTimer(jobPollInterval)
+			select {
+			case <-ctx.Done():
+			case <-s.jobWake[kind]:
+			case <-t.C:
+			}
+			t.Stop()
+			continue
+		}
+
+		s.runJob(j, h)
+	}
+}
+
+// 종료 중에도 실행 중인 작업은 끝까지, 임대 시간 안에서
+func (s *Service) runJob(j Job, h jobHandler) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	err := callJobHandler(ctx, h, j.Payload)
+	ctx = context.Background()
+	if err == nil {
+		jobMetrics.Add("done", 1)
+		if err = s.store.DeleteJob(ctx, j.ID); err != nil {
+			log.Printf("could not delete %s job %d: %v\n", j.Kind, j.ID, err)
+		}
+		return
+	}
+
+	if j.Attempts >= j.MaxAttempts || permanentJobError(err) {
+		jobMetrics.Add("dead", 1)
+		log.Printf("%s job %d is dead after %d attempts: %v\n", j.Kind, j.ID, j.Attempts, err)
+		if err = s.store.BuryJob(ctx, j.ID, err.Error()); err != nil {
+			log.Printf("could not bury %s job %d: %v\n", j.Kind, j.ID, err)
+		}
+		return
+	}
+
+	jobMetrics.Add("retries", 1)
+	if err = s.store.RetryJob(ctx, j.ID, backoff(j.Attempts, minJobBackoff, maxJobBackoff), err.Error()); err != nil {
+		log.Printf("could not retry %s job %d: %v\n", j.Kind, j.ID, err)
+	}
+}
+
+// 패닉도 실패로 기록
+func callJobHandler(ctx context.Context, h jobHandler, payload json.RawMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return h(ctx, payload)
+}
+
+// 다시 실행해도 결과가 같은 에러는 재시도하지 않음
+func permanentJobError(err error) bool {
+	var serviceErr *Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &serviceErr) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// 지터를 넣은 지수 백오프, 최대 max
+func backoff(attempt int, min, max time.Duration) time.Duration {
+	d := max
+	if attempt < 32 {
+		if d = min << uint(attempt-1); d > max || d <= 0 {
+			d = max
+		}
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
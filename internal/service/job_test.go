@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hako/branca"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, time.Second / 2, time.Second},
+		{2, time.Second, time.Second * 2},
+		{4, time.Second * 4, time.Second * 8},
+		// 최대를 넘으면 최대
+		{13, time.Hour / 2, time.Hour},
+		// 밀어서 넘친 값도 최대
+		{31, time.Hour / 2, time.Hour},
+		{100, time.Hour / 2, time.Hour},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := backoff(tt.attempt, minJobBackoff, maxJobBackoff); d < tt.min || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want %v..%v", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+// 테스트용 작업을 넣고 가져옴
+func claimTestJob(t *testing.T, s *Service, maxAttempts int) Job {
+	t.Helper()
+	ctx := context.Background()
+	if err := s.store.InsertJob(ctx, &Job{Kind: "test", Payload: json.RawMessage(`{}`), MaxAttempts: maxAttempts}); err != nil {
+		t.Fatal(err)
+	}
+
+	j, ok, err := s.store.ClaimJob(ctx, "test", jobLease)
+	if err != nil || !ok {
+		t.Fatalf("could not claim test job: %v", err)
+	}
+	return j
+}
+
+// 저장소에 남은 작업, 지워졌으면 false
+func testJobRow(s *Service, id int64) (memoryJob, bool) {
+	m := s.store.(*memoryStore)
+	defer m.lock()()
+
+	j, ok := m.jobs[id]
+	return j, ok
+}
+
+func TestRunJob(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		err         error
+		panics      bool
+		deleted     bool
+		dead        bool
+		metric      string
+	}{
+		{name: "done", maxAttempts: 3, deleted: true, metric: "done"},
+		{name: "retry", maxAttempts: 3, err: errors.New("connection reset"), metric: "retries"},
+		{name: "panic is retried", maxAttempts: 3, panics: true, metric: "retries"},
+		{name: "last attempt", maxAttempts: 1, err: errors.New("connection reset"), dead: true, metric: "dead"},
+		{name: "permanent error", maxAttempts: 3, err: newError(http.StatusNotFound, "gone", "gone"), dead: true, metric: "dead"},
+		{name: "bad payload", maxAttempts: 3, err: json.Unmarshal([]byte("{"), new(dispatchPostJob)), dead: true, metric: "dead"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+			j := claimTestJob(t, s, tt.maxAttempts)
+			before := metricValue(jobMetrics, tt.metric)
+
+			start := time.Now()
+			s.runJob(j, func(ctx context.Context, payload json.RawMessage) error {
+				if tt.panics {
+					panic("boom")
+				}
+				return tt.err
+			})
+
+			if got := metricValue(jobMetrics, tt.metric) - before; got != 1 {
+				t.Errorf("%s metric +%d, want +1", tt.metric, got)
+			}
+
+			row, ok := testJobRow(s, j.ID)
+			if ok == tt.deleted {
+				t.Fatalf("job kept = %v, want %v", ok, !tt.deleted)
+			}
+
+			if tt.deleted {
+				return
+			}
+
+			if row.dead != tt.dead || row.lastError == "" || !row.lockedUntil.IsZero() {
+				t.Errorf("job = %+v, want dead %v with the last error and unlocked", row, tt.dead)
+			}
+
+			// 재시도는 첫 백오프 뒤로 미룸
+			if !tt.dead {
+				if delay := row.runAt.Sub(start); delay < minJobBackoff/2 || delay > minJobBackoff+time.Second {
+					t.Errorf("retry delay = %v, want about %v", delay, minJobBackoff)
+				}
+			}
+
+			// 죽은 작업과 아직 때가 안 된 작업은 가져가지 않음
+			if _, ok, _ = s.store.ClaimJob(context.Background(), "test", jobLease); ok {
+				t.Error("claimed a dead or delayed job")
+			}
+		})
+	}
+}
+
+func TestClaimJobLease(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	if err := s.store.InsertJob(ctx, &Job{Kind: "test", MaxAttempts: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	j, ok, err := s.store.ClaimJob(ctx, "test", time.Millisecond*50)
+	if err != nil || !ok || j.Attempts != 1 {
+		t.Fatalf("claim = %+v, %v, %v", j, ok, err)
+	}
+
+	// 임대 중에는 다른 워커가 가져가지 못함
+	if _, ok, _ = s.store.ClaimJob(ctx, "test", jobLease); ok {
+		t.Error("claimed a leased job")
+	}
+
+	// 워커가 죽어 임대 시간이 지나면 다시 가져가고 시도 횟수가 늘어남
+	time.Sleep(time.Millisecond * 60)
+	again, ok, err := s.store.ClaimJob(ctx, "test", jobLease)
+	if err != nil || !ok {
+		t.Fatalf("reclaim after lease = %v, %v", ok, err)
+	}
+
+	if again.ID != j.ID || again.Attempts != 2 {
+		t.Errorf("reclaimed %+v, want job %d at attempt 2", again, j.ID)
+	}
+}
+
+// armed이면 트랜잭션을 시작하기 전에 release까지 기다리는 저장소
+type blockingTxStore struct {
+	Store
+	armed   int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingTxStore) Tx(ctx context.Context, fn func(Store) error) error {
+	if atomic.CompareAndSwapInt32(&s.armed, 1, 0) {
+		close(s.started)
+		<-s.release
+	}
+	return s.Store.Tx(ctx, fn)
+}
+
+func TestRunJobsDrain(t *testing.T) {
+	st := &blockingTxStore{Store: NewMemoryStore(), started: make(chan struct{}), release: make(chan struct{})}
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	s := New(st, codec, "http://localhost:3000", NewLocalBlobStore(t.TempDir(), "http://localhost:3000/img"))
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	bobCtx, _ := newTestUser(t, s, "bob")
+
+	if _, err := s.ToggleFollow(aliceCtx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&st.armed, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.RunJobs(ctx, map[string]int{JobDispatchPost: 0, JobNotifyFollow: 1, JobNotifyPost: 0})
+	}()
+
+	select {
+	case <-st.started:
+	case <-time.After(time.Second * 5):
+		t.Fatal("notify_follow job did not start")
+	}
+
+	// 종료해도 실행 중인 작업을 기다림
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("RunJobs returned before the running job finished")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(st.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("RunJobs did not return after the job finished")
+	}
+
+	// 취소된 ctx와 상관없이 끝까지 실행됨
+	if nn, _ := s.Notifications(bobCtx, 0, 0); len(nn) != 1 || nn[0].Type != "follow" {
+		t.Errorf("notifications = %+v, want one follow", nn)
+	}
+
+	if n := len(st.Store.(*memoryStore).jobs); n != 0 {
+		t.Errorf("%d jobs left, want none", n)
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	gonanoid "github.com/matoous/go-nanoid"
+)
+
+const (
+	// 게시물 하나에 첨부할 수 있는 이미지 수
+	// MaxPostMedia attached to a single post.
+	MaxPostMedia = 4
+	// 이미지 하나당 용량 제한
+	// MaxMediaBytes to read per image.
+	MaxMediaBytes = 10 << 20 //10MB
+	// 디코딩 전에 헤더로 확인하는 픽셀 수 제한, 압축된 작은 파일이 메모리를 다 쓰지 않게
+	// MaxMediaPixels of an image, checked on the header before decoding.
+	MaxMediaPixels = 40_000_000
+
+	thumbnailSize = 200
+	mediumSize    = 800
+)
+
+var (
+	// ErrUnsupportedMediaFormat used for unsupported post media format.
+	ErrUnsupportedMediaFormat = newFieldError(http.StatusUnsupportedMediaType, "unsupported_media_format", "only png and jpeg allowed as media", "media")
+	// ErrMediaTooLarge used when an image is over MaxMediaBytes.
+	ErrMediaTooLarge = newFieldError(http.StatusRequestEntityTooLarge, "media_too_large", "media too large", "media")
+	// ErrMediaDimensionsTooLarge used when an image is over MaxMediaPixels.
+	ErrMediaDimensionsTooLarge = newFieldError(http.StatusUnprocessableEntity, "media_dimensions_too_large", "media dimensions too large", "media")
+	// ErrTooManyMedia used when a post carries more than MaxPostMedia images.
+	ErrTooManyMedia = newFieldError(http.StatusUnprocessableEntity, "too_many_media", "too many media", "media")
+)
+
+// 게시물 이미지 모델
+// Media attached to a post with its renditions.
+type Media struct {
+	Thumbnail MediaRendition `json:"thumbnail"`
+	Medium    MediaRendition `json:"medium"`
+	Original  MediaRendition `json:"original"`
+}
+
+// MediaRendition of a post image.
+type MediaRendition struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// 디스크에 저장된 이미지 하나 (원본, 중간, 썸네일)
+type mediaFile struct {
+	name                      string
+	width, height             int
+	mediumWidth, mediumHeight int
+}
+
+// 이미지를 읽어서 세 가지 크기로 저장
+// saveMedia decodes, validates and stores the renditions of an image.
+func (s *Service) saveMedia(ctx context.Context, r io.Reader) (mediaFile, error) {
+	var mf mediaFile
+
+	// 이미지 용량 제한, 한 바이트 더 읽어서 넘었는지 확인
+	b, err := ioutil.ReadAll(io.LimitReader(r, MaxMediaBytes+1))
+	if err != nil {
+		return mf, fmt.Errorf("could not read media: %v", err)
+	}
+
+	if len(b) > MaxMediaBytes {
+		return mf, ErrMediaTooLarge
+	}
+
+	// 형식 제한, 모르는 형식이나 잘리거나 깨진 파일은 모두 415
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return mf, ErrUnsupportedMediaFormat
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > MaxMediaPixels {
+		return mf, ErrMediaDimensionsTooLarge
+	}
+
+	// 휴대폰 사진의 EXIF 회전 정보 적용
+	img, err := imaging.Decode(bytes.NewReader(b), imaging.AutoOrientation(true))
+	if err != nil {
+		return mf, ErrUnsupportedMediaFormat
+	}
+
+	name, err := gonanoid.Nanoid()
+	if err != nil {
+		return mf, fmt.Errorf("could not generate media filename: %v", err)
+	}
+
+	name += imageExt(format)
+
+	medium := imaging.Fit(img, mediumSize, mediumSize, imaging.Lanczos)
+	thumbnail := imaging.Fill(img, thumbnailSize, thumbnailSize, imaging.Center, imaging.CatmullRom)
+
+	renditions := []struct {
+		name string
+		img  image.Image
+	}{
+		{name, img},
+		{renditionName(name, "md"), medium},
+		{renditionName(name, "th"), thumbnail},
+	}
+
+	for i, rd := range renditions {
+		if err = s.putImage(ctx, mediaBlob(rd.name), rd.img, format); err != nil {
+			for _, written := range renditions[:i] {
+				s.deleteBlob(mediaBlob(written.name))
+			}
+			return mf, err
+		}
+	}
+
+	mf.name = name
+	mf.width = img.Bounds().Dx()
+	mf.height = img.Bounds().Dy()
+	mf.mediumWidth = medium.Bounds().Dx()
+	mf.mediumHeight = medium.Bounds().Dy()
+	return mf, nil
+}
+
+func (s *Service) putImage(ctx context.Context, name string, img image.Image, format string) error {
+	var buf bytes.Buffer
+	var err error
+	contentType := "image/" + format
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "webp":
+		err = encodeWebP(&buf, img)
+	default:
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, nil)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not encode image: %v", err)
+	}
+
+	if err = s.blobs.Put(ctx, name, &buf, contentType); err != nil {
+		return fmt.Errorf("could not store image: %v", err)
+	}
+
+	return nil
+}
+
+func imageExt(format string) string {
+	switch format {
+	case "png":
+		return ".png"
+	case "webp":
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+func (s *Service) removeMedia(name string) {
+	s.deleteBlob(mediaBlob(name))
+	s.deleteBlob(mediaBlob(renditionName(name, "md")))
+	s.deleteBlob(mediaBlob(renditionName(name, "th")))
+}
+
+// 저장소 안에서의 이미지 이름
+func mediaBlob(name string) string {
+	return "media/" + name
+}
+
+// "abc.jpg" -> "abc_th.jpg"
+func renditionName(name, suffix string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_" + suffix + ext
+}
+
+func (s *Service) mediaFromFile(mf mediaFile) Media {
+	return Media{
+		Thumbnail: MediaRendition{
+			URL:    s.blobs.URL(mediaBlob(renditionName(mf.name, "th"))),
+			Width:  thumbnailSize,
+			Height: thumbnailSize,
+		},
+		Medium: MediaRendition{
+			URL:    s.blobs.URL(mediaBlob(renditionName(mf.name, "md"))),
+			Width:  mf.mediumWidth,
+			Height: mf.mediumHeight,
+		},
+		Original: MediaRendition{
+			URL:    s.blobs.URL(mediaBlob(mf.name)),
+			Width:  mf.width,
+			Height: mf.height,
+		},
+	}
+}
+
+// 여러 게시물의 이미지를 한 번에 불러오기
+// postsMedia selects the media of the given posts keyed by post ID.
+func (s *Service) postsMedia(ctx context.Context, postIDs []int64) (map[int64][]Media, error) {
+	ff, err := s.store.PostsMedia(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mm := make(map[int64][]Media, len(ff))
+	for postID, files := range ff {
+		for _, mf := range files {
+			mm[postID] = append(mm[postID], s.mediaFromFile(mf))
+		}
+	}
+
+	return mm, nil
+}
@@ -2,12 +2,8 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"log"
 	"time"
-
-	"github.com/lib/pq"
 )
 
 // Notification model
@@ -16,6 +12,7 @@ type Notification struct {
 	UserID   int64     `json:"-"`
 	Actors   []string  `json:"actors"`
 	Type     string    `json:"type"`
+	PostID   *int64    `json:"post_id,omitempty"`
 	Read     bool      `json:"read"`
 	IssuedAt time.Time `json:"issued_at"`
 }
@@ -27,45 +24,7 @@ func (s *Service) Notifications(ctx context.Context, last int, before int64) ([]
 		return nil, ErrUnauthenticated
 	}
 
-	last = normailizePageSize(last)
-	query, args, err := buildQuery(`
-		SELECT id, actors, type, read, issued_at
-		FROM notifications
-		WHERE user_id = @uid
-		{{if .before}}AND id < @before{{end}}
-		ORDER BY issued_at DESC
-		LIMIT @last`, map[string]interface{}{
-		"uid":    uid,
-		"before": before,
-		"last":   last,
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("could not build notifications sql query: %v", err)
-	}
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("could not query select notifications: %v", err)
-	}
-
-	defer rows.Close()
-
-	nn := make([]Notification, 0, last)
-	for rows.Next() {
-		var n Notification
-		if err = rows.Scan(&n.ID, pq.Array(&n.Actors), &n.Type, &n.Read, &n.IssuedAt); err != nil {
-			return nil, fmt.Errorf("could not scan notification: %v", err)
-		}
-
-		nn = append(nn, n)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate over notification rows: %v", err)
-	}
-
-	return nn, nil
+	return s.store.Notifications(ctx, uid, normailizePageSize(last), before)
 }
 
 // MarkNotficationAsRead sets a notification from the authenticated user as read.
@@ -75,13 +34,7 @@ func (s *Service) MarkNotificationAsRead(ctx context.Context, notificationID int
 		return ErrUnauthenticated
 	}
 
-	if _, err := s.db.Exec(`
-	UPDATE notifications SET read = true
-	WHERE id = $1 AND user_id = $2`, notificationID, uid); err != nil {
-		return fmt.Errorf("could not update and mark notification as read: %v", err)
-	}
-
-	return nil
+	return s.store.MarkNotificationAsRead(ctx, uid, notificationID)
 }
 
 // MarkNotficationsAsRead sets all notification from the authenticated user as read.
@@ -91,88 +44,84 @@ func (s *Service) MarkNotificationsAsRead(ctx context.Context) error {
 		return ErrUnauthenticated
 	}
 
-	if _, err := s.db.Exec(`
-	UPDATE notifications SET read = true
-	WHERE user_id = $1`, uid); err != nil {
-		return fmt.Errorf("could not update and mark notifications as read: %v", err)
-	}
-
-	return nil
+	return s.store.MarkNotificationsAsRead(ctx, uid)
 }
 
-func (s *Service) notifyFollow(followerID, followeeID int64) {
-	tx, err := s.db.Begin()
-	if err != nil {
-		log.Printf("could not begin tx: %v", err)
-		return
-	}
+// notifyFollow runs as a JobNotifyFollow job.
+func (s *Service) notifyFollow(ctx context.Context, followerID, followeeID int64) error {
+	var n Notification
+	err := s.store.Tx(ctx, func(st Store) error {
+		n = Notification{UserID: followeeID, Type: "follow"}
 
-	defer tx.Rollback()
+		// 작업이 돌기 전에 언팔로우나 차단으로 지워졌으면 알림 없음
+		following, err := st.Following(ctx, followerID, followeeID)
+		if err != nil || !following {
+			return err
+		}
 
-	var actor string
-	query := "SELECT username FROM users WHERE id = $1"
-	if err = tx.QueryRow(query, followerID).Scan(&actor); err != nil {
-		log.Printf("could not query select follow notification actor: %v\n", err)
-		return
-	}
+		actor, err := st.UserByID(ctx, followerID)
+		if err != nil {
+			return err
+		}
+
+		notified, err := st.FollowNotified(ctx, followeeID, actor.UserName)
+		if err != nil || notified {
+			return err
+		}
 
-	var notified bool
-	query = `SELECT EXISTS (
-		SELECT 1 FROM notifications
-		WHERE user_id = $1
-			AND $2:::VARCHAR = ANY(actors)
-			AND type = 'follow')
-		`
-	if err = tx.QueryRow(query, followeeID, actor).Scan(&notified); err != nil {
-		log.Printf("could not query select follow notification existence: %v\n", err)
-		return
+		return s.upsertNotification(ctx, st, &n, actor.UserName)
+	})
+	if err != nil {
+		return fmt.Errorf("could not notify follow: %w", err)
 	}
 
-	if notified {
-		return
+	if n.ID != 0 {
+		s.broadcastNotification(n)
 	}
+	return nil
+}
 
+// 게시물 관련 알림 (같은 게시물의 읽지 않은 알림이 있으면 actor만 추가)
+// notifyPostActivity runs as a JobNotifyPost job, typ is repost or comment_reply.
+func (s *Service) notifyPostActivity(ctx context.Context, typ string, actorID, userID, postID int64) error {
 	var n Notification
-	var nid int64
-	query = "SELECT id FROM notifications WHERE user_id = $1 AND type = 'follow' AND read = false"
-	err = tx.QueryRow(query, followeeID).Scan(&nid)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("could not query select unread follow notification: %v\n", err)
-		return
-	}
+	err := s.store.Tx(ctx, func(st Store) error {
+		n = Notification{UserID: userID, Type: typ, PostID: &postID}
 
-	if err == sql.ErrNoRows {
-		actors := []string{actor}
-		query = `INSERT INTO notifications (user_id, actors, type) VALUES ($1, $2, 'follow')
-			RETURNING id, issued_at`
-		if err = tx.QueryRow(query, followeeID, pq.Array(actors)).Scan(&n.ID, &n.IssuedAt); err != nil {
-			log.Printf("could not insert follow notification: %v\n", err)
-			return
+		// 차단 관계면 알림 없음
+		blocked, err := st.Blocked(ctx, actorID, userID)
+		if err != nil || blocked {
+			return err
 		}
 
-		n.Actors = actors
-	} else {
-		query = `
-				UPDATE notifications SET
-					actors = array_prepend($1, notifications.actors),
-					issued_at = now()
-				WHERE id = $2
-				RETURNING actors, issued_at`
-		if err = tx.QueryRow(query, actor, nid).Scan(pq.Array(&n.Actors), &n.IssuedAt); err != nil {
-			log.Printf("could not update follow notification: %v", err)
-			return
+		actor, err := st.UserByID(ctx, actorID)
+		if err != nil {
+			return err
 		}
 
-		n.ID = nid
+		return s.upsertNotification(ctx, st, &n, actor.UserName)
+	})
+	if err != nil {
+		return fmt.Errorf("could not notify %s: %w", typ, err)
+	}
+
+	if n.ID != 0 {
+		s.broadcastNotification(n)
+	}
+	return nil
+}
 
+// 같은 종류의 읽지 않은 알림이 있으면 actor만 앞에 추가, 없으면 새로 생성
+func (s *Service) upsertNotification(ctx context.Context, st Store, n *Notification, actor string) error {
+	var err error
+	if n.ID, err = st.UnreadNotificationID(ctx, n.UserID, n.Type, n.PostID); err != nil {
+		return err
 	}
-	n.UserID = followeeID
-	n.Type = "follow"
 
-	if err = tx.Commit(); err != nil {
-		log.Printf("could not commit to notify follow :%v\n", err)
-		return
+	if n.ID != 0 {
+		return st.AddNotificationActor(ctx, n, actor)
 	}
 
-	//TODO: broadcast follow notification
+	n.Actors = []string{actor}
+	return st.InsertNotification(ctx, n)
 }
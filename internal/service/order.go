@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"net/http"
+)
+
+// 장바구니 한 줄에 담을 수 있는 최대 수량
+const maxOrderQuantity = 99
+
+var (
+	// ErrInvalidQuantity is used when the quantity is out of 1..99.
+	ErrInvalidQuantity = newFieldError(http.StatusUnprocessableEntity, "invalid_quantity", "invalid quantity", "quantity")
+	// ErrBasketItemNotFound denotes that the basket item was not found.
+	ErrBasketItemNotFound = newError(http.StatusNotFound, "basket_item_not_found", "basket item not found")
+	// ErrEmptyBasket is used when placing an order with nothing in the basket.
+	ErrEmptyBasket = newError(http.StatusUnprocessableEntity, "empty_basket", "basket is empty")
+)
+
+// 장바구니에 담은 게시물
+// BasketItem model
+type BasketItem struct {
+	ID       int64 `json:"id"`
+	PostID   int64 `json:"post_id"`
+	Quantity int   `json:"quantity"`
+}
+
+// 주문 한 건의 게시물 하나. 구매자에게는 buy_record, 판매자(게시물 작성자)에게는 sell_record
+// OrderItem model
+type OrderItem struct {
+	ID       int64 `json:"id"`
+	OrderNum int64 `json:"order_num"`
+	PostID   int64 `json:"post_id"`
+	Quantity int   `json:"quantity"`
+}
+
+// Order model, the buy records created from one basket.
+type Order struct {
+	Num   int64       `json:"num"`
+	Items []OrderItem `json:"items"`
+}
+
+// 장바구니 담기, 이미 담은 게시물이면 수량을 더함
+// AddToBasket adds quantity of the post to the basket of the authenticated user.
+func (s *Service) AddToBasket(ctx context.Context, postID int64, quantity int) (BasketItem, error) {
+	var item BasketItem
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return item, ErrUnauthenticated
+	}
+
+	if quantity < 1 || quantity > maxOrderQuantity {
+		return item, ErrInvalidQuantity
+	}
+
+	err := s.store.Tx(ctx, func(st Store) error {
+		p, err := st.Post(ctx, uid, postID)
+		if err != nil {
+			return err
+		}
+
+		// 내용 없는 공유는 원본 게시물을 담음
+		if p.RepostOfID != nil && p.Content == "" {
+			postID = *p.RepostOfID
+		}
+
+		item, err = st.AddBasketItem(ctx, uid, postID, quantity)
+		if err != nil {
+			return err
+		}
+
+		if item.Quantity > maxOrderQuantity {
+			return ErrInvalidQuantity
+		}
+
+		return nil
+	})
+	if err != nil {
+		return BasketItem{}, err
+	}
+
+	return item, nil
+}
+
+// Basket of the authenticated user, oldest first.
+func (s *Service) Basket(ctx context.Context) ([]BasketItem, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return s.store.BasketItems(ctx, uid)
+}
+
+// RemoveFromBasket deletes an item from the basket of the authenticated user.
+func (s *Service) RemoveFromBasket(ctx context.Context, itemID int64) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	return s.store.DeleteBasketItem(ctx, uid, itemID)
+}
+
+// 장바구니를 한 번에 주문하고 비움
+// PlaceOrder turns the basket of the authenticated user into an order.
+// Posts that are no longer visible fail the whole order with ErrPostNotFound.
+func (s *Service) PlaceOrder(ctx context.Context) (Order, error) {
+	var o Order
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return o, ErrUnauthenticated
+	}
+
+	err := s.store.Tx(ctx, func(st Store) error {
+		items, err := st.BasketItems(ctx, uid)
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return ErrEmptyBasket
+		}
+
+		sellers := map[int64]int64{}
+		for _, item := range items {
+			p, err := st.Post(ctx, uid, item.PostID)
+			if err != nil {
+				return err
+			}
+			sellers[p.ID] = p.UserID
+		}
+
+		if o, err = st.PlaceOrder(ctx, uid); err != nil {
+			return err
+		}
+
+		e := OrderEvent{OrderNum: o.Num, UserID: uid, Items: make([]OrderEventItem, len(o.Items))}
+		for i, item := range o.Items {
+			e.Items[i] = OrderEventItem{PostID: item.PostID, SellerID: sellers[item.PostID], Quantity: item.Quantity}
+		}
+		return emitEvent(ctx, st, EventOrderPlaced, e)
+	})
+	if err != nil {
+		return Order{}, err
+	}
+
+	s.wakeOutbox()
+	return o, nil
+}
+
+// Orders bought by the authenticated user in descending order and with backward pagination.
+func (s *Service) Orders(ctx context.Context, last int, before int64) ([]OrderItem, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return s.store.OrderItems(ctx, uid, false, normailizePageSize(last), before)
+}
+
+// Sales of the posts of the authenticated user in descending order and with backward pagination.
+func (s *Service) Sales(ctx context.Context, last int, before int64) ([]OrderItem, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return s.store.OrderItems(ctx, uid, true, normailizePageSize(last), before)
+}
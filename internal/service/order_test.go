@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	s := newTestService(t)
+	sellerCtx, _ := newTestUser(t, s, "seller")
+	buyerCtx, _ := newTestUser(t, s, "buyer")
+
+	p, err := s.CreatePost(sellerCtx, "for sale", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 내용 없는 공유를 담으면 원본이 담김
+	repost, err := s.Repost(buyerCtx, p.Post.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.AddToBasket(buyerCtx, p.Post.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := s.AddToBasket(buyerCtx, repost.Post.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.PostID != p.Post.ID || item.Quantity != 3 {
+		t.Errorf("basket item = %+v, want 3 of post %d", item, p.Post.ID)
+	}
+
+	o, err := s.PlaceOrder(buyerCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Num == 0 || len(o.Items) != 1 || o.Items[0].Quantity != 3 {
+		t.Errorf("order = %+v", o)
+	}
+
+	basket, err := s.Basket(buyerCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(basket) != 0 {
+		t.Errorf("basket after order = %+v, want empty", basket)
+	}
+
+	orders, err := s.Orders(buyerCtx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sales, err := s.Sales(sellerCtx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(orders) != 1 || len(sales) != 1 || sales[0].OrderNum != o.Num || sales[0].PostID != p.Post.ID {
+		t.Errorf("orders = %+v, sales = %+v", orders, sales)
+	}
+
+	_, err = s.PlaceOrder(buyerCtx)
+	assertError(t, err, ErrEmptyBasket)
+}
+
+func TestOrderErrors(t *testing.T) {
+	s := newTestService(t)
+	sellerCtx, _ := newTestUser(t, s, "seller")
+	buyerCtx, _ := newTestUser(t, s, "buyer")
+	otherCtx, _ := newTestUser(t, s, "other")
+
+	p, err := s.CreatePost(sellerCtx, "for sale", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := s.AddToBasket(buyerCtx, p.Post.ID, maxOrderQuantity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+		want *Error
+	}{
+		{"anonymous", func() error {
+			_, err := s.AddToBasket(context.Background(), p.Post.ID, 1)
+			return err
+		}, ErrUnauthenticated},
+		{"zero quantity", func() error {
+			_, err := s.AddToBasket(buyerCtx, p.Post.ID, 0)
+			return err
+		}, ErrInvalidQuantity},
+		{"over the limit in total", func() error {
+			_, err := s.AddToBasket(buyerCtx, p.Post.ID, 1)
+			return err
+		}, ErrInvalidQuantity},
+		{"missing post", func() error {
+			_, err := s.AddToBasket(buyerCtx, p.Post.ID+100, 1)
+			return err
+		}, ErrPostNotFound},
+		{"item of another user", func() error {
+			return s.RemoveFromBasket(otherCtx, item.ID)
+		}, ErrBasketItemNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, tt.fn(), tt.want)
+		})
+	}
+
+	// 실패한 담기는 수량을 바꾸지 않음
+	basket, err := s.Basket(buyerCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(basket) != 1 || basket[0].Quantity != maxOrderQuantity {
+		t.Errorf("basket = %+v, want %d of one post", basket, maxOrderQuantity)
+	}
+}
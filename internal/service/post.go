@@ -2,41 +2,43 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net/http"
 	"strings"
 	"time"
-
-	"github.com/sanity-io/litter"
 )
 
 // 에러문
 // Error
 var (
 	// ErrInvalidContent is used for invalid content.
-	ErrInvalidContent = errors.New("invalid content")
+	ErrInvalidContent = newFieldError(http.StatusUnprocessableEntity, "invalid_content", "invalid content", "content")
 	// ErrInvalidSpoiler is used for invalid spoiler.
-	ErrInvalidSpoiler = errors.New("invalid spoiler")
+	ErrInvalidSpoiler = newFieldError(http.StatusUnprocessableEntity, "invalid_spoiler", "invalid spoiler", "spoiler_of")
 	// ErrPostNotFound denotes a post that was not found
-	ErrPostNotFound = errors.New("post not found")
+	ErrPostNotFound = newError(http.StatusNotFound, "post_not_found", "post not found")
 )
 
 // 게시물 모델
 // Post model
 type Post struct {
-	ID         int64     `json:"id,"`
-	UserID     int64     `json:"userid,"`
-	Content    string    `json:"content,"`
-	SpoilerOf  *string   `json:"spoiler_of,"`
-	NSFW       bool      `json:"nsfw,"`
-	LikesCount int       `json:"likesCount,"`
-	CommentsCount int	 `json:"commentsCount"`
-	CreatedAt  time.Time `json:"created_at,"`
-	User       *User     `json:"user,omitempty"`
-	Mine       bool      `json:"mine,"`
-	Liked      bool      `json:"liked,"`
+	ID            int64     `json:"id,"`
+	UserID        int64     `json:"userid,"`
+	Content       string    `json:"content,"`
+	SpoilerOf     *string   `json:"spoiler_of,"`
+	NSFW          bool      `json:"nsfw,"`
+	LikesCount    int       `json:"likesCount,"`
+	CommentsCount int       `json:"commentsCount"`
+	RepostsCount  int       `json:"repostsCount"`
+	CreatedAt     time.Time `json:"created_at,"`
+	User          *User     `json:"user,omitempty"`
+	Mine          bool      `json:"mine,"`
+	Liked         bool      `json:"liked,"`
+	Media         []Media   `json:"media,omitempty"`
+	RepostOfID    *int64    `json:"-"`
+	RepostOf      *Post     `json:"repost_of,omitempty"`
+	Held          bool      `json:"held,omitempty"`
 }
 
 type ToggleLikeOutput struct {
@@ -46,7 +48,7 @@ type ToggleLikeOutput struct {
 
 // 게시물 생성 및 타임라인에 게시물 표시
 // CreatedPost publishes a post the user timeline and fan-outs it to his followers.
-func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool) (TimelineItem, error) {
+func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.Reader) (TimelineItem, error) {
 	var ti TimelineItem
 	uid, ok := ctx.Value(KeyAuthUserID).(int64)
 
@@ -54,103 +56,235 @@ func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *str
 		return ti, ErrUnauthenticated
 	}
 
+	var contentErr, spoilerErr *Error
 	content = strings.TrimSpace(content)
 
 	if content == "" || len([]rune(content)) > 480 {
-		return ti, ErrInvalidContent
+		contentErr = ErrInvalidContent
 	}
 
 	if spoilerOf != nil {
 		*spoilerOf = strings.TrimSpace(*spoilerOf)
 		if *spoilerOf == "" || len([]rune(*spoilerOf)) > 64 {
-			return ti, ErrInvalidContent
+			spoilerErr = ErrInvalidSpoiler
 		}
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
+	if err := invalid(contentErr, spoilerErr); err != nil {
+		return ti, err
+	}
+
+	if len(media) > MaxPostMedia {
+		return ti, ErrTooManyMedia
+	}
 
+	// 필터 검사, 이미지 저장 전에
+	// run the content filter before writing anything
+	texts := []string{content}
+	if spoilerOf != nil {
+		texts = append(texts, *spoilerOf)
+	}
+	verdict, err := s.filterContent(ctx, "posts", uid, texts...)
 	if err != nil {
-		return ti, fmt.Errorf("could not begin tx: %v", err)
+		return ti, err
 	}
 
-	defer tx.Rollback()
+	switch verdict.action {
+	case FilterReject:
+		return ti, ErrContentRejected
+	case FilterNSFW:
+		nsfw = true
+	}
 
-	query := "INSERT INTO posts (user_id, content, spoiler_of, nsfw) VALUES ($1, $2, $3, $4) " + "RETURNING id, created_at"
-	if err = tx.QueryRowContext(ctx, query, uid, content, spoilerOf, nsfw).Scan(&ti.Post.ID, &ti.Post.CreatedAt); err != nil {
-		return ti, fmt.Errorf("could not insert post: %v", err)
+	// 이미지 먼저 저장, 실패하면 저장된 이미지 삭제
+	// write the media renditions first and remove them if anything fails
+	files := make([]mediaFile, 0, len(media))
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		for _, mf := range files {
+			s.removeMedia(mf.name)
+		}
+	}()
+
+	for _, r := range media {
+		mf, err := s.saveMedia(ctx, r)
+		if err != nil {
+			return ti, err
+		}
+
+		files = append(files, mf)
 	}
 
-	ti.Post.UserID = uid
-	ti.Post.Content = content
-	ti.Post.SpoilerOf = spoilerOf
-	ti.Post.NSFW = nsfw
-	ti.Post.Mine = true
+	err = s.store.Tx(ctx, func(st Store) error {
+		ti = TimelineItem{}
+		ti.Post.UserID = uid
+		ti.Post.Content = content
+		ti.Post.SpoilerOf = spoilerOf
+		ti.Post.NSFW = nsfw
+		ti.Post.Mine = true
+		if err := st.InsertPost(ctx, &ti.Post); err != nil {
+			return err
+		}
 
-	query = "INSERT INTO timeline (user_id, post_id) VALUES ($1, $2) RETURNING id"
-	if err = tx.QueryRowContext(ctx, query, uid, ti.Post.ID).Scan(&ti.ID); err != nil {
-		return ti, fmt.Errorf("could not insert timeline item: %v", err)
+		if verdict.action == FilterHold {
+			if err := holdForReview(ctx, st, ReportTargetPost, ti.Post.ID, verdict.reason); err != nil {
+				return err
+			}
+			ti.Post.Held = true
+		}
+
+		if err := st.InsertPostMedia(ctx, ti.Post.ID, files); err != nil {
+			return err
+		}
+
+		var err error
+		if ti.ID, err = st.InsertTimelineItem(ctx, uid, ti.Post.ID); err != nil {
+			return err
+		}
+
+		if err = enqueueJob(ctx, st, JobDispatchPost, dispatchPostJob{PostID: ti.Post.ID, UserID: uid, Held: ti.Post.Held}); err != nil {
+			return err
+		}
+
+		return emitEvent(ctx, st, EventPostCreated, postEvent(ti.Post))
+	})
+	if err != nil {
+		return ti, err
+	}
+
+	s.wakeJobs(JobDispatchPost)
+	s.wakeOutbox()
+
+	for _, mf := range files {
+		ti.Post.Media = append(ti.Post.Media, s.mediaFromFile(mf))
 	}
 
 	ti.UserID = uid
 	ti.PostID = ti.Post.ID
 
-	if err = tx.Commit(); err != nil {
-		return ti, fmt.Errorf("could not commit to create post: %v", err)
+	committed = true
+
+	return ti, nil
+}
+
+// 팔로워들의 타임라인에 게시물 배포
+// dispatchPost fan-outs a new post, it runs as a JobDispatchPost job.
+func (s *Service) dispatchPost(ctx context.Context, postID, authorID int64, held bool) error {
+	p, err := s.Post(context.WithValue(ctx, KeyAuthUserID, authorID), postID)
+	// 그 사이 지워진 게시물
+	if err == ErrPostNotFound {
+		return nil
 	}
 
-	go func(p Post) {
-		u, err := s.userByID(context.Background(), p.UserID)
-		if err != nil {
-			log.Printf("could not get post user: %v\n", err)
-			return
-		}
+	if err != nil {
+		return err
+	}
 
-		p.User = &u
-		p.Mine = false
+	u, err := s.userByID(ctx, p.UserID)
+	if err != nil {
+		return fmt.Errorf("could not get post user: %w", err)
+	}
 
-		tt, err := s.fanoutPost(p)
-		if err != nil {
-			log.Printf("could not fanout post: %v\n", err)
-			return
+	p.User = &u
+	p.Mine = false
+	p.Liked = false
+	p.Held = held
+
+	tt, err := s.fanoutPost(ctx, p)
+	if err != nil {
+		return fmt.Errorf("could not fanout post: %w", err)
+	}
+
+	// 검토 대기 중인 게시물은 실시간으로 보내지 않음
+	if p.Held {
+		return nil
+	}
+
+	muters, err := s.muterIDs(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, ti := range tt {
+		if !muters[ti.UserID] {
+			s.broadcastTimelineItem(ti)
 		}
+	}
+	return nil
+}
 
-		for _, ti = range tt {
-			log.Println(litter.Sdump(ti))
-			// TODO: broadcast timeline items.
+// 다시 실행해도 이미 넣은 타임라인 항목은 그대로
+func (s *Service) fanoutPost(ctx context.Context, p Post) ([]TimelineItem, error) {
+	pull, err := s.pullPost(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pull {
+		if err = s.store.SetPostPulled(ctx, p.ID); err != nil {
+			return nil, err
 		}
 
-	}(ti.Post)
+		return s.pulledTimelineItems(ctx, p)
+	}
 
-	return ti, nil
+	tt, err := s.store.FanoutPost(ctx, p.ID, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tt {
+		tt[i].Post = p
+	}
+
+	return tt, nil
 }
 
-func (s *Service) fanoutPost(p Post) ([]TimelineItem, error) {
-	query := "INSERT INTO timeline (user_id, post_id) " +
-		"SELECT follower_id, $1 FROM follows WHERE followee_id = $2 " +
-		"RETURNING id, user_id"
-	rows, err := s.db.Query(query, p.ID, p.UserID)
+// 팔로워가 기준 이상이면 배포하지 않고 읽을 때 합침
+func (s *Service) pullPost(ctx context.Context, authorID int64) (bool, error) {
+	if s.pullThreshold <= 0 {
+		return false, nil
+	}
 
+	n, err := s.store.FollowersCount(ctx, authorID)
 	if err != nil {
-		return nil, fmt.Errorf("could not insert timeline: %v", err)
+		return false, err
 	}
 
-	defer rows.Close()
+	return n >= s.pullThreshold, nil
+}
 
-	tt := []TimelineItem{}
-	for rows.Next() {
-		var ti TimelineItem
+// 배포하지 않은 게시물은 지금 구독 중인 팔로워에게만 실시간으로 보냄
+func (s *Service) pulledTimelineItems(ctx context.Context, p Post) ([]TimelineItem, error) {
+	subscribers := map[int64]bool{}
+	s.subs.timelineItemClients.Range(func(key, _ interface{}) bool {
+		subscribers[key.(*timelineItemClient).userID] = true
+		return true
+	})
 
-		if err = rows.Scan(&ti.ID, &ti.UserID); err != nil {
-			return nil, fmt.Errorf("could not scan timeline time: %v", err)
-		}
+	tt := []TimelineItem{}
+	if len(subscribers) == 0 {
+		return tt, nil
+	}
 
-		ti.PostID = p.ID
-		ti.Post = p
-		tt = append(tt, ti)
+	// 합치는 항목은 작성자 타임라인의 항목 아이디를 씀
+	id, err := s.store.TimelineItemID(ctx, p.UserID, p.ID)
+	if err != nil || id == 0 {
+		return tt, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate timeline rows: %v", err)
+	for uid := range subscribers {
+		following, err := s.store.Following(ctx, uid, p.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if following {
+			tt = append(tt, TimelineItem{ID: id, UserID: uid, PostID: p.ID, Post: p})
+		}
 	}
 
 	return tt, nil
@@ -168,63 +302,21 @@ func (s *Service) Posts(
 		return nil, ErrInvalidUsername
 	}
 
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
 	last = normailizePageSize(last)
 
-	query, args, err := buildQuery(`
-		SELECT id, content, spoiler_of, nsfw, likes_count, comments_count, created_at
-		{{if .auth}}
-		, posts.user_id = @uid AS mine
-		, likes.user_id IS NOT NULL AS liked
-		{{end}}
-		FROM posts
-		{{if .auth}}
-		LEFT JOIN post_likes AS likes
-			ON likes.user_id = @uid AND likes.post_id = posts.id
-		{{end}}
-		WHERE posts.user_id = (SELECT id FROM users WHERE username = @username)
-		{{if .before}}AND posts.id < @before{{end}}
-		ORDER BY created_at DESC
-		LIMIT @last
-	`, map[string]interface{}{
-		"auth":     auth,
-		"uid":      uid,
-		"username": username,
-		"last":     last,
-		"before":   before,
-	})
+	pp, err := s.store.Posts(ctx, uid, username, last, before)
 	if err != nil {
-		return nil, fmt.Errorf("could not build posts sql query: %v", err)
+		return nil, err
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("could not query select posts: %v", err)
-	}
-
-	defer rows.Close()
-
-	pp := make([]Post, 0, last)
-	for rows.Next() {
-		var p Post
-		dest := []interface{}{&p.ID, &p.Content, &p.SpoilerOf, &p.NSFW, &p.LikesCount, &p.CommentsCount, &p.CreatedAt}
-		if auth {
-			dest = append(dest, &p.Mine, &p.Liked)
-		}
-
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan post: %v", err)
-		}
-
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan post: %v", err)
-		}
-
-		pp = append(pp, p)
+	ptrs := make([]*Post, len(pp))
+	for i := range pp {
+		ptrs[i] = &pp[i]
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate posts rows: %v", err)
+	if err = s.decoratePosts(ctx, ptrs); err != nil {
+		return nil, err
 	}
 
 	return pp, nil
@@ -232,52 +324,15 @@ func (s *Service) Posts(
 
 // Post with the given ID
 func (s *Service) Post(ctx context.Context, postID int64) (Post, error) {
-	var p Post
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
-	query, args, err := buildQuery(`
-		SELECT posts.id, content, spoiler_of, nsfw, likes_count, comments_count, created_at
-		, users.username, users.avatar
-		{{if .auth}}
-		, posts.user_id = @uid AS mine
-		, likes.user_id IS NOT NULL AS liked
-		{{end}}
-		FROM posts
-		INNER JOIN users ON posts.user_id = users.id
-		{{if .auth}}
-		LEFT JOIN post_likes AS likes
-			ON likes.user_id = @uid AND likes.post_id = posts.id
-		{{end}}
-		WHERE posts.id = @post_id
-	`, map[string]interface{}{
-		"auth":    auth,
-		"uid":     uid,
-		"post_id": postID,
-	})
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
+	p, err := s.store.Post(ctx, uid, postID)
 	if err != nil {
-		return p, fmt.Errorf("could not build post sql query: %v", err)
+		return p, err
 	}
 
-	var u User
-	var avatar sql.NullString
-	dest := []interface{}{&p.ID, &p.Content, &p.SpoilerOf, &p.NSFW, &p.LikesCount, &p.CommentsCount, &p.CreatedAt, &u.UserName, &avatar}
-	if auth {
-		dest = append(dest, &p.Mine, &p.Liked)
+	if err = s.decoratePosts(ctx, []*Post{&p}); err != nil {
+		return p, err
 	}
-	err = s.db.QueryRowContext(ctx, query, args...).Scan(dest...)
-	if err == sql.ErrNoRows {
-		return p, ErrPostNotFound
-	}
-
-	if err != nil {
-		return p, fmt.Errorf("could not query select post: %v", err)
-	}
-
-	if avatar.Valid {
-		avatarURL := s.origin + "/img/avatars/" + avatar.String
-		u.AvatarURL = &avatarURL
-	}
-
-	p.User = &u
 
 	return p, nil
 }
@@ -285,6 +340,25 @@ func (s *Service) Post(ctx context.Context, postID int64) (Post, error) {
 // 글 좋아요 기능
 // TogllePostLike
 func (s *Service) TogglePostLike(ctx context.Context, postID int64) (ToggleLikeOutput, error) {
+	return s.setPostLike(ctx, postID, nil)
+}
+
+// 좋아요, 이미 좋아요한 상태면 그대로
+// LikePost is the idempotent form of TogglePostLike.
+func (s *Service) LikePost(ctx context.Context, postID int64) (ToggleLikeOutput, error) {
+	liked := true
+	return s.setPostLike(ctx, postID, &liked)
+}
+
+// 좋아요 취소, 좋아요하지 않은 상태면 그대로
+// UnlikePost is the idempotent form of TogglePostLike.
+func (s *Service) UnlikePost(ctx context.Context, postID int64) (ToggleLikeOutput, error) {
+	liked := false
+	return s.setPostLike(ctx, postID, &liked)
+}
+
+// like가 nil이면 토글
+func (s *Service) setPostLike(ctx context.Context, postID int64, like *bool) (ToggleLikeOutput, error) {
 	var out ToggleLikeOutput
 	uid, ok := ctx.Value(KeyAuthUserID).(int64)
 
@@ -292,58 +366,46 @@ func (s *Service) TogglePostLike(ctx context.Context, postID int64) (ToggleLikeO
 		return out, ErrUnauthenticated
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-
-	if err != nil {
-		return out, fmt.Errorf("could not begin tx: %v", err)
-	}
-
-	defer tx.Rollback()
-
-	query := `
-		SELECT EXISTS (
-			SELECT 1 FROM post_likes WHERE user_id = $1 AND post_id = $2
-		)`
-
-	if err = tx.QueryRowContext(ctx, query, uid, postID).Scan(&out.Liked); err != nil {
-		return out, fmt.Errorf("could not query select post like existence : %v", err)
-	}
-
-	//좋아요 취소 기능(두번 좋아요 했을시)
-	if out.Liked {
-		query = "DELETE FROM post_likes WHERE user_id = $1 AND post_id = $2"
-		if _, err = tx.ExecContext(ctx, query, uid, postID); err != nil {
-			return out, fmt.Errorf("could not delete post like: %v", err)
+	err := s.store.Tx(ctx, func(st Store) error {
+		// 볼 수 없는 게시물(숨김, 차단, 팔로우하지 않은 비공개 계정)은 없는 것과 같음
+		if _, err := st.Post(ctx, uid, postID); err != nil {
+			return err
 		}
 
-		query = "UPDATE posts SET likes_count = likes_count - 1 WHERE id = $1 RETURNING likes_count"
-		if err = tx.QueryRowContext(ctx, query, postID).Scan(&out.LikesCount); err != nil {
-			return out, fmt.Errorf("could not update and decrement post likes count: %v", err)
+		liked, err := st.PostLiked(ctx, uid, postID)
+		if err != nil {
+			return err
 		}
-	} else {
-		//좋아요 기능
-		query = "INSERT INTO post_likes (user_id, post_id) VALUES ($1, $2)"
-		_, err = tx.ExecContext(ctx, query, uid, postID)
 
-		if isForeignKeyViolation(err) {
-			return out, ErrPostNotFound
+		out.Liked = !liked
+		if like != nil {
+			out.Liked = *like
 		}
 
+		event := EventPostUnliked
+		switch {
+		case out.Liked == liked:
+			out.LikesCount, err = st.PostLikesCount(ctx, postID)
+			return err
+		case out.Liked:
+			//좋아요 기능
+			out.LikesCount, err = st.InsertPostLike(ctx, uid, postID)
+			event = EventPostLiked
+		default:
+			//좋아요 취소 기능(두번 좋아요 했을시)
+			out.LikesCount, err = st.DeletePostLike(ctx, uid, postID)
+		}
 		if err != nil {
-			return out, fmt.Errorf("could not insert post like: %v", err)
+			return err
 		}
 
-		query = "UPDATE posts SET likes_count = likes_count + 1 WHERE id = $1 RETURNING likes_count"
-		if err = tx.QueryRowContext(ctx, query, postID).Scan(&out.LikesCount); err != nil {
-			return out, fmt.Errorf("could not update and increment post likes count: %v", err)
-		}
-	}
-
-	if err = tx.Commit(); err != nil {
-		return out, fmt.Errorf("could not commit to toggle post like: %v", err)
+		return emitEvent(ctx, st, event, LikeEvent{UserID: uid, PostID: postID, LikesCount: out.LikesCount})
+	})
+	if err != nil {
+		return out, err
 	}
 
-	out.Liked = !out.Liked
+	s.wakeOutbox()
 
 	return out, nil
 }
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCreatePost(t *testing.T) {
+	s := newTestService(t)
+	ctx, authorID := newTestUser(t, s, "author")
+	followerCtx, followerID := newTestUser(t, s, "follower")
+	if err := s.store.InsertFollow(context.Background(), followerID, authorID); err != nil {
+		t.Fatal(err)
+	}
+
+	spoiler := "  show  "
+	ti, err := s.CreatePost(ctx, "  hello  ", &spoiler, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ti.ID == 0 || ti.Post.ID == 0 || ti.UserID != authorID || ti.PostID != ti.Post.ID {
+		t.Errorf("timeline item = %+v", ti)
+	}
+
+	if ti.Post.Content != "hello" || *ti.Post.SpoilerOf != "show" || !ti.Post.Mine {
+		t.Errorf("post = %+v, want trimmed content and spoiler", ti.Post)
+	}
+
+	// 작성자 타임라인에는 바로, 팔로워는 배포 작업 후
+	if tt, err := s.Timeline(ctx, 0, 0); err != nil || len(tt) != 1 || tt[0].ID != ti.ID {
+		t.Errorf("author timeline = %+v, %v", tt, err)
+	}
+
+	if tt, _ := s.Timeline(followerCtx, 0, 0); len(tt) != 0 {
+		t.Errorf("follower timeline before the fan-out = %+v", tt)
+	}
+
+	runTestJobs(t, s)
+	tt, err := s.Timeline(followerCtx, 0, 0)
+	if err != nil || len(tt) != 1 || tt[0].Post.ID != ti.Post.ID || tt[0].Post.Mine {
+		t.Errorf("follower timeline = %+v, %v", tt, err)
+	}
+}
+
+func TestCreatePostErrors(t *testing.T) {
+	s := newTestService(t, WithContentFilter(ContentFilter{
+		Words: []FilterWord{{Word: "spam", Action: FilterReject}},
+	}))
+	ctx, _ := newTestUser(t, s, "author")
+
+	blank := " "
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		content string
+		spoiler *string
+		media   int
+		want    *Error
+	}{
+		{"unauthenticated", context.Background(), "hello", nil, 0, ErrUnauthenticated},
+		{"empty", ctx, "   ", nil, 0, ErrInvalidContent},
+		{"too long", ctx, strings.Repeat("가", 481), nil, 0, ErrInvalidContent},
+		{"blank spoiler", ctx, "hello", &blank, 0, ErrInvalidSpoiler},
+		{"too many media", ctx, "hello", nil, MaxPostMedia + 1, ErrTooManyMedia},
+		{"filtered", ctx, "buy spam now", nil, 0, ErrContentRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media := make([]io.Reader, tt.media)
+			for i := range media {
+				media[i] = strings.NewReader("")
+			}
+
+			_, err := s.CreatePost(tt.ctx, tt.content, tt.spoiler, false, media)
+			assertError(t, err, tt.want)
+		})
+	}
+
+	// 여러 필드가 틀리면 한 번에
+	_, err := s.CreatePost(ctx, "", &blank, false, nil)
+	e, ok := err.(*Error)
+	if !ok || e.Code != ErrInvalidInput.Code || len(e.Details) != 2 {
+		t.Errorf("err = %#v, want invalid_input with 2 details", err)
+	}
+
+	if tt, _ := s.Timeline(ctx, 0, 0); len(tt) != 0 {
+		t.Errorf("failed posts were saved: %+v", tt)
+	}
+}
+
+func TestCreatePostMediaErrors(t *testing.T) {
+	s := newTestService(t)
+	ctx, _ := newTestUser(t, s, "author")
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	var pngBuf, jpegBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegBuf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		media []byte
+		want  *Error
+	}{
+		{"empty", nil, ErrUnsupportedMediaFormat},
+		{"not an image", []byte("hello"), ErrUnsupportedMediaFormat},
+		{"truncated png", pngBuf.Bytes()[:pngBuf.Len()/2], ErrUnsupportedMediaFormat},
+		{"truncated jpeg", jpegBuf.Bytes()[:jpegBuf.Len()/2], ErrUnsupportedMediaFormat},
+		{"corrupt png", append(append([]byte{}, pngBuf.Bytes()[:40]...), bytes.Repeat([]byte{0xff}, 64)...), ErrUnsupportedMediaFormat},
+		{"too large", bytes.Repeat([]byte{0}, MaxMediaBytes+1), ErrMediaTooLarge},
+		{"too many pixels", pngWithSize(t, pngBuf.Bytes(), 10000, 4001), ErrMediaDimensionsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreatePost(ctx, "hello", nil, false, []io.Reader{bytes.NewReader(tt.media)})
+			assertError(t, err, tt.want)
+		})
+	}
+}
+
+// 헤더의 크기만 바꾼 png, 압축 폭탄처럼 작은 파일이 큰 이미지로 풀림
+func pngWithSize(t testing.TB, b []byte, width, height uint32) []byte {
+	t.Helper()
+	if len(b) < 33 || string(b[12:16]) != "IHDR" {
+		t.Fatal("not a png")
+	}
+
+	b = append([]byte{}, b...)
+	binary.BigEndian.PutUint32(b[16:20], width)
+	binary.BigEndian.PutUint32(b[20:24], height)
+	binary.BigEndian.PutUint32(b[29:33], crc32.ChecksumIEEE(b[12:29]))
+	return b
+}
+
+func TestLikePostIdempotent(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	bobCtx, _ := newTestUser(t, s, "bob")
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 두 번 눌러도 한 번만 바뀌고 이벤트도 하나
+	steps := []struct {
+		name    string
+		fn      func(context.Context, int64) (ToggleLikeOutput, error)
+		liked   bool
+		count   int
+		likes   int
+		unlikes int
+	}{
+		{"like", s.LikePost, true, 1, 1, 0},
+		{"like again", s.LikePost, true, 1, 1, 0},
+		{"unlike", s.UnlikePost, false, 0, 1, 1},
+		{"unlike again", s.UnlikePost, false, 0, 1, 1},
+	}
+	for _, step := range steps {
+		out, err := step.fn(bobCtx, p.Post.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if out.Liked != step.liked || out.LikesCount != step.count {
+			t.Errorf("%s = %+v, want liked %v with %d likes", step.name, out, step.liked, step.count)
+		}
+
+		if likes, unlikes := outboxCount(s, EventPostLiked), outboxCount(s, EventPostUnliked); likes != step.likes || unlikes != step.unlikes {
+			t.Errorf("%s: %d liked and %d unliked events, want %d and %d", step.name, likes, unlikes, step.likes, step.unlikes)
+		}
+	}
+
+	_, err = s.LikePost(context.Background(), p.Post.ID)
+	assertError(t, err, ErrUnauthenticated)
+
+	_, err = s.UnlikePost(bobCtx, p.Post.ID+100)
+	assertError(t, err, ErrPostNotFound)
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 신고 대상
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+	ReportTargetReview  = "review"
+)
+
+// 관리자 처리
+const (
+	ModerationDismiss = "dismiss"
+	ModerationHide    = "hide"
+	ModerationSuspend = "suspend"
+	ModerationRestore = "restore"
+	ModerationApprove = "approve"
+)
+
+// 자동 필터가 검토 대기로 보낸 신고의 사유
+const reportReasonFilter = "content_filter"
+
+// 신고 사유 코드
+var reportReasons = map[string]bool{
+	"spam":            true,
+	"scam":            true,
+	"prohibited_item": true,
+	"counterfeit":     true,
+	"harassment":      true,
+	"nsfw":            true,
+	"other":           true,
+}
+
+var (
+	// ErrInvalidReportTarget is used when the report does not point to exactly one post, comment, user or review.
+	ErrInvalidReportTarget = newFieldError(http.StatusUnprocessableEntity, "invalid_report_target", "invalid report target", "target")
+	// ErrInvalidReportReason is used for unknown reason codes.
+	ErrInvalidReportReason = newFieldError(http.StatusUnprocessableEntity, "invalid_report_reason", "invalid report reason", "reason")
+	// ErrAlreadyReported is used when the user already reported the same target.
+	ErrAlreadyReported = newError(http.StatusConflict, "already_reported", "already reported")
+	// ErrReportNotFound denotes that the report was not found or is already handled.
+	ErrReportNotFound = newError(http.StatusNotFound, "report_not_found", "report not found")
+	// ErrInvalidModerationAction is used when the action does not apply to the report.
+	ErrInvalidModerationAction = newFieldError(http.StatusUnprocessableEntity, "invalid_moderation_action", "invalid moderation action", "action")
+	// ErrUserSuspended is used when a suspended user tries to use the service.
+	ErrUserSuspended = newError(http.StatusForbidden, "user_suspended", "user suspended")
+)
+
+// Report model
+type Report struct {
+	ID         int64      `json:"id"`
+	TargetType string     `json:"target_type"`
+	TargetID   int64      `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Note       string     `json:"note,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Reporter   *User      `json:"reporter,omitempty"`
+}
+
+// 신고할 대상, 넷 중 하나만
+// CreateReportInput points to exactly one post, comment, user or review.
+type CreateReportInput struct {
+	PostID    *int64
+	CommentID *int64
+	Username  *string
+	ReviewID  *int64
+	Reason    string
+	Note      string
+}
+
+// 게시물, 댓글, 유저, 후기 신고
+// CreateReport from the authenticated user.
+func (s *Service) CreateReport(ctx context.Context, in CreateReportInput) (Report, error) {
+	var r Report
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return r, ErrUnauthenticated
+	}
+
+	if !reportReasons[in.Reason] {
+		return r, ErrInvalidReportReason
+	}
+
+	in.Note = strings.TrimSpace(in.Note)
+	if len([]rune(in.Note)) > 480 {
+		return r, ErrInvalidContent
+	}
+
+	// 볼 수 없는 게시물과 댓글은 없는 것과 같음
+	var err error
+	targets := 0
+	if in.PostID != nil {
+		targets++
+		r.TargetType, r.TargetID = ReportTargetPost, *in.PostID
+		_, err = s.store.Post(ctx, uid, *in.PostID)
+	}
+	if in.CommentID != nil {
+		targets++
+		r.TargetType, r.TargetID = ReportTargetComment, *in.CommentID
+		var c Comment
+		if c, err = s.store.Comment(ctx, uid, *in.CommentID); err == nil && c.Deleted {
+			err = ErrCommentNotFound
+		}
+	}
+	if in.Username != nil {
+		targets++
+		r.TargetType = ReportTargetUser
+		r.TargetID, err = s.store.UserIDByUsername(ctx, strings.TrimSpace(*in.Username))
+	}
+	if in.ReviewID != nil {
+		targets++
+		r.TargetType, r.TargetID = ReportTargetReview, *in.ReviewID
+		_, err = s.store.Review(ctx, uid, *in.ReviewID)
+	}
+	if targets != 1 {
+		return Report{}, ErrInvalidReportTarget
+	}
+
+	if err != nil {
+		return Report{}, err
+	}
+
+	r.Reason = in.Reason
+	r.Note = in.Note
+	err = s.store.Tx(ctx, func(st Store) error {
+		if err := st.InsertReport(ctx, uid, &r); err != nil {
+			return err
+		}
+
+		return emitEvent(ctx, st, EventReportCreated, reportEvent(r))
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	s.wakeOutbox()
+	return r, nil
+}
+
+// 관리자용 신고 목록
+// Reports with the given status in descending order with backward pagination. Admin only.
+func (s *Service) Reports(ctx context.Context, status string, last int, before int64) ([]Report, error) {
+	if err := s.mustBeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = "open"
+	}
+
+	rr, err := s.store.Reports(ctx, status, normailizePageSize(last), before)
+	if err != nil {
+		return nil, err
+	}
+
+	// 자동 필터 신고는 신고자가 없음
+	for i := range rr {
+		s.userAvatar(rr[i].Reporter)
+	}
+	return rr, nil
+}
+
+// 신고 처리, 같은 대상의 다른 신고도 함께 처리하고 감사 기록을 남김
+// ResolveReport applies the moderation action to the report target,
+// closes every open report of that target and records the decision. Admin only.
+// Restore reverts the action of an already resolved report.
+func (s *Service) ResolveReport(ctx context.Context, reportID int64, action, note string) error {
+	if err := s.mustBeAdmin(ctx); err != nil {
+		return err
+	}
+
+	uid := ctx.Value(KeyAuthUserID).(int64)
+	note = strings.TrimSpace(note)
+
+	err := s.store.Tx(ctx, func(st Store) error {
+		r, err := st.Report(ctx, reportID)
+		if err != nil {
+			return err
+		}
+
+		// 되돌리기는 처리된 신고만, 나머지는 열린 신고만
+		if action == ModerationRestore && r.Status != "resolved" {
+			return ErrInvalidModerationAction
+		}
+
+		if action != ModerationRestore && r.Status != "open" {
+			return ErrReportNotFound
+		}
+
+		applied := action
+		newStatus := "resolved"
+		switch action {
+		case ModerationDismiss:
+			newStatus = "dismissed"
+		case ModerationHide:
+			if r.TargetType == ReportTargetUser {
+				return ErrInvalidModerationAction
+			}
+			err = setHidden(ctx, st, r.TargetType, r.TargetID, true)
+		case ModerationSuspend:
+			err = setSuspended(ctx, st, r.TargetType, r.TargetID, true)
+		case ModerationApprove:
+			// 숨겨진 내용 다시 공개 (자동 필터의 검토 대기 포함)
+			if r.TargetType == ReportTargetUser {
+				return ErrInvalidModerationAction
+			}
+			err = setHidden(ctx, st, r.TargetType, r.TargetID, false)
+			newStatus = "dismissed"
+		case ModerationRestore:
+			// 마지막 처리를 되돌림, 이미 되돌렸으면 할 일이 없음
+			if applied, err = st.LastModerationAction(ctx, r.TargetType, r.TargetID); err != nil {
+				return err
+			}
+
+			switch applied {
+			case ModerationHide:
+				err = setHidden(ctx, st, r.TargetType, r.TargetID, false)
+			case ModerationSuspend:
+				err = setSuspended(ctx, st, r.TargetType, r.TargetID, false)
+			default:
+				return ErrInvalidModerationAction
+			}
+			applied = ModerationRestore
+			newStatus = "dismissed"
+		default:
+			return ErrInvalidModerationAction
+		}
+		if err != nil {
+			return err
+		}
+
+		if action == ModerationRestore {
+			err = st.SetReportStatus(ctx, reportID, newStatus)
+		} else {
+			err = st.CloseReports(ctx, reportID, r.TargetType, r.TargetID, newStatus)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = st.InsertModerationAction(ctx, ModerationAction{
+			ModeratorID: uid,
+			ReportID:    reportID,
+			Action:      applied,
+			TargetType:  r.TargetType,
+			TargetID:    r.TargetID,
+			Note:        note,
+		})
+		if err != nil {
+			return err
+		}
+
+		e := reportEvent(r)
+		e.Action = applied
+		return emitEvent(ctx, st, EventReportResolved, e)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wakeOutbox()
+	return nil
+}
+
+// 자동 필터에 걸린 내용을 숨기고 신고 목록에 올림
+func holdForReview(ctx context.Context, st Store, targetType string, targetID int64, rule string) error {
+	if err := setHidden(ctx, st, targetType, targetID, true); err != nil {
+		return err
+	}
+
+	r := Report{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reportReasonFilter,
+		Note:       rule,
+	}
+	if err := st.InsertReport(ctx, 0, &r); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, st, EventReportCreated, reportEvent(r))
+}
+
+func reportEvent(r Report) ReportEvent {
+	return ReportEvent{
+		ReportID:   r.ID,
+		TargetType: r.TargetType,
+		TargetID:   r.TargetID,
+		Reason:     r.Reason,
+	}
+}
+
+// 게시물, 댓글, 후기 숨기기
+func setHidden(ctx context.Context, st Store, targetType string, targetID int64, hidden bool) error {
+	switch targetType {
+	case ReportTargetComment:
+		return st.SetCommentHidden(ctx, targetID, hidden)
+	case ReportTargetReview:
+		return st.SetReviewHidden(ctx, targetID, hidden)
+	}
+	return st.SetPostHidden(ctx, targetID, hidden)
+}
+
+// 유저 정지, 게시물과 댓글, 후기는 작성자를 정지
+func setSuspended(ctx context.Context, st Store, targetType string, targetID int64, suspended bool) error {
+	userID := targetID
+	switch targetType {
+	case ReportTargetPost:
+		p, err := st.PostByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		userID = p.UserID
+	case ReportTargetComment:
+		c, err := st.CommentByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		userID = c.UserID
+	case ReportTargetReview:
+		r, err := st.ReviewByID(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		userID = r.UserID
+	}
+
+	return st.SetSuspended(ctx, userID, suspended)
+}
+
+// 관리자만 허용
+func (s *Service) mustBeAdmin(ctx context.Context) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	flags, err := s.store.UserFlags(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if !flags.Admin {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveReport(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	readerCtx, _ := newTestUser(t, s, "reader")
+	adminCtx, adminID := newTestUser(t, s, "admin")
+	setTestAdmin(t, s, adminID)
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.CreateReport(readerCtx, CreateReportInput{PostID: &p.Post.ID, Reason: "spam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationHide, " hidden "); err != nil {
+		t.Fatal(err)
+	}
+
+	// 숨긴 게시물은 작성자와 관리자만
+	_, err = s.Post(readerCtx, p.Post.ID)
+	assertError(t, err, ErrPostNotFound)
+
+	if _, err = s.Post(authorCtx, p.Post.ID); err != nil {
+		t.Errorf("author cannot see the hidden post: %v", err)
+	}
+
+	rr, err := s.Reports(adminCtx, "resolved", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rr) != 1 || rr[0].ID != r.ID {
+		t.Errorf("resolved reports = %+v, want %d", rr, r.ID)
+	}
+
+	// 처리된 신고는 다시 처리할 수 없고 되돌리기만
+	err = s.ResolveReport(adminCtx, r.ID, ModerationDismiss, "")
+	assertError(t, err, ErrReportNotFound)
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationRestore, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Post(readerCtx, p.Post.ID); err != nil {
+		t.Errorf("restored post: %v", err)
+	}
+
+	err = s.ResolveReport(adminCtx, r.ID, ModerationRestore, "")
+	assertError(t, err, ErrInvalidModerationAction)
+}
+
+func TestResolveReportDismiss(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	readerCtx, _ := newTestUser(t, s, "reader")
+	adminCtx, adminID := newTestUser(t, s, "admin")
+	setTestAdmin(t, s, adminID)
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.CreateReport(readerCtx, CreateReportInput{PostID: &p.Post.ID, Reason: "spam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationDismiss, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Post(readerCtx, p.Post.ID); err != nil {
+		t.Errorf("post of a dismissed report: %v", err)
+	}
+
+	if rr, _ := s.Reports(adminCtx, "dismissed", 0, 0); len(rr) != 1 || rr[0].ID != r.ID {
+		t.Errorf("dismissed reports = %+v, want %d", rr, r.ID)
+	}
+
+	if rr, _ := s.Reports(adminCtx, "open", 0, 0); len(rr) != 0 {
+		t.Errorf("open reports = %+v, want none", rr)
+	}
+}
+
+func TestResolveReportErrors(t *testing.T) {
+	s := newTestService(t)
+	readerCtx, _ := newTestUser(t, s, "reader")
+	adminCtx, adminID := newTestUser(t, s, "admin")
+	setTestAdmin(t, s, adminID)
+	username := "admin"
+
+	r, err := s.CreateReport(readerCtx, CreateReportInput{Username: &username, Reason: "spam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		reportID int64
+		action   string
+		want     *Error
+	}{
+		{"unauthenticated", context.Background(), r.ID, ModerationDismiss, ErrUnauthenticated},
+		{"not admin", readerCtx, r.ID, ModerationDismiss, ErrPermissionDenied},
+		{"missing report", adminCtx, r.ID + 1000, ModerationDismiss, ErrReportNotFound},
+		{"unknown action", adminCtx, r.ID, "delete", ErrInvalidModerationAction},
+		{"hide a user", adminCtx, r.ID, ModerationHide, ErrInvalidModerationAction},
+		{"restore an open report", adminCtx, r.ID, ModerationRestore, ErrInvalidModerationAction},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ResolveReport(tt.ctx, tt.reportID, tt.action, "")
+			assertError(t, err, tt.want)
+		})
+	}
+
+	// 실패한 처리는 신고를 그대로 둠
+	if rr, _ := s.Reports(adminCtx, "open", 0, 0); len(rr) != 1 || rr[0].ID != r.ID {
+		t.Errorf("open reports = %+v, want %d", rr, r.ID)
+	}
+}
+
+// 볼 수 없는 게시물과 댓글은 신고할 수 없음
+func TestCreateReportHiddenTargets(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	blockerCtx, _ := newTestUser(t, s, "blocker")
+	readerCtx, _ := newTestUser(t, s, "reader")
+	adminCtx, adminID := newTestUser(t, s, "admin")
+	setTestAdmin(t, s, adminID)
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hidden, err := s.CreatePost(authorCtx, "hidden", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.CreateComment(authorCtx, p.Post.ID, "comment", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.CreateReport(readerCtx, CreateReportInput{PostID: &hidden.Post.ID, Reason: "spam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationHide, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.ToggleBlock(blockerCtx, "author"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		in   CreateReportInput
+		want *Error
+	}{
+		{"hidden post", readerCtx, CreateReportInput{PostID: &hidden.Post.ID}, ErrPostNotFound},
+		{"post of a blocked user", blockerCtx, CreateReportInput{PostID: &p.Post.ID}, ErrPostNotFound},
+		{"comment of a blocked user", blockerCtx, CreateReportInput{CommentID: &c.ID}, ErrCommentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Reason = "spam"
+			_, err := s.CreateReport(tt.ctx, tt.in)
+			assertError(t, err, tt.want)
+		})
+	}
+}
+
+// 같은 대상의 신고로 처리를 두 번 되돌리지 않고, 하지 않은 처리는 되돌리지 않음
+func TestResolveReportRestoreOnce(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	authorCtx, authorID := newTestUser(t, s, "author")
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	bobCtx, _ := newTestUser(t, s, "bob")
+	adminCtx, adminID := newTestUser(t, s, "admin")
+	setTestAdmin(t, s, adminID)
+	username := "author"
+
+	p, err := s.CreatePost(authorCtx, "post", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rr []Report
+	for _, in := range []struct {
+		ctx context.Context
+		in  CreateReportInput
+	}{
+		{aliceCtx, CreateReportInput{PostID: &p.Post.ID, Reason: "spam"}},
+		{bobCtx, CreateReportInput{PostID: &p.Post.ID, Reason: "spam"}},
+		{aliceCtx, CreateReportInput{Username: &username, Reason: "spam"}},
+	} {
+		r, err := s.CreateReport(in.ctx, in.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr = append(rr, r)
+	}
+
+	// 게시물 신고 둘은 숨김으로 함께 처리, 유저 신고는 정지
+	if err = s.ResolveReport(adminCtx, rr[0].ID, ModerationHide, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ResolveReport(adminCtx, rr[2].ID, ModerationSuspend, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ResolveReport(adminCtx, rr[0].ID, ModerationRestore, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.ResolveReport(adminCtx, rr[1].ID, ModerationRestore, "")
+	assertError(t, err, ErrInvalidModerationAction)
+
+	flags, err := s.store.UserFlags(ctx, authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !flags.Suspended {
+		t.Error("restoring the hidden post unsuspended the author")
+	}
+}
+
+// 후기를 숨기면 작성자와 관리자에게만 보이고, 정지는 후기 작성자에게
+func TestResolveReportReview(t *testing.T) {
+	s := newTestService(t)
+	sellerCtx, _ := newTestUser(t, s, "seller")
+	buyerCtx, buyerID := newTestUser(t, s, "buyer")
+	readerCtx, _ := newTestUser(t, s, "reader")
+	adminCtx, adminID := newTestUser(t, s, "admin")
+	setTestAdmin(t, s, adminID)
+
+	p, err := s.CreatePost(sellerCtx, "for sale", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.CreateReview(buyerCtx, p.Post.ID, 5, "great")
+	assertError(t, err, ErrNotPurchased)
+
+	if _, err = s.AddToBasket(buyerCtx, p.Post.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.PlaceOrder(buyerCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	review, err := s.CreateReview(buyerCtx, p.Post.ID, 1, "spam link")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.CreateReview(buyerCtx, p.Post.ID, 5, "again")
+	assertError(t, err, ErrAlreadyReviewed)
+
+	r, err := s.CreateReport(readerCtx, CreateReportInput{ReviewID: &review.ID, Reason: "spam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.TargetType != ReportTargetReview {
+		t.Errorf("target type = %q, want review", r.TargetType)
+	}
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationHide, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{"reader", readerCtx, 0},
+		{"author", buyerCtx, 1},
+		{"admin", adminCtx, 1},
+	} {
+		rr, err := s.Reviews(tt.ctx, p.Post.ID, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rr) != tt.want {
+			t.Errorf("%s sees %d reviews, want %d", tt.name, len(rr), tt.want)
+		}
+	}
+
+	_, err = s.CreateReport(sellerCtx, CreateReportInput{ReviewID: &review.ID, Reason: "spam"})
+	assertError(t, err, ErrReviewNotFound)
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationRestore, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = s.CreateReport(sellerCtx, CreateReportInput{ReviewID: &review.ID, Reason: "harassment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ResolveReport(adminCtx, r.ID, ModerationSuspend, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := s.store.UserFlags(context.Background(), buyerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !flags.Suspended {
+		t.Error("review author is not suspended")
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrAlreadyReposted is used when the user already shared the post.
+	ErrAlreadyReposted = newError(http.StatusConflict, "already_reposted", "already reposted")
+	// ErrForbiddenRepost is used when sharing a post of a private account.
+	ErrForbiddenRepost = newError(http.StatusForbidden, "forbidden_repost", "cannot repost a private post")
+)
+
+// 게시물 공유 (content가 있으면 인용 게시물)
+// Repost shares a post with the followers of the authenticated user.
+// With content it becomes a quote post.
+func (s *Service) Repost(ctx context.Context, postID int64, content string) (TimelineItem, error) {
+	var ti TimelineItem
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return ti, ErrUnauthenticated
+	}
+
+	content = strings.TrimSpace(content)
+	if len([]rune(content)) > 480 {
+		return ti, ErrInvalidContent
+	}
+
+	// 인용 게시물만 필터 검사
+	var verdict filterVerdict
+	if content != "" {
+		var err error
+		if verdict, err = s.filterContent(ctx, "posts", uid, content); err != nil {
+			return ti, err
+		}
+
+		if verdict.action == FilterReject {
+			return ti, ErrContentRejected
+		}
+	}
+
+	nsfw := verdict.action == FilterNSFW
+	var authorID int64
+	err := s.store.Tx(ctx, func(st Store) error {
+		ti = TimelineItem{}
+
+		// 볼 수 없는 게시물(숨김, 차단, 팔로우하지 않은 비공개 계정)은 없는 것과 같음
+		// 공유된 게시물을 다시 공유하면 원본을 가리킴
+		// reposting a plain repost points to the original post
+		p, err := st.Post(ctx, uid, postID)
+		if err != nil {
+			return err
+		}
+
+		if p.RepostOfID != nil && p.Content == "" {
+			postID = *p.RepostOfID
+			if p, err = st.Post(ctx, uid, postID); err != nil {
+				return err
+			}
+		}
+
+		authorID = p.UserID
+
+		// 비공개 계정의 게시물은 작성자만 공유
+		// only the author shares posts of a private account
+		flags, err := st.UserFlags(ctx, authorID)
+		if err != nil {
+			return err
+		}
+
+		if flags.Private && authorID != uid {
+			return ErrForbiddenRepost
+		}
+
+		// 내용 없는 공유는 한 번만
+		if content == "" {
+			reposted, err := st.Reposted(ctx, uid, postID)
+			if err != nil {
+				return err
+			}
+
+			if reposted {
+				return ErrAlreadyReposted
+			}
+		}
+
+		ti.Post.UserID = uid
+		ti.Post.Content = content
+		ti.Post.NSFW = nsfw
+		ti.Post.RepostOfID = &postID
+		if err = st.InsertPost(ctx, &ti.Post); err != nil {
+			return err
+		}
+
+		if verdict.action == FilterHold {
+			if err = holdForReview(ctx, st, ReportTargetPost, ti.Post.ID, verdict.reason); err != nil {
+				return err
+			}
+			ti.Post.Held = true
+		}
+
+		if err = st.AddRepostsCount(ctx, postID, 1); err != nil {
+			return err
+		}
+
+		if ti.ID, err = st.InsertTimelineItem(ctx, uid, ti.Post.ID); err != nil {
+			return err
+		}
+
+		if err = enqueueJob(ctx, st, JobDispatchPost, dispatchPostJob{PostID: ti.Post.ID, UserID: uid, Held: ti.Post.Held}); err != nil {
+			return err
+		}
+
+		if err = emitEvent(ctx, st, EventPostCreated, postEvent(ti.Post)); err != nil {
+			return err
+		}
+
+		// 내 게시물이 공유되었을 때 알림
+		if authorID == uid {
+			return nil
+		}
+		return enqueueJob(ctx, st, JobNotifyPost, notifyPostJob{Type: "repost", ActorID: uid, UserID: authorID, PostID: postID})
+	})
+	if err != nil {
+		return ti, err
+	}
+
+	s.wakeJobs(JobDispatchPost)
+	s.wakeJobs(JobNotifyPost)
+	s.wakeOutbox()
+
+	ti.UserID = uid
+	ti.PostID = ti.Post.ID
+	ti.Post.Mine = true
+
+	// 공유는 이미 저장됨, 원본을 불러오지 못하면 원본 없이 응답
+	original, err := s.Post(ctx, postID)
+	if err != nil {
+		if err != ErrPostNotFound {
+			log.Printf("could not load reposted post %d: %v\n", postID, err)
+		}
+		return ti, nil
+	}
+
+	ti.Post.RepostOf = &original
+
+	return ti, nil
+}
+
+// 미디어와 공유된 원본 게시물 채우기
+// decoratePosts loads the media and the reposted originals of the given posts.
+func (s *Service) decoratePosts(ctx context.Context, pp []*Post) error {
+	postIDs := make([]int64, 0, len(pp))
+	var originalIDs []int64
+	for _, p := range pp {
+		postIDs = append(postIDs, p.ID)
+		if p.RepostOfID != nil {
+			originalIDs = append(originalIDs, *p.RepostOfID)
+		}
+	}
+
+	mm, err := s.postsMedia(ctx, postIDs)
+	if err != nil {
+		return err
+	}
+
+	originals, err := s.PostsByIDs(ctx, originalIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pp {
+		s.userAvatar(p.User)
+		p.Media = mm[p.ID]
+		if p.RepostOfID == nil {
+			continue
+		}
+
+		if original, ok := originals[*p.RepostOfID]; ok {
+			p.RepostOf = &original
+		}
+	}
+
+	return nil
+}
+
+// 여러 게시물을 작성자와 함께 한 번에 불러오기
+// PostsByIDs selects the given posts keyed by ID, leaving out the ones the authenticated user can not see.
+func (s *Service) PostsByIDs(ctx context.Context, postIDs []int64) (map[int64]Post, error) {
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
+	pp, err := s.store.PostsByIDs(ctx, uid, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(pp))
+	for id := range pp {
+		ids = append(ids, id)
+	}
+
+	mm, err := s.postsMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, p := range pp {
+		s.userAvatar(p.User)
+		p.Media = mm[id]
+		pp[id] = p
+	}
+
+	return pp, nil
+}
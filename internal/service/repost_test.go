@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRepost(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, _ := newTestUser(t, s, "author")
+	bobCtx, _ := newTestUser(t, s, "bob")
+	carolCtx, _ := newTestUser(t, s, "carol")
+
+	original, err := s.CreatePost(authorCtx, "original", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repost, err := s.Repost(bobCtx, original.Post.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repost.Post.RepostOfID == nil || *repost.Post.RepostOfID != original.Post.ID || repost.Post.Content != "" {
+		t.Errorf("repost = %+v", repost.Post)
+	}
+
+	if repost.Post.RepostOf == nil || repost.Post.RepostOf.Content != "original" {
+		t.Errorf("repost of = %+v, want the original", repost.Post.RepostOf)
+	}
+
+	_, err = s.Repost(bobCtx, original.Post.ID, "")
+	assertError(t, err, ErrAlreadyReposted)
+
+	// 공유한 게시물을 다시 공유하면 원본을 가리킴
+	again, err := s.Repost(carolCtx, repost.Post.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *again.Post.RepostOfID != original.Post.ID {
+		t.Errorf("repost of a repost points to %d, want %d", *again.Post.RepostOfID, original.Post.ID)
+	}
+
+	// 인용은 여러 번 가능
+	quote, err := s.Repost(bobCtx, original.Post.ID, "  look  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quote.Post.Content != "look" {
+		t.Errorf("quote content = %q", quote.Post.Content)
+	}
+
+	p, err := s.Post(authorCtx, original.Post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.RepostsCount != 3 {
+		t.Errorf("reposts count = %d, want 3", p.RepostsCount)
+	}
+}
+
+func TestRepostErrors(t *testing.T) {
+	s := newTestService(t)
+	authorCtx, authorID := newTestUser(t, s, "author")
+	followerCtx, followerID := newTestUser(t, s, "follower")
+	blockedCtx, _ := newTestUser(t, s, "blocked")
+
+	p, err := s.CreatePost(authorCtx, "original", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.ToggleBlock(authorCtx, "blocked"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Repost(context.Background(), p.Post.ID, "")
+	assertError(t, err, ErrUnauthenticated)
+
+	_, err = s.Repost(followerCtx, p.Post.ID, strings.Repeat("a", 481))
+	assertError(t, err, ErrInvalidContent)
+
+	_, err = s.Repost(followerCtx, p.Post.ID+1000, "")
+	assertError(t, err, ErrPostNotFound)
+
+	// 차단한 사람의 게시물은 없는 것과 같음
+	_, err = s.Repost(blockedCtx, p.Post.ID, "")
+	assertError(t, err, ErrPostNotFound)
+
+	// 비공개 계정은 팔로워에게 보여도 공유 금지
+	if err = s.SetPrivate(authorCtx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Repost(followerCtx, p.Post.ID, "")
+	assertError(t, err, ErrPostNotFound)
+
+	if err = s.store.InsertFollow(context.Background(), followerID, authorID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Repost(followerCtx, p.Post.ID, "")
+	assertError(t, err, ErrForbiddenRepost)
+
+	if _, err = s.Repost(authorCtx, p.Post.ID, ""); err != nil {
+		t.Errorf("author repost of a private post: %v", err)
+	}
+}
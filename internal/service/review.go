@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidRating is used when the rating is out of 1..5.
+	ErrInvalidRating = newFieldError(http.StatusUnprocessableEntity, "invalid_rating", "invalid rating", "rating")
+	// ErrNotPurchased is used when reviewing a post the user did not order.
+	ErrNotPurchased = newError(http.StatusForbidden, "not_purchased", "not purchased")
+	// ErrAlreadyReviewed is used when the user already reviewed the post.
+	ErrAlreadyReviewed = newError(http.StatusConflict, "already_reviewed", "already reviewed")
+	// ErrReviewNotFound denotes that the review was not found.
+	ErrReviewNotFound = newError(http.StatusNotFound, "review_not_found", "review not found")
+)
+
+// 구매한 게시물에 남기는 후기
+// Review model
+type Review struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	PostID    int64     `json:"-"`
+	Rating    int       `json:"rating"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	User      *User     `json:"user,omitempty"`
+	Mine      bool      `json:"mine"`
+}
+
+// 주문한 게시물에만 한 번
+// CreateReview of a post the authenticated user ordered.
+func (s *Service) CreateReview(ctx context.Context, postID int64, rating int, content string) (Review, error) {
+	var r Review
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return r, ErrUnauthenticated
+	}
+
+	if rating < 1 || rating > 5 {
+		return r, ErrInvalidRating
+	}
+
+	content = strings.TrimSpace(content)
+	if len([]rune(content)) > 480 {
+		return r, ErrInvalidContent
+	}
+
+	err := s.store.Tx(ctx, func(st Store) error {
+		if _, err := st.Post(ctx, uid, postID); err != nil {
+			return err
+		}
+
+		purchased, err := st.Purchased(ctx, uid, postID)
+		if err != nil {
+			return err
+		}
+
+		if !purchased {
+			return ErrNotPurchased
+		}
+
+		r = Review{UserID: uid, PostID: postID, Rating: rating, Content: content, Mine: true}
+		return st.InsertReview(ctx, &r)
+	})
+	if err != nil {
+		return Review{}, err
+	}
+
+	return r, nil
+}
+
+// Reviews of a post in descending order with backward pagination.
+func (s *Service) Reviews(ctx context.Context, postID int64, last int, before int64) ([]Review, error) {
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
+	if _, err := s.store.Post(ctx, uid, postID); err != nil {
+		return nil, err
+	}
+
+	rr, err := s.store.Reviews(ctx, uid, postID, normailizePageSize(last), before)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rr {
+		s.userAvatar(rr[i].User)
+	}
+	return rr, nil
+}
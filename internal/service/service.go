@@ -1,23 +1,65 @@
 package service
 
 import (
-	"database/sql"
-
 	"github.com/hako/branca"
 )
 
 // 서비스 핵심 로직. REST, GraphQL, RPC API 등 원하는거 사용
 type Service struct {
-	db     *sql.DB
+	store  Store
 	codec  *branca.Branca
 	origin string
+	blobs  BlobStore
+
+	avatarWebP    bool
+	filter        *ContentFilter
+	pullThreshold int
+
+	subs       subscriptions
+	jobWake    map[string]chan struct{}
+	outboxWake chan struct{}
+}
+
+// 선택 옵션
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// WithAvatarWebP stores avatars as lossless WebP instead of png or jpeg.
+func WithAvatarWebP() Option {
+	return func(s *Service) {
+		s.avatarWebP = true
+	}
 }
 
-//DB와 Codec 생성자
-func New(db *sql.DB, codec *branca.Branca, origin string) *Service {
-	return &Service{
-		db:     db,
+// 기본값, 팔로워가 이 이상이면 게시물을 팔로워 타임라인에 넣지 않음
+// DefaultPullThreshold of followers from which posts are merged into timelines at read time.
+const DefaultPullThreshold = 10000
+
+// WithPullThreshold sets the follower count from which posts are not fanned out
+// but merged into the followers' timelines at read time. 0 always fans out.
+func WithPullThreshold(n int) Option {
+	return func(s *Service) {
+		s.pullThreshold = n
+	}
+}
+
+//저장소와 Codec 생성자
+func New(store Store, codec *branca.Branca, origin string, blobs BlobStore, opts ...Option) *Service {
+	s := &Service{
+		store:  store,
 		codec:  codec,
 		origin: origin,
+		blobs:  blobs,
+
+		pullThreshold: DefaultPullThreshold,
+		jobWake:       map[string]chan struct{}{},
+		outboxWake:    make(chan struct{}, 1),
+	}
+	for kind := range s.jobHandlers() {
+		s.jobWake[kind] = make(chan struct{}, 1)
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
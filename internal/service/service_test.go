@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/hako/branca"
+)
+
+// 메모리 저장소로 만든 테스트용 서비스
+func newTestService(t testing.TB, opts ...Option) *Service {
+	t.Helper()
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	blobs := NewLocalBlobStore(t.TempDir(), "http://localhost:3000/img")
+	return New(NewMemoryStore(), codec, "http://localhost:3000", blobs, opts...)
+}
+
+// 사용자를 만들고 그 사용자로 로그인한 context
+func newTestUser(t testing.TB, s *Service, username string) (context.Context, int64) {
+	t.Helper()
+	ctx := context.Background()
+	if err := s.CreateUser(ctx, username+"@example.org", username); err != nil {
+		t.Fatalf("could not create user %s: %v", username, err)
+	}
+
+	out, err := s.Login(ctx, username+"@example.org")
+	if err != nil {
+		t.Fatalf("could not login %s: %v", username, err)
+	}
+
+	return context.WithValue(ctx, KeyAuthUserID, out.AuthUser.ID), out.AuthUser.ID
+}
+
+// 대기 중인 작업을 모두 실행
+func runTestJobs(t testing.TB, s *Service) {
+	t.Helper()
+	ctx := context.Background()
+	for ran := true; ran; {
+		ran = false
+		for kind, h := range s.jobHandlers() {
+			j, ok, err := s.store.ClaimJob(ctx, kind, jobLease)
+			if err != nil {
+				t.Fatalf("could not claim %s job: %v", kind, err)
+			}
+
+			if ok {
+				s.runJob(j, h)
+				ran = true
+			}
+		}
+	}
+}
+
+// 관리자 권한은 API로 줄 수 없어서 저장소에 직접
+func setTestAdmin(t testing.TB, s *Service, userID int64) {
+	t.Helper()
+	m := s.store.(*memoryStore)
+	defer m.lock()()
+
+	u, ok := m.users[userID]
+	if !ok {
+		t.Fatalf("no user %d", userID)
+	}
+	u.admin = true
+	m.users[userID] = u
+}
+
+// 감싼 오류도 같은 *Error면 통과
+func assertError(t testing.TB, err error, want *Error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+}
+
+// expvar 맵의 카운터 값, 없으면 0
+func metricValue(m *expvar.Map, key string) int64 {
+	if v, ok := m.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
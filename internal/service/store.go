@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// 서비스가 사용하는 저장소. SQL은 저장소 구현 안에만 있음
+// Store is the persistence Service depends on.
+// NewPGStore keeps the data in Postgres/CockroachDB and NewMemoryStore keeps it in memory.
+//
+// Methods taking a viewerID apply the visibility rules for that user:
+// hidden content, private accounts and blocks. 0 means an anonymous viewer.
+type Store interface {
+	UserStore
+	FollowStore
+	BlockStore
+	PostStore
+	TimelineStore
+	CommentStore
+	NotificationStore
+	ReportStore
+	OrderStore
+	ReviewStore
+	JobStore
+	OutboxStore
+
+	// Tx runs fn in a transaction, every call inside fn must go through the given Store.
+	// Nested calls reuse the outer transaction.
+	Tx(ctx context.Context, fn func(Store) error) error
+
+	// RepeatedContent counts the rows of table ("posts" or "comments") from the user
+	// with the same content created inside window.
+	RepeatedContent(ctx context.Context, table string, userID int64, content string, window time.Duration) (int, error)
+}
+
+// 유저 상태
+// UserFlags of an account. The zero value is returned for missing users.
+type UserFlags struct {
+	Private   bool
+	Admin     bool
+	Suspended bool
+}
+
+// UserStore keeps the accounts.
+type UserStore interface {
+	// InsertUser returns ErrEmailTaken or ErrUsernameTaken on duplicates.
+	InsertUser(ctx context.Context, email, username string) error
+	// UserByID returns ErrUserNotFound when missing.
+	UserByID(ctx context.Context, id int64) (User, error)
+	// UserByEmail returns ErrUserNotFound when missing.
+	UserByEmail(ctx context.Context, email string) (User, error)
+	// UserIDByUsername returns ErrUserNotFound when missing.
+	UserIDByUsername(ctx context.Context, username string) (int64, error)
+	UsersByIDs(ctx context.Context, ids []int64) (map[int64]User, error)
+	UserFlags(ctx context.Context, id int64) (UserFlags, error)
+	// UserProfile returns ErrUserNotFound when missing or blocked.
+	UserProfile(ctx context.Context, viewerID int64, username string) (UserProfile, error)
+	Users(ctx context.Context, viewerID int64, search string, first int, after string) ([]UserProfile, error)
+	// SetAvatar returns the previous avatar name, empty if there was none.
+	SetAvatar(ctx context.Context, userID int64, avatar string) (string, error)
+	SetPrivate(ctx context.Context, userID int64, private bool) error
+	SetSuspended(ctx context.Context, userID int64, suspended bool) error
+}
+
+// FollowStore keeps the follows and the follow requests to private accounts.
+type FollowStore interface {
+	Followers(ctx context.Context, viewerID int64, username string, first int, after string) ([]UserProfile, error)
+	Followees(ctx context.Context, viewerID int64, username string, first int, after string) ([]UserProfile, error)
+	Following(ctx context.Context, followerID, followeeID int64) (bool, error)
+	// InsertFollow and DeleteFollow keep the followers and followees counts.
+	InsertFollow(ctx context.Context, followerID, followeeID int64) error
+	// DeleteFollow reports false when there was no follow.
+	DeleteFollow(ctx context.Context, followerID, followeeID int64) (bool, error)
+	FollowersCount(ctx context.Context, userID int64) (int, error)
+
+	FollowRequested(ctx context.Context, followerID, followeeID int64) (bool, error)
+	InsertFollowRequest(ctx context.Context, followerID, followeeID int64) error
+	DeleteFollowRequest(ctx context.Context, followerID, followeeID int64) error
+	FollowRequesterIDs(ctx context.Context, followeeID int64) ([]int64, error)
+	FollowRequests(ctx context.Context, followeeID int64, first int, after string) ([]UserProfile, error)
+}
+
+// BlockStore keeps the blocks and mutes.
+type BlockStore interface {
+	// Blocked reports whether any of the two blocked the other.
+	Blocked(ctx context.Context, a, b int64) (bool, error)
+	Blocking(ctx context.Context, blockerID, blockedID int64) (bool, error)
+	InsertBlock(ctx context.Context, blockerID, blockedID int64) error
+	DeleteBlock(ctx context.Context, blockerID, blockedID int64) error
+
+	Muting(ctx context.Context, muterID, mutedID int64) (bool, error)
+	InsertMute(ctx context.Context, muterID, mutedID int64) error
+	DeleteMute(ctx context.Context, muterID, mutedID int64) error
+	MuterIDs(ctx context.Context, mutedID int64) (map[int64]bool, error)
+}
+
+// PostStore keeps the posts, their media and likes.
+type PostStore interface {
+	// InsertPost saves UserID, Content, SpoilerOf, NSFW and RepostOfID and sets ID and CreatedAt.
+	// A second plain repost of the same post by the same user is ErrAlreadyReposted.
+	InsertPost(ctx context.Context, p *Post) error
+	InsertPostMedia(ctx context.Context, postID int64, files []mediaFile) error
+	// PostByID without visibility rules, only ID, UserID, Content and RepostOfID are set.
+	// Returns ErrPostNotFound when missing.
+	PostByID(ctx context.Context, id int64) (Post, error)
+	// Post returns ErrPostNotFound when missing or not visible.
+	Post(ctx context.Context, viewerID, id int64) (Post, error)
+	Posts(ctx context.Context, viewerID int64, username string, last int, before int64) ([]Post, error)
+	PostsByIDs(ctx context.Context, viewerID int64, ids []int64) (map[int64]Post, error)
+	PostsMedia(ctx context.Context, ids []int64) (map[int64][]mediaFile, error)
+	// Reposted reports whether the user already shared the post without content.
+	Reposted(ctx context.Context, userID, postID int64) (bool, error)
+	AddRepostsCount(ctx context.Context, postID int64, n int) error
+	AddCommentsCount(ctx context.Context, postID int64, n int) error
+	SetPostHidden(ctx context.Context, postID int64, hidden bool) error
+
+	PostLiked(ctx context.Context, userID, postID int64) (bool, error)
+	// PostLikesCount returns ErrPostNotFound when the post is missing.
+	PostLikesCount(ctx context.Context, postID int64) (int, error)
+	// InsertPostLike returns the new likes count or ErrPostNotFound.
+	InsertPostLike(ctx context.Context, userID, postID int64) (int, error)
+	// DeletePostLike returns the new likes count.
+	DeletePostLike(ctx context.Context, userID, postID int64) (int, error)
+}
+
+// TimelineStore keeps the timeline items of each user.
+type TimelineStore interface {
+	// InsertTimelineItem adds the post to the timeline of the user and returns the item ID.
+	InsertTimelineItem(ctx context.Context, userID, postID int64) (int64, error)
+	// TimelineItemID returns the ID of the item of the post in the timeline of the user
+	// or 0 when there is none.
+	TimelineItemID(ctx context.Context, userID, postID int64) (int64, error)
+	// FanoutPost adds the post to the timeline of every follower of the author
+	// and returns the new items with ID, UserID and PostID.
+	// Followers who already have the post are skipped, so it can run again.
+	FanoutPost(ctx context.Context, postID, authorID int64) ([]TimelineItem, error)
+	// SetPostPulled marks a post that was not fanned out.
+	// Timeline reads it from the author instead.
+	SetPostPulled(ctx context.Context, postID int64) error
+	// Timeline leaves out hidden posts, blocked and muted authors.
+	// Pulled posts of followees are merged in with the ID of the item of the post
+	// in the timeline of its author, so pushed and pulled items share the timeline
+	// IDs and one cursor space.
+	// Items are ordered by the post (created_at, id), newest first, and before is
+	// the ID of an item of the user, read as the position of its post.
+	// An unknown before cursor gives an empty page.
+	Timeline(ctx context.Context, userID int64, last int, before int64) ([]TimelineItem, error)
+}
+
+// CommentStore keeps the comments and their likes.
+type CommentStore interface {
+	// InsertComment saves UserID, PostID, ParentID and Content and sets ID and CreatedAt.
+	// Returns ErrPostNotFound when the post is missing.
+	InsertComment(ctx context.Context, c *Comment) error
+	// CommentByID without visibility rules, only ID, UserID, PostID, ParentID,
+	// RepliesCount and Deleted are set. Returns ErrCommentNotFound when missing.
+	CommentByID(ctx context.Context, id int64) (Comment, error)
+	// Comment is CommentByID for a comment viewerID can see on a post viewerID can see.
+	Comment(ctx context.Context, viewerID, id int64) (Comment, error)
+	Comments(ctx context.Context, viewerID, postID int64, last int, before int64) ([]Comment, error)
+	// PostsComments is Comments of several posts at once, keyed by the post ID.
+	PostsComments(ctx context.Context, viewerID int64, postIDs []int64, last int, before int64) (map[int64][]Comment, error)
+	Replies(ctx context.Context, viewerID, commentID int64, last int, before int64) ([]Comment, error)
+	AddRepliesCount(ctx context.Context, commentID int64, n int) error
+	// TombstoneComment clears the content and marks the comment as deleted.
+	TombstoneComment(ctx context.Context, commentID int64) error
+	// DeleteComment removes the comment with its likes.
+	DeleteComment(ctx context.Context, commentID int64) error
+	SetCommentHidden(ctx context.Context, commentID int64, hidden bool) error
+
+	CommentLiked(ctx context.Context, userID, commentID int64) (bool, error)
+	// CommentLikesCount returns ErrCommentNotFound when the comment is missing.
+	CommentLikesCount(ctx context.Context, commentID int64) (int, error)
+	// InsertCommentLike returns the new likes count or ErrCommentNotFound.
+	InsertCommentLike(ctx context.Context, userID, commentID int64) (int, error)
+	// DeleteCommentLike returns the new likes count.
+	DeleteCommentLike(ctx context.Context, userID, commentID int64) (int, error)
+}
+
+// NotificationStore keeps the notifications.
+type NotificationStore interface {
+	Notifications(ctx context.Context, userID int64, last int, before int64) ([]Notification, error)
+	MarkNotificationAsRead(ctx context.Context, userID, notificationID int64) error
+	MarkNotificationsAsRead(ctx context.Context, userID int64) error
+	// FollowNotified reports whether the user has a follow notification with the actor.
+	FollowNotified(ctx context.Context, userID int64, actor string) (bool, error)
+	// UnreadNotificationID of the given type and post, 0 when there is none.
+	UnreadNotificationID(ctx context.Context, userID int64, typ string, postID *int64) (int64, error)
+	// InsertNotification saves UserID, Actors, Type and PostID and sets ID and IssuedAt.
+	InsertNotification(ctx context.Context, n *Notification) error
+	// AddNotificationActor moves the actor to the front of the notification
+	// and sets the new Actors and IssuedAt on n.
+	AddNotificationActor(ctx context.Context, n *Notification, actor string) error
+	// DropNotificationActor removes the actor from the notifications of the user
+	// and deletes the ones left without actors.
+	DropNotificationActor(ctx context.Context, userID, actorID int64) error
+}
+
+// ReportStore keeps the reports and the moderation log.
+type ReportStore interface {
+	// InsertReport saves TargetType, TargetID, Reason and Note and sets ID, Status and CreatedAt.
+	// reporterID 0 is used by the content filter. Returns ErrAlreadyReported on duplicates.
+	InsertReport(ctx context.Context, reporterID int64, r *Report) error
+	Reports(ctx context.Context, status string, last int, before int64) ([]Report, error)
+	// Report returns ErrReportNotFound when missing.
+	Report(ctx context.Context, id int64) (Report, error)
+	// SetReportStatus of a single report.
+	SetReportStatus(ctx context.Context, reportID int64, status string) error
+	// CloseReports sets the status of the report and every open report of the same target.
+	CloseReports(ctx context.Context, reportID int64, targetType string, targetID int64, status string) error
+	// LastModerationAction applied to the target, hide or suspend.
+	// Empty when there is none or it was restored since.
+	LastModerationAction(ctx context.Context, targetType string, targetID int64) (string, error)
+	InsertModerationAction(ctx context.Context, a ModerationAction) error
+}
+
+// OrderStore keeps the shopping basket and the buy and sell records.
+type OrderStore interface {
+	// AddBasketItem adds quantity to the basket row of the post, creating it, and returns the row.
+	AddBasketItem(ctx context.Context, userID, postID int64, quantity int) (BasketItem, error)
+	BasketItems(ctx context.Context, userID int64) ([]BasketItem, error)
+	// DeleteBasketItem returns ErrBasketItemNotFound when the user has no such item.
+	DeleteBasketItem(ctx context.Context, userID, itemID int64) error
+	// PlaceOrder moves the basket of the user into buy records, and sell records
+	// for the authors of the posts, under a new order number.
+	PlaceOrder(ctx context.Context, userID int64) (Order, error)
+	// OrderItems are the buy records of the user, or the sell records with sales, newest first.
+	OrderItems(ctx context.Context, userID int64, sales bool, last int, before int64) ([]OrderItem, error)
+}
+
+// ReviewStore keeps the reviews of ordered posts.
+type ReviewStore interface {
+	// Purchased reports whether the user has a buy record of the post.
+	Purchased(ctx context.Context, userID, postID int64) (bool, error)
+	// InsertReview saves UserID, PostID, Rating and Content and sets ID and CreatedAt.
+	// Returns ErrAlreadyReviewed on duplicates.
+	InsertReview(ctx context.Context, r *Review) error
+	// ReviewByID without visibility rules, only ID, UserID and PostID are set.
+	// Returns ErrReviewNotFound when missing.
+	ReviewByID(ctx context.Context, id int64) (Review, error)
+	// Review is ReviewByID for a review viewerID can see on a post viewerID can see.
+	Review(ctx context.Context, viewerID, id int64) (Review, error)
+	Reviews(ctx context.Context, viewerID, postID int64, last int, before int64) ([]Review, error)
+	SetReviewHidden(ctx context.Context, reviewID int64, hidden bool) error
+}
+
+// JobStore keeps the background job queue.
+type JobStore interface {
+	// InsertJob saves Kind, Payload and MaxAttempts and sets ID.
+	InsertJob(ctx context.Context, j *Job) error
+	// ClaimJob locks the oldest ready job of the kind for lease and counts the attempt.
+	// Reports false when there is none.
+	ClaimJob(ctx context.Context, kind string, lease time.Duration) (Job, bool, error)
+	// DeleteJob removes a finished job.
+	DeleteJob(ctx context.Context, id int64) error
+	// RetryJob unlocks the job to run again after delay.
+	RetryJob(ctx context.Context, id int64, delay time.Duration, lastError string) error
+	// BuryJob marks the job as dead, it is kept but never claimed again.
+	BuryJob(ctx context.Context, id int64, lastError string) error
+}
+
+// OutboxStore keeps the events waiting to be published.
+type OutboxStore interface {
+	// InsertEvent saves Type and Payload and sets ID and OccurredAt.
+	InsertEvent(ctx context.Context, e *Event) error
+	// ClaimEvents locks up to limit unlocked events for lease, oldest first.
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]Event, error)
+	// DeleteEvents removes published events.
+	DeleteEvents(ctx context.Context, ids []int64) error
+	// UnlockEvents lets claimed events be claimed again before the lease ends.
+	UnlockEvents(ctx context.Context, ids []int64) error
+}
+
+// 관리자 처리 기록
+// ModerationAction taken by an admin on a report.
+type ModerationAction struct {
+	ModeratorID int64
+	ReportID    int64
+	Action      string
+	TargetType  string
+	TargetID    int64
+	Note        string
+}
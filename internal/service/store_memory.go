@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 메모리 저장소. 테스트나 DB 없이 실행할 때 사용
+// 트랜잭션은 전체 락으로 처리하고 실패하면 복사본으로 되돌림
+type memoryStore struct {
+	*memoryData
+	tx bool
+}
+
+type memoryData struct {
+	mu sync.Mutex
+	memoryTables
+}
+
+type memoryTables struct {
+	seq int64
+
+	users          map[int64]memoryUser
+	follows        map[memoryPair]bool
+	followRequests map[memoryPair]bool
+	blocks         map[memoryPair]bool
+	mutes          map[memoryPair]bool
+
+	posts        map[int64]memoryPost
+	postMedia    map[int64][]mediaFile
+	postLikes    map[memoryPair]bool
+	timeline     map[int64]memoryTimelineItem
+	comments     map[int64]memoryComment
+	commentLikes map[memoryPair]bool
+
+	notifications map[int64]Notification
+	reports       map[int64]memoryReport
+	moderation    []ModerationAction
+
+	basket      map[int64]memoryBasketItem
+	orderRecord map[int64]memoryOrderItem
+	orderSeq    int64
+	reviews     map[int64]memoryReview
+
+	jobs   map[int64]memoryJob
+	outbox map[int64]memoryEvent
+}
+
+// (follower, followee), (user, post) 같은 관계 키
+type memoryPair struct {
+	a, b int64
+}
+
+type memoryUser struct {
+	id        int64
+	email     string
+	username  string
+	avatar    string
+	private   bool
+	admin     bool
+	suspended bool
+}
+
+type memoryPost struct {
+	id            int64
+	userID        int64
+	content       string
+	spoilerOf     *string
+	nsfw          bool
+	commentsCount int
+	repostsCount  int
+	repostOfID    *int64
+	hidden        bool
+	pulled        bool
+	createdAt     time.Time
+}
+
+type memoryTimelineItem struct {
+	id, userID, postID int64
+}
+
+type memoryComment struct {
+	id           int64
+	userID       int64
+	postID       int64
+	parentID     *int64
+	content      string
+	repliesCount int
+	deleted      bool
+	hidden       bool
+	createdAt    time.Time
+}
+
+type memoryReport struct {
+	Report
+	reporterID int64
+}
+
+type memoryBasketItem struct {
+	BasketItem
+	userID int64
+}
+
+// 구매와 판매 기록을 한 곳에, sale이면 userID는 판매자
+type memoryOrderItem struct {
+	OrderItem
+	userID int64
+	sale   bool
+}
+
+type memoryReview struct {
+	Review
+	hidden bool
+}
+
+type memoryJob struct {
+	Job
+	lastError   string
+	runAt       time.Time
+	lockedUntil time.Time
+	dead        bool
+}
+
+type memoryEvent struct {
+	Event
+	lockedUntil time.Time
+}
+
+// NewMemoryStore keeps the data in memory, for tests and running without a database.
+// Transactions hold a single lock and roll back to a copy of the data.
+func NewMemoryStore() Store {
+	return &memoryStore{memoryData: &memoryData{memoryTables: memoryTables{
+		users:          map[int64]memoryUser{},
+		follows:        map[memoryPair]bool{},
+		followRequests: map[memoryPair]bool{},
+		blocks:         map[memoryPair]bool{},
+		mutes:          map[memoryPair]bool{},
+		posts:          map[int64]memoryPost{},
+		postMedia:      map[int64][]mediaFile{},
+		postLikes:      map[memoryPair]bool{},
+		timeline:       map[int64]memoryTimelineItem{},
+		comments:       map[int64]memoryComment{},
+		commentLikes:   map[memoryPair]bool{},
+		notifications:  map[int64]Notification{},
+		reports:        map[int64]memoryReport{},
+		basket:         map[int64]memoryBasketItem{},
+		orderRecord:    map[int64]memoryOrderItem{},
+		reviews:        map[int64]memoryReview{},
+		jobs:           map[int64]memoryJob{},
+		outbox:         map[int64]memoryEvent{},
+	}}}
+}
+
+func (m *memoryStore) Tx(ctx context.Context, fn func(Store) error) error {
+	if m.tx {
+		return fn(m)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backup := m.memoryTables.clone()
+	if err := fn(&memoryStore{memoryData: m.memoryData, tx: true}); err != nil {
+		m.memoryTables = backup
+		return err
+	}
+
+	return nil
+}
+
+// 트랜잭션 안에서는 이미 락을 잡고 있음
+func (m *memoryStore) lock() func() {
+	if m.tx {
+		return func() {}
+	}
+
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func (m *memoryStore) nextID() int64 {
+	m.seq++
+	return m.seq
+}
+
+// 롤백용 복사본
+func (t memoryTables) clone() memoryTables {
+	c := t
+	c.users = make(map[int64]memoryUser, len(t.users))
+	for id, u := range t.users {
+		c.users[id] = u
+	}
+	c.follows = copyPairs(t.follows)
+	c.followRequests = copyPairs(t.followRequests)
+	c.blocks = copyPairs(t.blocks)
+	c.mutes = copyPairs(t.mutes)
+	c.posts = make(map[int64]memoryPost, len(t.posts))
+	for id, p := range t.posts {
+		c.posts[id] = p
+	}
+	c.postMedia = make(map[int64][]mediaFile, len(t.postMedia))
+	for id, mm := range t.postMedia {
+		c.postMedia[id] = mm
+	}
+	c.postLikes = copyPairs(t.postLikes)
+	c.timeline = make(map[int64]memoryTimelineItem, len(t.timeline))
+	for id, ti := range t.timeline {
+		c.timeline[id] = ti
+	}
+	c.comments = make(map[int64]memoryComment, len(t.comments))
+	for id, cm := range t.comments {
+		c.comments[id] = cm
+	}
+	c.commentLikes = copyPairs(t.commentLikes)
+	c.notifications = make(map[int64]Notification, len(t.notifications))
+	for id, n := range t.notifications {
+		n.Actors = append([]string(nil), n.Actors...)
+		c.notifications[id] = n
+	}
+	c.reports = make(map[int64]memoryReport, len(t.reports))
+	for id, r := range t.reports {
+		c.reports[id] = r
+	}
+	c.moderation = append([]ModerationAction(nil), t.moderation...)
+	c.basket = make(map[int64]memoryBasketItem, len(t.basket))
+	for id, b := range t.basket {
+		c.basket[id] = b
+	}
+	c.orderRecord = make(map[int64]memoryOrderItem, len(t.orderRecord))
+	for id, o := range t.orderRecord {
+		c.orderRecord[id] = o
+	}
+	c.reviews = make(map[int64]memoryReview, len(t.reviews))
+	for id, r := range t.reviews {
+		c.reviews[id] = r
+	}
+	c.jobs = make(map[int64]memoryJob, len(t.jobs))
+	for id, j := range t.jobs {
+		c.jobs[id] = j
+	}
+	c.outbox = make(map[int64]memoryEvent, len(t.outbox))
+	for id, e := range t.outbox {
+		c.outbox[id] = e
+	}
+	return c
+}
+
+func copyPairs(m map[memoryPair]bool) map[memoryPair]bool {
+	c := make(map[memoryPair]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func (m *memoryStore) RepeatedContent(ctx context.Context, table string, userID int64, content string, window time.Duration) (int, error) {
+	defer m.lock()()
+
+	since := time.Now().Add(-window)
+	n := 0
+	if table == "comments" {
+		for _, c := range m.comments {
+			if c.userID == userID && c.content == content && c.createdAt.After(since) {
+				n++
+			}
+		}
+		return n, nil
+	}
+
+	for _, p := range m.posts {
+		if p.userID == userID && p.content == content && p.createdAt.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// 두 사람 중 한 명이라도 차단했는지
+func (m *memoryStore) blocked(a, b int64) bool {
+	return m.blocks[memoryPair{a, b}] || m.blocks[memoryPair{b, a}]
+}
+
+func (m *memoryStore) isAdmin(id int64) bool {
+	return m.users[id].admin
+}
+
+// 관계 수 (팔로워, 좋아요)
+func countPairs(pairs map[memoryPair]bool, match func(memoryPair) bool) int {
+	n := 0
+	for p := range pairs {
+		if match(p) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func (m *memoryStore) InsertComment(ctx context.Context, c *Comment) error {
+	defer m.lock()()
+
+	if _, ok := m.posts[c.PostID]; !ok {
+		return ErrPostNotFound
+	}
+
+	c.ID = m.nextID()
+	c.CreatedAt = time.Now()
+	m.comments[c.ID] = memoryComment{
+		id:        c.ID,
+		userID:    c.UserID,
+		postID:    c.PostID,
+		parentID:  c.ParentID,
+		content:   c.Content,
+		createdAt: c.CreatedAt,
+	}
+	return nil
+}
+
+func (m *memoryStore) CommentByID(ctx context.Context, id int64) (Comment, error) {
+	defer m.lock()()
+
+	c, ok := m.comments[id]
+	if !ok {
+		return Comment{}, ErrCommentNotFound
+	}
+
+	return Comment{
+		ID:           c.id,
+		UserID:       c.userID,
+		PostID:       c.postID,
+		ParentID:     c.parentID,
+		RepliesCount: c.repliesCount,
+		Deleted:      c.deleted,
+	}, nil
+}
+
+func (m *memoryStore) Comment(ctx context.Context, viewerID, id int64) (Comment, error) {
+	defer m.lock()()
+
+	c, ok := m.comments[id]
+	if !ok || !m.postVisible(viewerID, m.posts[c.postID]) {
+		return Comment{}, ErrCommentNotFound
+	}
+
+	if viewerID != 0 && m.blocked(viewerID, c.userID) {
+		return Comment{}, ErrCommentNotFound
+	}
+
+	if c.hidden && c.userID != viewerID && !m.isAdmin(viewerID) {
+		return Comment{}, ErrCommentNotFound
+	}
+
+	return Comment{
+		ID:           c.id,
+		UserID:       c.userID,
+		PostID:       c.postID,
+		ParentID:     c.parentID,
+		RepliesCount: c.repliesCount,
+		Deleted:      c.deleted,
+	}, nil
+}
+
+func (m *memoryStore) Comments(ctx context.Context, viewerID, postID int64, last int, before int64) ([]Comment, error) {
+	defer m.lock()()
+
+	return m.commentList(viewerID, last, before, func(c memoryComment) bool {
+		return c.postID == postID && c.parentID == nil
+	}), nil
+}
+
+func (m *memoryStore) PostsComments(ctx context.Context, viewerID int64, postIDs []int64, last int, before int64) (map[int64][]Comment, error) {
+	defer m.lock()()
+
+	cc := map[int64][]Comment{}
+	for _, postID := range postIDs {
+		postID := postID
+		cc[postID] = m.commentList(viewerID, last, before, func(c memoryComment) bool {
+			return c.postID == postID && c.parentID == nil
+		})
+	}
+	return cc, nil
+}
+
+func (m *memoryStore) Replies(ctx context.Context, viewerID, commentID int64, last int, before int64) ([]Comment, error) {
+	defer m.lock()()
+
+	return m.commentList(viewerID, last, before, func(c memoryComment) bool {
+		return c.parentID != nil && *c.parentID == commentID
+	}), nil
+}
+
+func (m *memoryStore) AddRepliesCount(ctx context.Context, commentID int64, n int) error {
+	defer m.lock()()
+
+	if c, ok := m.comments[commentID]; ok {
+		c.repliesCount += n
+		m.comments[commentID] = c
+	}
+	return nil
+}
+
+func (m *memoryStore) TombstoneComment(ctx context.Context, commentID int64) error {
+	defer m.lock()()
+
+	if c, ok := m.comments[commentID]; ok {
+		c.content = ""
+		c.deleted = true
+		m.comments[commentID] = c
+	}
+	return nil
+}
+
+func (m *memoryStore) DeleteComment(ctx context.Context, commentID int64) error {
+	defer m.lock()()
+
+	for l := range m.commentLikes {
+		if l.b == commentID {
+			delete(m.commentLikes, l)
+		}
+	}
+	delete(m.comments, commentID)
+	return nil
+}
+
+func (m *memoryStore) SetCommentHidden(ctx context.Context, commentID int64, hidden bool) error {
+	defer m.lock()()
+
+	if c, ok := m.comments[commentID]; ok {
+		c.hidden = hidden
+		m.comments[commentID] = c
+	}
+	return nil
+}
+
+func (m *memoryStore) CommentLiked(ctx context.Context, userID, commentID int64) (bool, error) {
+	defer m.lock()()
+	return m.commentLikes[memoryPair{userID, commentID}], nil
+}
+
+func (m *memoryStore) CommentLikesCount(ctx context.Context, commentID int64) (int, error) {
+	defer m.lock()()
+
+	if _, ok := m.comments[commentID]; !ok {
+		return 0, ErrCommentNotFound
+	}
+
+	return m.commentLikesCount(commentID), nil
+}
+
+func (m *memoryStore) InsertCommentLike(ctx context.Context, userID, commentID int64) (int, error) {
+	defer m.lock()()
+
+	if _, ok := m.comments[commentID]; !ok {
+		return 0, ErrCommentNotFound
+	}
+
+	m.commentLikes[memoryPair{userID, commentID}] = true
+	return m.commentLikesCount(commentID), nil
+}
+
+func (m *memoryStore) DeleteCommentLike(ctx context.Context, userID, commentID int64) (int, error) {
+	defer m.lock()()
+
+	delete(m.commentLikes, memoryPair{userID, commentID})
+	return m.commentLikesCount(commentID), nil
+}
+
+// 차단, 숨김을 거른 최신순 댓글
+func (m *memoryStore) commentList(viewerID int64, last int, before int64, match func(memoryComment) bool) []Comment {
+	var mc []memoryComment
+	for _, c := range m.comments {
+		if !match(c) || (before != 0 && c.id >= before) {
+			continue
+		}
+
+		if viewerID != 0 && (m.blocked(viewerID, c.userID) || m.blocked(viewerID, m.posts[c.postID].userID)) {
+			continue
+		}
+
+		if c.hidden && c.userID != viewerID && !m.isAdmin(viewerID) {
+			continue
+		}
+
+		mc = append(mc, c)
+	}
+
+	sort.Slice(mc, func(i, j int) bool {
+		if !mc[i].createdAt.Equal(mc[j].createdAt) {
+			return mc[i].createdAt.After(mc[j].createdAt)
+		}
+		return mc[i].id > mc[j].id
+	})
+
+	cc := make([]Comment, 0, last)
+	for _, c := range mc {
+		if len(cc) == last {
+			break
+		}
+
+		author := m.users[c.userID].user()
+		author.ID = 0
+		comment := Comment{
+			ID:           c.id,
+			PostID:       c.postID,
+			ParentID:     c.parentID,
+			Content:      c.content,
+			LikesCount:   m.commentLikesCount(c.id),
+			RepliesCount: c.repliesCount,
+			Deleted:      c.deleted,
+			CreatedAt:    c.createdAt,
+			User:         &author,
+		}
+		if viewerID != 0 {
+			comment.Mine = c.userID == viewerID
+			comment.Liked = m.commentLikes[memoryPair{viewerID, c.id}]
+		}
+		cc = append(cc, comment)
+	}
+	return cc
+}
+
+func (m *memoryStore) commentLikesCount(commentID int64) int {
+	return countPairs(m.commentLikes, func(l memoryPair) bool { return l.b == commentID })
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func (m *memoryStore) InsertEvent(ctx context.Context, e *Event) error {
+	defer m.lock()()
+
+	e.ID = m.nextID()
+	e.OccurredAt = time.Now()
+	m.outbox[e.ID] = memoryEvent{Event: *e}
+	return nil
+}
+
+func (m *memoryStore) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	defer m.lock()()
+
+	now := time.Now()
+	var ee []Event
+	for _, e := range m.outbox {
+		if !e.lockedUntil.After(now) {
+			ee = append(ee, e.Event)
+		}
+	}
+
+	sort.Slice(ee, func(i, j int) bool { return ee[i].ID < ee[j].ID })
+	if len(ee) > limit {
+		ee = ee[:limit]
+	}
+
+	for _, e := range ee {
+		m.outbox[e.ID] = memoryEvent{Event: e, lockedUntil: now.Add(lease)}
+	}
+	return ee, nil
+}
+
+func (m *memoryStore) DeleteEvents(ctx context.Context, ids []int64) error {
+	defer m.lock()()
+
+	for _, id := range ids {
+		delete(m.outbox, id)
+	}
+	return nil
+}
+
+func (m *memoryStore) UnlockEvents(ctx context.Context, ids []int64) error {
+	defer m.lock()()
+
+	for _, id := range ids {
+		if e, ok := m.outbox[id]; ok {
+			e.lockedUntil = time.Time{}
+			m.outbox[id] = e
+		}
+	}
+	return nil
+}
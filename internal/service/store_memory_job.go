@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+func (m *memoryStore) InsertJob(ctx context.Context, j *Job) error {
+	defer m.lock()()
+
+	j.ID = m.nextID()
+	j.CreatedAt = time.Now()
+	m.jobs[j.ID] = memoryJob{Job: *j, runAt: j.CreatedAt}
+	return nil
+}
+
+func (m *memoryStore) ClaimJob(ctx context.Context, kind string, lease time.Duration) (Job, bool, error) {
+	defer m.lock()()
+
+	now := time.Now()
+	var claimed *memoryJob
+	for _, j := range m.jobs {
+		if j.Kind != kind || j.dead || j.runAt.After(now) || j.lockedUntil.After(now) {
+			continue
+		}
+
+		if claimed == nil || j.runAt.Before(claimed.runAt) || (j.runAt.Equal(claimed.runAt) && j.ID < claimed.ID) {
+			j := j
+			claimed = &j
+		}
+	}
+
+	if claimed == nil {
+		return Job{}, false, nil
+	}
+
+	claimed.Attempts++
+	claimed.lockedUntil = now.Add(lease)
+	m.jobs[claimed.ID] = *claimed
+	return claimed.Job, true, nil
+}
+
+func (m *memoryStore) DeleteJob(ctx context.Context, id int64) error {
+	defer m.lock()()
+
+	delete(m.jobs, id)
+	return nil
+}
+
+func (m *memoryStore) RetryJob(ctx context.Context, id int64, delay time.Duration, lastError string) error {
+	defer m.lock()()
+
+	if j, ok := m.jobs[id]; ok {
+		j.lastError = lastError
+		j.runAt = time.Now().Add(delay)
+		j.lockedUntil = time.Time{}
+		m.jobs[id] = j
+	}
+	return nil
+}
+
+func (m *memoryStore) BuryJob(ctx context.Context, id int64, lastError string) error {
+	defer m.lock()()
+
+	if j, ok := m.jobs[id]; ok {
+		j.lastError = lastError
+		j.lockedUntil = time.Time{}
+		j.dead = true
+		m.jobs[id] = j
+	}
+	return nil
+}
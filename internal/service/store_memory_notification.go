@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func (m *memoryStore) Notifications(ctx context.Context, userID int64, last int, before int64) ([]Notification, error) {
+	defer m.lock()()
+
+	var nn []Notification
+	for _, n := range m.notifications {
+		if n.UserID == userID && (before == 0 || n.ID < before) {
+			n.Actors = append([]string(nil), n.Actors...)
+			nn = append(nn, n)
+		}
+	}
+
+	sort.Slice(nn, func(i, j int) bool {
+		if !nn[i].IssuedAt.Equal(nn[j].IssuedAt) {
+			return nn[i].IssuedAt.After(nn[j].IssuedAt)
+		}
+		return nn[i].ID > nn[j].ID
+	})
+
+	if len(nn) > last {
+		nn = nn[:last]
+	}
+
+	if nn == nil {
+		nn = []Notification{}
+	}
+	return nn, nil
+}
+
+func (m *memoryStore) MarkNotificationAsRead(ctx context.Context, userID, notificationID int64) error {
+	defer m.lock()()
+
+	if n, ok := m.notifications[notificationID]; ok && n.UserID == userID {
+		n.Read = true
+		m.notifications[notificationID] = n
+	}
+	return nil
+}
+
+func (m *memoryStore) MarkNotificationsAsRead(ctx context.Context, userID int64) error {
+	defer m.lock()()
+
+	for id, n := range m.notifications {
+		if n.UserID == userID {
+			n.Read = true
+			m.notifications[id] = n
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) FollowNotified(ctx context.Context, userID int64, actor string) (bool, error) {
+	defer m.lock()()
+
+	for _, n := range m.notifications {
+		if n.UserID == userID && n.Type == "follow" && containsString(n.Actors, actor) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryStore) UnreadNotificationID(ctx context.Context, userID int64, typ string, postID *int64) (int64, error) {
+	defer m.lock()()
+
+	for _, n := range m.notifications {
+		if n.UserID != userID || n.Type != typ || n.Read {
+			continue
+		}
+
+		if (n.PostID == nil) != (postID == nil) || (postID != nil && *n.PostID != *postID) {
+			continue
+		}
+
+		return n.ID, nil
+	}
+	return 0, nil
+}
+
+func (m *memoryStore) InsertNotification(ctx context.Context, n *Notification) error {
+	defer m.lock()()
+
+	n.ID = m.nextID()
+	n.IssuedAt = time.Now()
+	stored := *n
+	stored.Actors = append([]string(nil), n.Actors...)
+	m.notifications[n.ID] = stored
+	return nil
+}
+
+func (m *memoryStore) AddNotificationActor(ctx context.Context, n *Notification, actor string) error {
+	defer m.lock()()
+
+	stored, ok := m.notifications[n.ID]
+	if !ok {
+		return nil
+	}
+
+	actors := []string{actor}
+	for _, a := range stored.Actors {
+		if a != actor {
+			actors = append(actors, a)
+		}
+	}
+
+	stored.Actors = actors
+	stored.IssuedAt = time.Now()
+	m.notifications[n.ID] = stored
+
+	n.Actors = append([]string(nil), actors...)
+	n.IssuedAt = stored.IssuedAt
+	return nil
+}
+
+func (m *memoryStore) DropNotificationActor(ctx context.Context, userID, actorID int64) error {
+	defer m.lock()()
+
+	actor := m.users[actorID].username
+	for id, n := range m.notifications {
+		if n.UserID != userID {
+			continue
+		}
+
+		var actors []string
+		for _, a := range n.Actors {
+			if a != actor {
+				actors = append(actors, a)
+			}
+		}
+
+		if len(actors) == 0 {
+			delete(m.notifications, id)
+			continue
+		}
+
+		n.Actors = actors
+		m.notifications[id] = n
+	}
+	return nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"sort"
+)
+
+func (m *memoryStore) AddBasketItem(ctx context.Context, userID, postID int64, quantity int) (BasketItem, error) {
+	defer m.lock()()
+
+	for id, b := range m.basket {
+		if b.userID == userID && b.PostID == postID {
+			b.Quantity += quantity
+			m.basket[id] = b
+			return b.BasketItem, nil
+		}
+	}
+
+	b := memoryBasketItem{BasketItem: BasketItem{ID: m.nextID(), PostID: postID, Quantity: quantity}, userID: userID}
+	m.basket[b.ID] = b
+	return b.BasketItem, nil
+}
+
+func (m *memoryStore) BasketItems(ctx context.Context, userID int64) ([]BasketItem, error) {
+	defer m.lock()()
+	return m.basketItems(userID), nil
+}
+
+func (m *memoryStore) basketItems(userID int64) []BasketItem {
+	items := []BasketItem{}
+	for _, b := range m.basket {
+		if b.userID == userID {
+			items = append(items, b.BasketItem)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+func (m *memoryStore) DeleteBasketItem(ctx context.Context, userID, itemID int64) error {
+	defer m.lock()()
+
+	if b, ok := m.basket[itemID]; !ok || b.userID != userID {
+		return ErrBasketItemNotFound
+	}
+
+	delete(m.basket, itemID)
+	return nil
+}
+
+func (m *memoryStore) PlaceOrder(ctx context.Context, userID int64) (Order, error) {
+	defer m.lock()()
+
+	m.orderSeq++
+	o := Order{Num: m.orderSeq, Items: []OrderItem{}}
+	for _, b := range m.basketItems(userID) {
+		item := OrderItem{ID: m.nextID(), OrderNum: o.Num, PostID: b.PostID, Quantity: b.Quantity}
+		m.orderRecord[item.ID] = memoryOrderItem{OrderItem: item, userID: userID}
+		o.Items = append(o.Items, item)
+
+		sale := OrderItem{ID: m.nextID(), OrderNum: o.Num, PostID: b.PostID, Quantity: b.Quantity}
+		m.orderRecord[sale.ID] = memoryOrderItem{OrderItem: sale, userID: m.posts[b.PostID].userID, sale: true}
+		delete(m.basket, b.ID)
+	}
+
+	return o, nil
+}
+
+func (m *memoryStore) OrderItems(ctx context.Context, userID int64, sales bool, last int, before int64) ([]OrderItem, error) {
+	defer m.lock()()
+
+	items := []OrderItem{}
+	for _, o := range m.orderRecord {
+		if o.userID == userID && o.sale == sales && (before == 0 || o.ID < before) {
+			items = append(items, o.OrderItem)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	if len(items) > last {
+		items = items[:last]
+	}
+	return items, nil
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func (m *memoryStore) InsertPost(ctx context.Context, p *Post) error {
+	defer m.lock()()
+
+	// unique_reposts
+	if p.RepostOfID != nil && p.Content == "" {
+		for _, other := range m.posts {
+			if other.userID == p.UserID && other.repostOfID != nil && *other.repostOfID == *p.RepostOfID && other.content == "" {
+				return ErrAlreadyReposted
+			}
+		}
+	}
+
+	p.ID = m.nextID()
+	p.CreatedAt = time.Now()
+	m.posts[p.ID] = memoryPost{
+		id:         p.ID,
+		userID:     p.UserID,
+		content:    p.Content,
+		spoilerOf:  p.SpoilerOf,
+		nsfw:       p.NSFW,
+		repostOfID: p.RepostOfID,
+		createdAt:  p.CreatedAt,
+	}
+	return nil
+}
+
+func (m *memoryStore) InsertPostMedia(ctx context.Context, postID int64, files []mediaFile) error {
+	defer m.lock()()
+
+	if len(files) != 0 {
+		m.postMedia[postID] = append([]mediaFile(nil), files...)
+	}
+	return nil
+}
+
+func (m *memoryStore) PostByID(ctx context.Context, id int64) (Post, error) {
+	defer m.lock()()
+
+	p, ok := m.posts[id]
+	if !ok {
+		return Post{}, ErrPostNotFound
+	}
+
+	return Post{ID: p.id, UserID: p.userID, Content: p.content, RepostOfID: p.repostOfID}, nil
+}
+
+func (m *memoryStore) Post(ctx context.Context, viewerID, id int64) (Post, error) {
+	defer m.lock()()
+
+	p, ok := m.posts[id]
+	if !ok || !m.postVisible(viewerID, p) {
+		return Post{}, ErrPostNotFound
+	}
+
+	return m.post(viewerID, p), nil
+}
+
+func (m *memoryStore) Posts(ctx context.Context, viewerID int64, username string, last int, before int64) ([]Post, error) {
+	defer m.lock()()
+
+	author, ok := m.userByUsername(username)
+	if !ok {
+		return []Post{}, nil
+	}
+
+	var mp []memoryPost
+	for _, p := range m.posts {
+		if p.userID != author.id || !m.postVisible(viewerID, p) {
+			continue
+		}
+
+		if before != 0 && p.id >= before {
+			continue
+		}
+
+		mp = append(mp, p)
+	}
+
+	sortPosts(mp)
+
+	pp := make([]Post, 0, last)
+	for _, p := range mp {
+		if len(pp) == last {
+			break
+		}
+
+		post := m.post(viewerID, p)
+		post.UserID = 0
+		post.User = nil
+		pp = append(pp, post)
+	}
+	return pp, nil
+}
+
+func (m *memoryStore) PostsByIDs(ctx context.Context, viewerID int64, ids []int64) (map[int64]Post, error) {
+	defer m.lock()()
+
+	pp := map[int64]Post{}
+	for _, id := range ids {
+		if p, ok := m.posts[id]; ok && m.postVisible(viewerID, p) {
+			post := m.post(viewerID, p)
+			post.RepostOfID = nil
+			pp[id] = post
+		}
+	}
+	return pp, nil
+}
+
+func (m *memoryStore) PostsMedia(ctx context.Context, ids []int64) (map[int64][]mediaFile, error) {
+	defer m.lock()()
+
+	mm := map[int64][]mediaFile{}
+	for _, id := range ids {
+		if files, ok := m.postMedia[id]; ok {
+			mm[id] = files
+		}
+	}
+	return mm, nil
+}
+
+func (m *memoryStore) Reposted(ctx context.Context, userID, postID int64) (bool, error) {
+	defer m.lock()()
+
+	for _, p := range m.posts {
+		if p.userID == userID && p.repostOfID != nil && *p.repostOfID == postID && p.content == "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryStore) AddRepostsCount(ctx context.Context, postID int64, n int) error {
+	defer m.lock()()
+
+	if p, ok := m.posts[postID]; ok {
+		p.repostsCount += n
+		m.posts[postID] = p
+	}
+	return nil
+}
+
+func (m *memoryStore) AddCommentsCount(ctx context.Context, postID int64, n int) error {
+	defer m.lock()()
+
+	if p, ok := m.posts[postID]; ok {
+		p.commentsCount += n
+		m.posts[postID] = p
+	}
+	return nil
+}
+
+func (m *memoryStore) SetPostHidden(ctx context.Context, postID int64, hidden bool) error {
+	defer m.lock()()
+
+	if p, ok := m.posts[postID]; ok {
+		p.hidden = hidden
+		m.posts[postID] = p
+	}
+	return nil
+}
+
+func (m *memoryStore) PostLiked(ctx context.Context, userID, postID int64) (bool, error) {
+	defer m.lock()()
+	return m.postLikes[memoryPair{userID, postID}], nil
+}
+
+func (m *memoryStore) PostLikesCount(ctx context.Context, postID int64) (int, error) {
+	defer m.lock()()
+
+	if _, ok := m.posts[postID]; !ok {
+		return 0, ErrPostNotFound
+	}
+
+	return m.postLikesCount(postID), nil
+}
+
+func (m *memoryStore) InsertPostLike(ctx context.Context, userID, postID int64) (int, error) {
+	defer m.lock()()
+
+	if _, ok := m.posts[postID]; !ok {
+		return 0, ErrPostNotFound
+	}
+
+	m.postLikes[memoryPair{userID, postID}] = true
+	return m.postLikesCount(postID), nil
+}
+
+func (m *memoryStore) DeletePostLike(ctx context.Context, userID, postID int64) (int, error) {
+	defer m.lock()()
+
+	delete(m.postLikes, memoryPair{userID, postID})
+	return m.postLikesCount(postID), nil
+}
+
+func (m *memoryStore) InsertTimelineItem(ctx context.Context, userID, postID int64) (int64, error) {
+	defer m.lock()()
+	return m.insertTimelineItem(userID, postID), nil
+}
+
+func (m *memoryStore) TimelineItemID(ctx context.Context, userID, postID int64) (int64, error) {
+	defer m.lock()()
+	return m.timelineItemID(userID, postID), nil
+}
+
+func (m *memoryStore) SetPostPulled(ctx context.Context, postID int64) error {
+	defer m.lock()()
+
+	if p, ok := m.posts[postID]; ok {
+		p.pulled = true
+		m.posts[postID] = p
+	}
+	return nil
+}
+
+func (m *memoryStore) FanoutPost(ctx context.Context, postID, authorID int64) ([]TimelineItem, error) {
+	defer m.lock()()
+
+	var followerIDs []int64
+	for f := range m.follows {
+		if f.b == authorID {
+			followerIDs = append(followerIDs, f.a)
+		}
+	}
+
+	sort.Slice(followerIDs, func(i, j int) bool { return followerIDs[i] < followerIDs[j] })
+
+	tt := []TimelineItem{}
+	for _, followerID := range followerIDs {
+		if m.timelineItemID(followerID, postID) != 0 {
+			continue
+		}
+
+		id := m.insertTimelineItem(followerID, postID)
+		tt = append(tt, TimelineItem{ID: id, UserID: followerID, PostID: postID})
+	}
+	return tt, nil
+}
+
+func (m *memoryStore) Timeline(ctx context.Context, userID int64, last int, before int64) ([]TimelineItem, error) {
+	defer m.lock()()
+
+	type item struct {
+		memoryTimelineItem
+		post memoryPost
+	}
+
+	// 팔로우한 계정의 읽을 때 합치는 게시물은 작성자 타임라인의 항목 아이디를 씀
+	candidates := []memoryTimelineItem{}
+	for _, ti := range m.timeline {
+		if ti.userID == userID || m.pulledFor(userID, ti) {
+			candidates = append(candidates, ti)
+		}
+	}
+
+	// before 항목의 게시물 (created_at, id) 위치부터
+	var cursor *memoryPost
+	if before != 0 {
+		if ti, ok := m.timeline[before]; ok && (ti.userID == userID || m.pulledFor(userID, ti)) {
+			p := m.posts[ti.postID]
+			cursor = &p
+		}
+
+		if cursor == nil {
+			return []TimelineItem{}, nil
+		}
+	}
+
+	var ii []item
+	for _, ti := range candidates {
+		p := m.posts[ti.postID]
+		if cursor != nil && !memoryPostOlder(p, *cursor) {
+			continue
+		}
+
+		if p.hidden && p.userID != userID && !m.isAdmin(userID) {
+			continue
+		}
+
+		// 차단, 뮤트는 공유한 사람과 원본 작성자 모두 확인
+		authors := []int64{p.userID}
+		if p.repostOfID != nil {
+			if original, ok := m.posts[*p.repostOfID]; ok {
+				authors = append(authors, original.userID)
+			}
+		}
+
+		skip := false
+		for _, a := range authors {
+			if m.blocked(userID, a) || m.mutes[memoryPair{userID, a}] {
+				skip = true
+			}
+		}
+
+		if !skip {
+			ii = append(ii, item{ti, p})
+		}
+	}
+
+	sort.Slice(ii, func(i, j int) bool { return memoryPostOlder(ii[j].post, ii[i].post) })
+
+	tt := make([]TimelineItem, 0, last)
+	for _, it := range ii {
+		if len(tt) == last {
+			break
+		}
+
+		tt = append(tt, TimelineItem{
+			ID:     it.id,
+			UserID: userID,
+			PostID: it.post.id,
+			Post:   m.post(userID, it.post),
+		})
+	}
+	return tt, nil
+}
+
+// (created_at, id) 순서
+func memoryPostOlder(a, b memoryPost) bool {
+	if !a.createdAt.Equal(b.createdAt) {
+		return a.createdAt.Before(b.createdAt)
+	}
+	return a.id < b.id
+}
+
+// 작성자 타임라인에 있는 배포하지 않은 게시물이고 userID가 작성자를 팔로우
+func (m *memoryStore) pulledFor(userID int64, ti memoryTimelineItem) bool {
+	p, ok := m.posts[ti.postID]
+	return ok && p.pulled && ti.userID == p.userID && m.follows[memoryPair{userID, p.userID}]
+}
+
+// timeline (user_id, post_id)는 유니크
+func (m *memoryStore) timelineItemID(userID, postID int64) int64 {
+	for _, ti := range m.timeline {
+		if ti.userID == userID && ti.postID == postID {
+			return ti.id
+		}
+	}
+	return 0
+}
+
+func (m *memoryStore) insertTimelineItem(userID, postID int64) int64 {
+	if id := m.timelineItemID(userID, postID); id != 0 {
+		return id
+	}
+
+	id := m.nextID()
+	m.timeline[id] = memoryTimelineItem{id: id, userID: userID, postID: postID}
+	return id
+}
+
+// 숨김, 비공개 계정, 차단 확인
+func (m *memoryStore) postVisible(viewerID int64, p memoryPost) bool {
+	if viewerID != 0 && m.blocked(viewerID, p.userID) {
+		return false
+	}
+
+	if viewerID == p.userID && viewerID != 0 {
+		return true
+	}
+
+	if p.hidden && !(viewerID != 0 && m.isAdmin(viewerID)) {
+		return false
+	}
+
+	return !m.users[p.userID].private || (viewerID != 0 && m.follows[memoryPair{viewerID, p.userID}])
+}
+
+func (m *memoryStore) post(viewerID int64, p memoryPost) Post {
+	author := m.users[p.userID].user()
+	author.ID = 0
+	post := Post{
+		ID:            p.id,
+		UserID:        p.userID,
+		Content:       p.content,
+		SpoilerOf:     p.spoilerOf,
+		NSFW:          p.nsfw,
+		LikesCount:    m.postLikesCount(p.id),
+		CommentsCount: p.commentsCount,
+		RepostsCount:  p.repostsCount,
+		CreatedAt:     p.createdAt,
+		User:          &author,
+		RepostOfID:    p.repostOfID,
+	}
+	if viewerID != 0 {
+		post.Mine = p.userID == viewerID
+		post.Liked = m.postLikes[memoryPair{viewerID, p.id}]
+	}
+	return post
+}
+
+func (m *memoryStore) postLikesCount(postID int64) int {
+	return countPairs(m.postLikes, func(l memoryPair) bool { return l.b == postID })
+}
+
+// 최신순
+func sortPosts(pp []memoryPost) {
+	sort.Slice(pp, func(i, j int) bool {
+		if !pp[i].createdAt.Equal(pp[j].createdAt) {
+			return pp[i].createdAt.After(pp[j].createdAt)
+		}
+		return pp[i].id > pp[j].id
+	})
+}
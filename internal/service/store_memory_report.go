@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func (m *memoryStore) InsertReport(ctx context.Context, reporterID int64, r *Report) error {
+	defer m.lock()()
+
+	// 신고자가 없는 자동 필터 신고는 중복 검사 없음
+	if reporterID != 0 {
+		for _, existing := range m.reports {
+			if existing.reporterID == reporterID && existing.TargetType == r.TargetType && existing.TargetID == r.TargetID {
+				return ErrAlreadyReported
+			}
+		}
+	}
+
+	r.ID = m.nextID()
+	r.Status = "open"
+	r.CreatedAt = time.Now()
+	m.reports[r.ID] = memoryReport{Report: *r, reporterID: reporterID}
+	return nil
+}
+
+func (m *memoryStore) Reports(ctx context.Context, status string, last int, before int64) ([]Report, error) {
+	defer m.lock()()
+
+	var rr []Report
+	for _, r := range m.reports {
+		if r.Status != status || (before != 0 && r.ID >= before) {
+			continue
+		}
+
+		report := r.Report
+		if u, ok := m.users[r.reporterID]; ok {
+			reporter := u.user()
+			reporter.ID = 0
+			report.Reporter = &reporter
+		}
+		rr = append(rr, report)
+	}
+
+	sort.Slice(rr, func(i, j int) bool { return rr[i].ID > rr[j].ID })
+	if len(rr) > last {
+		rr = rr[:last]
+	}
+
+	if rr == nil {
+		rr = []Report{}
+	}
+	return rr, nil
+}
+
+func (m *memoryStore) Report(ctx context.Context, id int64) (Report, error) {
+	defer m.lock()()
+
+	r, ok := m.reports[id]
+	if !ok {
+		return Report{}, ErrReportNotFound
+	}
+
+	return Report{ID: r.ID, TargetType: r.TargetType, TargetID: r.TargetID, Status: r.Status}, nil
+}
+
+func (m *memoryStore) SetReportStatus(ctx context.Context, reportID int64, status string) error {
+	defer m.lock()()
+
+	if r, ok := m.reports[reportID]; ok {
+		m.reports[reportID] = closeReport(r, status)
+	}
+	return nil
+}
+
+func (m *memoryStore) CloseReports(ctx context.Context, reportID int64, targetType string, targetID int64, status string) error {
+	defer m.lock()()
+
+	for id, r := range m.reports {
+		if r.Status != "open" {
+			continue
+		}
+
+		if id == reportID || (r.TargetType == targetType && r.TargetID == targetID) {
+			m.reports[id] = closeReport(r, status)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) LastModerationAction(ctx context.Context, targetType string, targetID int64) (string, error) {
+	defer m.lock()()
+
+	for i := len(m.moderation) - 1; i >= 0; i-- {
+		a := m.moderation[i]
+		if a.TargetType != targetType || a.TargetID != targetID {
+			continue
+		}
+
+		switch a.Action {
+		case ModerationHide, ModerationSuspend:
+			return a.Action, nil
+		case ModerationRestore:
+			return "", nil
+		}
+	}
+
+	return "", nil
+}
+
+func (m *memoryStore) InsertModerationAction(ctx context.Context, a ModerationAction) error {
+	defer m.lock()()
+
+	m.moderation = append(m.moderation, a)
+	return nil
+}
+
+func closeReport(r memoryReport, status string) memoryReport {
+	now := time.Now()
+	r.Status = status
+	r.ResolvedAt = &now
+	return r
+}
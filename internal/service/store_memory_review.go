@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func (m *memoryStore) Purchased(ctx context.Context, userID, postID int64) (bool, error) {
+	defer m.lock()()
+
+	for _, o := range m.orderRecord {
+		if !o.sale && o.userID == userID && o.PostID == postID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryStore) InsertReview(ctx context.Context, r *Review) error {
+	defer m.lock()()
+
+	for _, existing := range m.reviews {
+		if existing.UserID == r.UserID && existing.PostID == r.PostID {
+			return ErrAlreadyReviewed
+		}
+	}
+
+	r.ID = m.nextID()
+	r.CreatedAt = time.Now()
+	m.reviews[r.ID] = memoryReview{Review: Review{
+		ID:        r.ID,
+		UserID:    r.UserID,
+		PostID:    r.PostID,
+		Rating:    r.Rating,
+		Content:   r.Content,
+		CreatedAt: r.CreatedAt,
+	}}
+	return nil
+}
+
+func (m *memoryStore) ReviewByID(ctx context.Context, id int64) (Review, error) {
+	defer m.lock()()
+
+	r, ok := m.reviews[id]
+	if !ok {
+		return Review{}, ErrReviewNotFound
+	}
+
+	return Review{ID: r.ID, UserID: r.UserID, PostID: r.PostID}, nil
+}
+
+func (m *memoryStore) Review(ctx context.Context, viewerID, id int64) (Review, error) {
+	defer m.lock()()
+
+	r, ok := m.reviews[id]
+	if !ok || !m.postVisible(viewerID, m.posts[r.PostID]) || !m.reviewVisible(viewerID, r) {
+		return Review{}, ErrReviewNotFound
+	}
+
+	return Review{ID: r.ID, UserID: r.UserID, PostID: r.PostID}, nil
+}
+
+func (m *memoryStore) Reviews(ctx context.Context, viewerID, postID int64, last int, before int64) ([]Review, error) {
+	defer m.lock()()
+
+	rr := []Review{}
+	for _, r := range m.reviews {
+		if r.PostID != postID || (before != 0 && r.ID >= before) || !m.reviewVisible(viewerID, r) {
+			continue
+		}
+
+		review := r.Review
+		author := m.users[r.UserID].user()
+		author.ID = 0
+		review.User = &author
+		review.Mine = viewerID != 0 && r.UserID == viewerID
+		rr = append(rr, review)
+	}
+
+	sort.Slice(rr, func(i, j int) bool { return rr[i].ID > rr[j].ID })
+	if len(rr) > last {
+		rr = rr[:last]
+	}
+	return rr, nil
+}
+
+func (m *memoryStore) SetReviewHidden(ctx context.Context, reviewID int64, hidden bool) error {
+	defer m.lock()()
+
+	if r, ok := m.reviews[reviewID]; ok {
+		r.hidden = hidden
+		m.reviews[reviewID] = r
+	}
+	return nil
+}
+
+// 숨긴 후기는 작성자와 관리자만, 차단한 사이는 안 보임
+func (m *memoryStore) reviewVisible(viewerID int64, r memoryReview) bool {
+	if viewerID != 0 && m.blocked(viewerID, r.UserID) {
+		return false
+	}
+
+	return !r.hidden || r.UserID == viewerID || m.isAdmin(viewerID)
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+func (m *memoryStore) InsertUser(ctx context.Context, email, username string) error {
+	defer m.lock()()
+
+	for _, u := range m.users {
+		if u.email == email {
+			return ErrEmailTaken
+		}
+
+		if u.username == username {
+			return ErrUsernameTaken
+		}
+	}
+
+	id := m.nextID()
+	m.users[id] = memoryUser{id: id, email: email, username: username}
+	return nil
+}
+
+func (m *memoryStore) UserByID(ctx context.Context, id int64) (User, error) {
+	defer m.lock()()
+
+	u, ok := m.users[id]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+
+	return u.user(), nil
+}
+
+func (m *memoryStore) UserByEmail(ctx context.Context, email string) (User, error) {
+	defer m.lock()()
+
+	for _, u := range m.users {
+		if u.email == email {
+			return u.user(), nil
+		}
+	}
+
+	return User{}, ErrUserNotFound
+}
+
+func (m *memoryStore) UserIDByUsername(ctx context.Context, username string) (int64, error) {
+	defer m.lock()()
+
+	u, ok := m.userByUsername(username)
+	if !ok {
+		return 0, ErrUserNotFound
+	}
+
+	return u.id, nil
+}
+
+func (m *memoryStore) UsersByIDs(ctx context.Context, ids []int64) (map[int64]User, error) {
+	defer m.lock()()
+
+	uu := map[int64]User{}
+	for _, id := range ids {
+		if u, ok := m.users[id]; ok {
+			uu[id] = u.user()
+		}
+	}
+	return uu, nil
+}
+
+func (m *memoryStore) UserFlags(ctx context.Context, id int64) (UserFlags, error) {
+	defer m.lock()()
+
+	u := m.users[id]
+	return UserFlags{Private: u.private, Admin: u.admin, Suspended: u.suspended}, nil
+}
+
+func (m *memoryStore) UserProfile(ctx context.Context, viewerID int64, username string) (UserProfile, error) {
+	defer m.lock()()
+
+	u, ok := m.userByUsername(username)
+	if !ok || (viewerID != 0 && m.blocked(viewerID, u.id)) {
+		return UserProfile{}, ErrUserNotFound
+	}
+
+	p := m.profile(viewerID, u)
+	if viewerID != 0 {
+		p.Requested = m.followRequests[memoryPair{viewerID, u.id}]
+	}
+	return p, nil
+}
+
+func (m *memoryStore) Users(ctx context.Context, viewerID int64, search string, first int, after string) ([]UserProfile, error) {
+	defer m.lock()()
+
+	search = strings.ToLower(search)
+	var uu []memoryUser
+	for _, u := range m.users {
+		if viewerID != 0 && m.blocked(viewerID, u.id) {
+			continue
+		}
+
+		if search != "" && !strings.Contains(strings.ToLower(u.username), search) {
+			continue
+		}
+
+		uu = append(uu, u)
+	}
+
+	return m.profiles(viewerID, uu, first, after), nil
+}
+
+func (m *memoryStore) SetAvatar(ctx context.Context, userID int64, avatar string) (string, error) {
+	defer m.lock()()
+
+	u := m.users[userID]
+	old := u.avatar
+	u.avatar = avatar
+	m.users[userID] = u
+	return old, nil
+}
+
+func (m *memoryStore) SetPrivate(ctx context.Context, userID int64, private bool) error {
+	defer m.lock()()
+
+	if u, ok := m.users[userID]; ok {
+		u.private = private
+		m.users[userID] = u
+	}
+	return nil
+}
+
+func (m *memoryStore) SetSuspended(ctx context.Context, userID int64, suspended bool) error {
+	defer m.lock()()
+
+	if u, ok := m.users[userID]; ok {
+		u.suspended = suspended
+		m.users[userID] = u
+	}
+	return nil
+}
+
+func (m *memoryStore) Followers(ctx context.Context, viewerID int64, username string, first int, after string) ([]UserProfile, error) {
+	defer m.lock()()
+
+	followee, ok := m.userByUsername(username)
+	if !ok {
+		return []UserProfile{}, nil
+	}
+
+	var uu []memoryUser
+	for f := range m.follows {
+		if f.b != followee.id {
+			continue
+		}
+
+		if viewerID != 0 && (m.blocked(viewerID, f.a) || m.blocked(viewerID, followee.id)) {
+			continue
+		}
+
+		uu = append(uu, m.users[f.a])
+	}
+
+	return m.profiles(viewerID, uu, first, after), nil
+}
+
+func (m *memoryStore) Followees(ctx context.Context, viewerID int64, username string, first int, after string) ([]UserProfile, error) {
+	defer m.lock()()
+
+	follower, ok := m.userByUsername(username)
+	if !ok {
+		return []UserProfile{}, nil
+	}
+
+	var uu []memoryUser
+	for f := range m.follows {
+		if f.a != follower.id {
+			continue
+		}
+
+		if viewerID != 0 && (m.blocked(viewerID, f.b) || m.blocked(viewerID, follower.id)) {
+			continue
+		}
+
+		uu = append(uu, m.users[f.b])
+	}
+
+	return m.profiles(viewerID, uu, first, after), nil
+}
+
+func (m *memoryStore) Following(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	defer m.lock()()
+	return m.follows[memoryPair{followerID, followeeID}], nil
+}
+
+func (m *memoryStore) InsertFollow(ctx context.Context, followerID, followeeID int64) error {
+	defer m.lock()()
+	m.follows[memoryPair{followerID, followeeID}] = true
+	return nil
+}
+
+func (m *memoryStore) DeleteFollow(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	defer m.lock()()
+
+	key := memoryPair{followerID, followeeID}
+	if !m.follows[key] {
+		return false, nil
+	}
+
+	delete(m.follows, key)
+	return true, nil
+}
+
+func (m *memoryStore) FollowersCount(ctx context.Context, userID int64) (int, error) {
+	defer m.lock()()
+	return m.followersCount(userID), nil
+}
+
+func (m *memoryStore) FollowRequested(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	defer m.lock()()
+	return m.followRequests[memoryPair{followerID, followeeID}], nil
+}
+
+func (m *memoryStore) InsertFollowRequest(ctx context.Context, followerID, followeeID int64) error {
+	defer m.lock()()
+	m.followRequests[memoryPair{followerID, followeeID}] = true
+	return nil
+}
+
+func (m *memoryStore) DeleteFollowRequest(ctx context.Context, followerID, followeeID int64) error {
+	defer m.lock()()
+	delete(m.followRequests, memoryPair{followerID, followeeID})
+	return nil
+}
+
+func (m *memoryStore) FollowRequesterIDs(ctx context.Context, followeeID int64) ([]int64, error) {
+	defer m.lock()()
+
+	var ids []int64
+	for r := range m.followRequests {
+		if r.b == followeeID {
+			ids = append(ids, r.a)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (m *memoryStore) FollowRequests(ctx context.Context, followeeID int64, first int, after string) ([]UserProfile, error) {
+	defer m.lock()()
+
+	var uu []memoryUser
+	for r := range m.followRequests {
+		if r.b == followeeID {
+			uu = append(uu, m.users[r.a])
+		}
+	}
+
+	// 요청 목록에는 나를 팔로우하는지만 표시
+	pp := m.profiles(followeeID, uu, first, after)
+	for i := range pp {
+		pp[i].ID = 0
+		pp[i].Email = ""
+		pp[i].Following = false
+	}
+	return pp, nil
+}
+
+func (m *memoryStore) Blocked(ctx context.Context, a, b int64) (bool, error) {
+	defer m.lock()()
+	return m.blocked(a, b), nil
+}
+
+func (m *memoryStore) Blocking(ctx context.Context, blockerID, blockedID int64) (bool, error) {
+	defer m.lock()()
+	return m.blocks[memoryPair{blockerID, blockedID}], nil
+}
+
+func (m *memoryStore) InsertBlock(ctx context.Context, blockerID, blockedID int64) error {
+	defer m.lock()()
+	m.blocks[memoryPair{blockerID, blockedID}] = true
+	return nil
+}
+
+func (m *memoryStore) DeleteBlock(ctx context.Context, blockerID, blockedID int64) error {
+	defer m.lock()()
+	delete(m.blocks, memoryPair{blockerID, blockedID})
+	return nil
+}
+
+func (m *memoryStore) Muting(ctx context.Context, muterID, mutedID int64) (bool, error) {
+	defer m.lock()()
+	return m.mutes[memoryPair{muterID, mutedID}], nil
+}
+
+func (m *memoryStore) InsertMute(ctx context.Context, muterID, mutedID int64) error {
+	defer m.lock()()
+	m.mutes[memoryPair{muterID, mutedID}] = true
+	return nil
+}
+
+func (m *memoryStore) DeleteMute(ctx context.Context, muterID, mutedID int64) error {
+	defer m.lock()()
+	delete(m.mutes, memoryPair{muterID, mutedID})
+	return nil
+}
+
+func (m *memoryStore) MuterIDs(ctx context.Context, mutedID int64) (map[int64]bool, error) {
+	defer m.lock()()
+
+	ids := map[int64]bool{}
+	for mu := range m.mutes {
+		if mu.b == mutedID {
+			ids[mu.a] = true
+		}
+	}
+	return ids, nil
+}
+
+func (u memoryUser) user() User {
+	return User{ID: u.id, UserName: u.username, avatarName: u.avatar}
+}
+
+func (m *memoryStore) userByUsername(username string) (memoryUser, bool) {
+	for _, u := range m.users {
+		if u.username == username {
+			return u, true
+		}
+	}
+	return memoryUser{}, false
+}
+
+func (m *memoryStore) followersCount(userID int64) int {
+	return countPairs(m.follows, func(f memoryPair) bool { return f.b == userID })
+}
+
+func (m *memoryStore) profile(viewerID int64, u memoryUser) UserProfile {
+	p := UserProfile{
+		User:           u.user(),
+		Email:          u.email,
+		FollowersCount: m.followersCount(u.id),
+		FolloweesCount: countPairs(m.follows, func(f memoryPair) bool { return f.a == u.id }),
+		Private:        u.private,
+	}
+	if viewerID != 0 {
+		p.Following = m.follows[memoryPair{viewerID, u.id}]
+		p.Followeed = m.follows[memoryPair{u.id, viewerID}]
+	}
+	return p
+}
+
+// 유저 이름 오름차순, after 다음부터 first개
+func (m *memoryStore) profiles(viewerID int64, uu []memoryUser, first int, after string) []UserProfile {
+	sort.Slice(uu, func(i, j int) bool { return uu[i].username < uu[j].username })
+
+	pp := make([]UserProfile, 0, first)
+	for _, u := range uu {
+		if len(pp) == first {
+			break
+		}
+
+		if after != "" && u.username <= after {
+			continue
+		}
+
+		pp = append(pp, m.profile(viewerID, u))
+	}
+	return pp
+}
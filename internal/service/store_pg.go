@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// 트랜잭션 재시도
+const (
+	txSavepoint   = "cockroach_restart"
+	maxTxAttempts = 10
+	minTxBackoff  = time.Millisecond * 10
+	maxTxBackoff  = time.Second
+)
+
+// txMetrics counts committed transactions, retries and transactions
+// that gave up after maxTxAttempts. Published as the "tx" expvar.
+var txMetrics = expvar.NewMap("tx")
+
+// *sql.DB, *sql.Tx 공통
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Postgres, CockroachDB 저장소
+type pgStore struct {
+	db *sql.DB
+	q  querier
+	tx bool
+}
+
+// NewPGStore keeps the data in Postgres or CockroachDB.
+func NewPGStore(db *sql.DB) Store {
+	return &pgStore{db: db, q: db}
+}
+
+func (s *pgStore) Tx(ctx context.Context, fn func(Store) error) error {
+	if s.tx {
+		return fn(s)
+	}
+
+	return s.runTx(ctx, fn)
+}
+
+// runTx runs fn in a transaction and retries it from a savepoint
+// when CockroachDB asks for a restart (SQLSTATE 40001).
+// fn can run more than once, so it must reset whatever it captures.
+func (s *pgStore) runTx(ctx context.Context, fn func(Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT "+txSavepoint); err != nil {
+		return fmt.Errorf("could not create savepoint: %v", err)
+	}
+
+	q := &txQuerier{Tx: tx}
+	for attempt := 1; ; attempt++ {
+		q.retry = false
+		if err = fn(&pgStore{db: s.db, q: q, tx: true}); err == nil {
+			_, err = q.ExecContext(ctx, "RELEASE SAVEPOINT "+txSavepoint)
+		}
+
+		if err == nil {
+			break
+		}
+
+		if !q.retry && !retryable(err) {
+			return err
+		}
+
+		if attempt == maxTxAttempts {
+			txMetrics.Add("exhausted", 1)
+			return fmt.Errorf("could not run tx after %d attempts: %v", attempt, err)
+		}
+
+		txMetrics.Add("retries", 1)
+		if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+txSavepoint); err != nil {
+			return fmt.Errorf("could not rollback to savepoint: %v", err)
+		}
+
+		if err = txBackoff(ctx, attempt); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit tx: %v", err)
+	}
+
+	txMetrics.Add("commits", 1)
+	return nil
+}
+
+// 재시도 전에 백오프만큼 기다림
+func txBackoff(ctx context.Context, attempt int) error {
+	t := time.NewTimer(backoff(attempt, minTxBackoff, maxTxBackoff))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// 40001 serialization_failure
+// 저장소 메서드는 에러를 %v로 감싸므로 쿼리 에러는 txQuerier에서 확인
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "40001"
+}
+
+// 트랜잭션 안의 쿼리에서 재시도 가능한 에러가 났는지 기록
+type txQuerier struct {
+	*sql.Tx
+	retry bool
+}
+
+func (q *txQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := q.Tx.ExecContext(ctx, query, args...)
+	q.check(err)
+	return res, err
+}
+
+func (q *txQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := q.Tx.QueryContext(ctx, query, args...)
+	q.check(err)
+	return rows, err
+}
+
+func (q *txQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := q.Tx.QueryRowContext(ctx, query, args...)
+	q.check(row.Err())
+	return row
+}
+
+func (q *txQuerier) check(err error) {
+	if retryable(err) {
+		q.retry = true
+	}
+}
+
+func (s *pgStore) RepeatedContent(ctx context.Context, table string, userID int64, content string, window time.Duration) (int, error) {
+	var n int
+	query := "SELECT count(*) FROM " + table +
+		" WHERE user_id = $1 AND content = $2 AND created_at > now() - $3 * INTERVAL '1 second'"
+	if err := s.q.QueryRowContext(ctx, query, userID, content, int64(window.Seconds())).Scan(&n); err != nil {
+		return 0, fmt.Errorf("could not query select repeated %s: %v", table, err)
+	}
+
+	return n, nil
+}
+
+// SELECT EXISTS 쿼리
+func (s *pgStore) exists(ctx context.Context, what, query string, args ...interface{}) (bool, error) {
+	var ok bool
+	if err := s.q.QueryRowContext(ctx, query, args...).Scan(&ok); err != nil {
+		return false, fmt.Errorf("could not query select existance of %s: %v", what, err)
+	}
+
+	return ok, nil
+}
+
+// RETURNING 으로 받은 카운트
+func (s *pgStore) count(ctx context.Context, what, query string, args ...interface{}) (int, error) {
+	var n int
+	if err := s.q.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("could not update %s: %v", what, err)
+	}
+
+	return n, nil
+}
+
+func (s *pgStore) exec(ctx context.Context, what, query string, args ...interface{}) error {
+	if _, err := s.q.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("could not %s: %v", what, err)
+	}
+
+	return nil
+}
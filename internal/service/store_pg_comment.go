@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (s *pgStore) InsertComment(ctx context.Context, c *Comment) error {
+	query := `
+		INSERT INTO comments (user_id, post_id, parent_id, content) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err := s.q.QueryRowContext(ctx, query, c.UserID, c.PostID, c.ParentID, c.Content).Scan(&c.ID, &c.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrPostNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not insert comment: %v", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) CommentByID(ctx context.Context, id int64) (Comment, error) {
+	c := Comment{ID: id}
+	query := "SELECT user_id, post_id, parent_id, replies_count, deleted_at IS NOT NULL FROM comments WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, id).Scan(&c.UserID, &c.PostID, &c.ParentID, &c.RepliesCount, &c.Deleted)
+	if err == sql.ErrNoRows {
+		return c, ErrCommentNotFound
+	}
+
+	if err != nil {
+		return c, fmt.Errorf("could not query select comment: %v", err)
+	}
+
+	return c, nil
+}
+
+func (s *pgStore) Comment(ctx context.Context, viewerID, id int64) (Comment, error) {
+	c := Comment{ID: id}
+	query, args, err := buildQuery(`
+		SELECT comments.user_id, comments.post_id, comments.parent_id, comments.replies_count, comments.deleted_at IS NOT NULL
+		FROM comments
+		INNER JOIN posts ON comments.post_id = posts.id
+		INNER JOIN users AS post_authors ON posts.user_id = post_authors.id
+		WHERE comments.id = @comment_id
+		AND (
+			comments.hidden_at IS NULL
+			{{if .auth}}
+			OR comments.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		AND (
+			posts.hidden_at IS NULL
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		AND (
+			post_authors.private = false
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = posts.user_id)
+			{{end}}
+		)
+		{{if .auth}}AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id IN (comments.user_id, posts.user_id))
+				OR (blocked_id = @uid AND blocker_id IN (comments.user_id, posts.user_id))
+		){{end}}`, map[string]interface{}{
+		"auth":       viewerID != 0,
+		"uid":        viewerID,
+		"comment_id": id,
+	})
+	if err != nil {
+		return c, fmt.Errorf("could not build comment sql query: %v", err)
+	}
+
+	err = s.q.QueryRowContext(ctx, query, args...).Scan(&c.UserID, &c.PostID, &c.ParentID, &c.RepliesCount, &c.Deleted)
+	if err == sql.ErrNoRows {
+		return c, ErrCommentNotFound
+	}
+
+	if err != nil {
+		return c, fmt.Errorf("could not query select comment: %v", err)
+	}
+
+	return c, nil
+}
+
+func (s *pgStore) Comments(ctx context.Context, viewerID, postID int64, last int, before int64) ([]Comment, error) {
+	return s.comments(ctx, viewerID, map[string]interface{}{
+		"post_id": postID,
+		"last":    last,
+		"before":  before,
+	})
+}
+
+func (s *pgStore) PostsComments(ctx context.Context, viewerID int64, postIDs []int64, last int, before int64) (map[int64][]Comment, error) {
+	pc := map[int64][]Comment{}
+	if len(postIDs) == 0 {
+		return pc, nil
+	}
+
+	cc, err := s.comments(ctx, viewerID, map[string]interface{}{
+		"post_ids": pq.Array(postIDs),
+		"last":     last,
+		"before":   before,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range cc {
+		pc[c.PostID] = append(pc[c.PostID], c)
+	}
+	return pc, nil
+}
+
+func (s *pgStore) Replies(ctx context.Context, viewerID, commentID int64, last int, before int64) ([]Comment, error) {
+	return s.comments(ctx, viewerID, map[string]interface{}{
+		"parent_id": commentID,
+		"last":      last,
+		"before":    before,
+	})
+}
+
+// 게시물의 댓글 또는 댓글의 답글 목록
+// post_ids로 여러 게시물을 한 번에 읽을 때는 게시물마다 last개씩
+func (s *pgStore) comments(ctx context.Context, viewerID int64, data map[string]interface{}) ([]Comment, error) {
+	auth := viewerID != 0
+	batch := data["post_ids"] != nil
+	data["auth"] = auth
+	data["uid"] = viewerID
+	query, args, err := buildQuery(`
+	{{if .post_ids}}SELECT * FROM ({{end}}
+	SELECT comments.id, comments.post_id, comments.parent_id, content, likes_count, replies_count
+	, comments.deleted_at IS NOT NULL AS deleted, created_at, username, avatar
+	{{if .auth}}
+	, comments.user_id = @uid AS mine
+	, likes.user_id IS NOT NULL AS liked
+	{{end}}
+	{{if .post_ids}}
+	, ROW_NUMBER() OVER (PARTITION BY comments.post_id ORDER BY created_at DESC) AS row_n
+	{{end}}
+	FROM comments
+	INNER JOIN users ON comments.user_id = users.id
+	{{if .auth}}
+	LEFT JOIN comment_likes AS likes
+		ON likes.comment_id = comments.id AND likes.user_id = @uid
+	{{end}}
+	{{if .parent_id}}
+	WHERE comments.parent_id = @parent_id
+	{{else if .post_ids}}
+	WHERE comments.post_id = ANY(@post_ids) AND comments.parent_id IS NULL
+	{{else}}
+	WHERE comments.post_id = @post_id AND comments.parent_id IS NULL
+	{{end}}
+	{{if .auth}}AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = @uid AND blocked_id IN (comments.user_id, (SELECT user_id FROM posts WHERE id = comments.post_id)))
+			OR (blocked_id = @uid AND blocker_id IN (comments.user_id, (SELECT user_id FROM posts WHERE id = comments.post_id)))
+	){{end}}
+	AND (
+		comments.hidden_at IS NULL
+		{{if .auth}}
+		OR comments.user_id = @uid
+		OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+		{{end}}
+	)
+	{{if .before}}AND comments.id < @before{{end}}
+	{{if .post_ids}}
+	) AS page WHERE row_n <= @last
+	ORDER BY created_at DESC
+	{{else}}
+	ORDER BY created_at DESC
+	LIMIT @last
+	{{end}}`, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not build comments sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select comments: %v", err)
+	}
+
+	defer rows.Close()
+
+	cc := make([]Comment, 0, data["last"].(int))
+	for rows.Next() {
+		var c Comment
+		var u User
+		var avatar sql.NullString
+		var rowN int
+		dest := []interface{}{
+			&c.ID,
+			&c.PostID,
+			&c.ParentID,
+			&c.Content,
+			&c.LikesCount,
+			&c.RepliesCount,
+			&c.Deleted,
+			&c.CreatedAt,
+			&u.UserName,
+			&avatar,
+		}
+		if auth {
+			dest = append(dest, &c.Mine, &c.Liked)
+		}
+		if batch {
+			dest = append(dest, &rowN)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan comment: %v", err)
+		}
+
+		u.avatarName = avatar.String
+		c.User = &u
+		cc = append(cc, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate comment rows: %v", err)
+	}
+
+	return cc, nil
+}
+
+func (s *pgStore) AddRepliesCount(ctx context.Context, commentID int64, n int) error {
+	query := "UPDATE comments SET replies_count = replies_count + $1 WHERE id = $2"
+	return s.exec(ctx, "update comment replies count", query, n, commentID)
+}
+
+func (s *pgStore) TombstoneComment(ctx context.Context, commentID int64) error {
+	query := "UPDATE comments SET content = '', deleted_at = now() WHERE id = $1"
+	return s.exec(ctx, "update comment to tombstone", query, commentID)
+}
+
+func (s *pgStore) DeleteComment(ctx context.Context, commentID int64) error {
+	query := "DELETE FROM comment_likes WHERE comment_id = $1"
+	if err := s.exec(ctx, "delete comment likes", query, commentID); err != nil {
+		return err
+	}
+
+	return s.exec(ctx, "delete comment", "DELETE FROM comments WHERE id = $1", commentID)
+}
+
+func (s *pgStore) SetCommentHidden(ctx context.Context, commentID int64, hidden bool) error {
+	query := "UPDATE comments SET hidden_at = NULL WHERE id = $1"
+	if hidden {
+		query = "UPDATE comments SET hidden_at = now() WHERE id = $1"
+	}
+	return s.exec(ctx, "update comments hidden", query, commentID)
+}
+
+func (s *pgStore) CommentLiked(ctx context.Context, userID, commentID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM comment_likes WHERE user_id = $1 AND comment_id = $2)"
+	return s.exists(ctx, "comment like", query, userID, commentID)
+}
+
+func (s *pgStore) CommentLikesCount(ctx context.Context, commentID int64) (int, error) {
+	var n int
+	query := "SELECT likes_count FROM comments WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, commentID).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, ErrCommentNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not query select comment likes count: %v", err)
+	}
+
+	return n, nil
+}
+
+func (s *pgStore) InsertCommentLike(ctx context.Context, userID, commentID int64) (int, error) {
+	query := "INSERT INTO comment_likes (user_id, comment_id) VALUES ($1, $2)"
+	_, err := s.q.ExecContext(ctx, query, userID, commentID)
+	if isForeignKeyViolation(err) {
+		return 0, ErrCommentNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not insert comment like: %v", err)
+	}
+
+	query = "UPDATE comments SET likes_count = likes_count + 1 WHERE id = $1 RETURNING likes_count"
+	return s.count(ctx, "and increment comment likes count", query, commentID)
+}
+
+func (s *pgStore) DeleteCommentLike(ctx context.Context, userID, commentID int64) (int, error) {
+	query := "DELETE FROM comment_likes WHERE user_id = $1 AND comment_id = $2"
+	if err := s.exec(ctx, "delete comment like", query, userID, commentID); err != nil {
+		return 0, err
+	}
+
+	query = "UPDATE comments SET likes_count = likes_count - 1 WHERE id = $1 RETURNING likes_count"
+	return s.count(ctx, "and decrement comment likes count", query, commentID)
+}
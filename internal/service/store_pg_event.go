@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (s *pgStore) InsertEvent(ctx context.Context, e *Event) error {
+	query := "INSERT INTO outbox (type, payload) VALUES ($1, $2) RETURNING id, created_at"
+	if err := s.q.QueryRowContext(ctx, query, e.Type, string(e.Payload)).Scan(&e.ID, &e.OccurredAt); err != nil {
+		return fmt.Errorf("could not insert event: %v", err)
+	}
+
+	return nil
+}
+
+// 바깥 WHERE에서 잠금을 다시 확인해서 두 릴레이가 같은 이벤트를 가져가지 않음
+func (s *pgStore) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	query, args, err := buildQuery(`
+		UPDATE outbox SET locked_until = now() + @lease_ms::INT * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE locked_until IS NULL OR locked_until < now()
+			ORDER BY id
+			LIMIT @limit
+		)
+		AND (locked_until IS NULL OR locked_until < now())
+		RETURNING id, type, payload, created_at`, map[string]interface{}{
+		"limit":    limit,
+		"lease_ms": lease.Milliseconds(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build claim events sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not claim events: %v", err)
+	}
+
+	defer rows.Close()
+
+	var ee []Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err = rows.Scan(&e.ID, &e.Type, &payload, &e.OccurredAt); err != nil {
+			return nil, fmt.Errorf("could not scan event: %v", err)
+		}
+
+		e.Payload = payload
+		ee = append(ee, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate event rows: %v", err)
+	}
+
+	// RETURNING은 순서를 보장하지 않음
+	sort.Slice(ee, func(i, j int) bool { return ee[i].ID < ee[j].ID })
+	return ee, nil
+}
+
+func (s *pgStore) DeleteEvents(ctx context.Context, ids []int64) error {
+	return s.exec(ctx, "delete events", "DELETE FROM outbox WHERE id = ANY($1)", pq.Array(ids))
+}
+
+func (s *pgStore) UnlockEvents(ctx context.Context, ids []int64) error {
+	return s.exec(ctx, "unlock events", "UPDATE outbox SET locked_until = NULL WHERE id = ANY($1)", pq.Array(ids))
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+func (s *pgStore) InsertJob(ctx context.Context, j *Job) error {
+	query := "INSERT INTO jobs (kind, payload, max_attempts) VALUES ($1, $2, $3) RETURNING id, created_at"
+	if err := s.q.QueryRowContext(ctx, query, j.Kind, string(j.Payload), j.MaxAttempts).Scan(&j.ID, &j.CreatedAt); err != nil {
+		return fmt.Errorf("could not insert job: %v", err)
+	}
+
+	return nil
+}
+
+// 바깥 WHERE에서 잠금을 다시 확인해서 두 워커가 같은 작업을 가져가지 않음
+func (s *pgStore) ClaimJob(ctx context.Context, kind string, lease time.Duration) (Job, bool, error) {
+	var j Job
+	var payload []byte
+	query, args, err := buildQuery(`
+		UPDATE jobs SET
+			attempts = attempts + 1,
+			locked_until = now() + @lease_ms::INT * INTERVAL '1 millisecond'
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = @kind
+			AND dead_at IS NULL
+			AND run_at <= now()
+			AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY run_at, id
+			LIMIT 1
+		)
+		AND (locked_until IS NULL OR locked_until < now())
+		RETURNING id, kind, payload, attempts, max_attempts, created_at`, map[string]interface{}{
+		"kind":     kind,
+		"lease_ms": lease.Milliseconds(),
+	})
+	if err != nil {
+		return j, false, fmt.Errorf("could not build claim job sql query: %v", err)
+	}
+
+	err = s.q.QueryRowContext(ctx, query, args...).Scan(&j.ID, &j.Kind, &payload, &j.Attempts, &j.MaxAttempts, &j.CreatedAt)
+	if err == sql.ErrNoRows {
+		return j, false, nil
+	}
+
+	if err != nil {
+		return j, false, fmt.Errorf("could not claim job: %v", err)
+	}
+
+	j.Payload = payload
+	return j, true, nil
+}
+
+func (s *pgStore) DeleteJob(ctx context.Context, id int64) error {
+	return s.exec(ctx, "delete job", "DELETE FROM jobs WHERE id = $1", id)
+}
+
+func (s *pgStore) RetryJob(ctx context.Context, id int64, delay time.Duration, lastError string) error {
+	query := "UPDATE jobs SET locked_until = NULL, last_error = $2, " +
+		"run_at = now() + $3::INT * INTERVAL '1 millisecond' WHERE id = $1"
+	return s.exec(ctx, "retry job", query, id, lastError, delay.Milliseconds())
+}
+
+func (s *pgStore) BuryJob(ctx context.Context, id int64, lastError string) error {
+	query := "UPDATE jobs SET locked_until = NULL, last_error = $2, dead_at = now() WHERE id = $1"
+	return s.exec(ctx, "bury job", query, id, lastError)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (s *pgStore) Notifications(ctx context.Context, userID int64, last int, before int64) ([]Notification, error) {
+	query, args, err := buildQuery(`
+		SELECT id, actors, type, post_id, read, issued_at
+		FROM notifications
+		WHERE user_id = @uid
+		{{if .before}}AND id < @before{{end}}
+		ORDER BY issued_at DESC
+		LIMIT @last`, map[string]interface{}{
+		"uid":    userID,
+		"before": before,
+		"last":   last,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("could not build notifications sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select notifications: %v", err)
+	}
+
+	defer rows.Close()
+
+	nn := make([]Notification, 0, last)
+	for rows.Next() {
+		n := Notification{UserID: userID}
+		if err = rows.Scan(&n.ID, pq.Array(&n.Actors), &n.Type, &n.PostID, &n.Read, &n.IssuedAt); err != nil {
+			return nil, fmt.Errorf("could not scan notification: %v", err)
+		}
+
+		nn = append(nn, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over notification rows: %v", err)
+	}
+
+	return nn, nil
+}
+
+func (s *pgStore) MarkNotificationAsRead(ctx context.Context, userID, notificationID int64) error {
+	query := "UPDATE notifications SET read = true WHERE id = $1 AND user_id = $2"
+	return s.exec(ctx, "update and mark notification as read", query, notificationID, userID)
+}
+
+func (s *pgStore) MarkNotificationsAsRead(ctx context.Context, userID int64) error {
+	query := "UPDATE notifications SET read = true WHERE user_id = $1"
+	return s.exec(ctx, "update and mark notifications as read", query, userID)
+}
+
+func (s *pgStore) FollowNotified(ctx context.Context, userID int64, actor string) (bool, error) {
+	query := `SELECT EXISTS (
+		SELECT 1 FROM notifications
+		WHERE user_id = $1
+			AND $2:::VARCHAR = ANY(actors)
+			AND type = 'follow')`
+	return s.exists(ctx, "follow notification", query, userID, actor)
+}
+
+func (s *pgStore) UnreadNotificationID(ctx context.Context, userID int64, typ string, postID *int64) (int64, error) {
+	var id int64
+	query := "SELECT id FROM notifications WHERE user_id = $1 AND type = $2 AND post_id IS NULL AND read = false"
+	args := []interface{}{userID, typ}
+	if postID != nil {
+		query = "SELECT id FROM notifications WHERE user_id = $1 AND type = $2 AND post_id = $3 AND read = false"
+		args = append(args, *postID)
+	}
+
+	err := s.q.QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("could not query select unread %s notification: %v", typ, err)
+	}
+
+	return id, nil
+}
+
+func (s *pgStore) InsertNotification(ctx context.Context, n *Notification) error {
+	query := `INSERT INTO notifications (user_id, actors, type, post_id) VALUES ($1, $2, $3, $4)
+		RETURNING id, issued_at`
+	if err := s.q.QueryRowContext(ctx, query, n.UserID, pq.Array(n.Actors), n.Type, n.PostID).Scan(&n.ID, &n.IssuedAt); err != nil {
+		return fmt.Errorf("could not insert %s notification: %v", n.Type, err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) AddNotificationActor(ctx context.Context, n *Notification, actor string) error {
+	query := `
+		UPDATE notifications SET
+			actors = array_prepend($1, array_remove(notifications.actors, $1)),
+			issued_at = now()
+		WHERE id = $2
+		RETURNING actors, issued_at`
+	if err := s.q.QueryRowContext(ctx, query, actor, n.ID).Scan(pq.Array(&n.Actors), &n.IssuedAt); err != nil {
+		return fmt.Errorf("could not update %s notification: %v", n.Type, err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) DropNotificationActor(ctx context.Context, userID, actorID int64) error {
+	query := `
+		UPDATE notifications
+		SET actors = array_remove(actors, (SELECT username FROM users WHERE id = $2))
+		WHERE user_id = $1`
+	if err := s.exec(ctx, "remove notification actor", query, userID, actorID); err != nil {
+		return err
+	}
+
+	query = "DELETE FROM notifications WHERE user_id = $1 AND array_length(actors, 1) IS NULL"
+	return s.exec(ctx, "delete empty notifications", query, userID)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+func (s *pgStore) AddBasketItem(ctx context.Context, userID, postID int64, quantity int) (BasketItem, error) {
+	item := BasketItem{PostID: postID}
+	query := `
+		INSERT INTO shopping_basket (user_id, post_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET quantity = shopping_basket.quantity + excluded.quantity
+		RETURNING id, quantity`
+	if err := s.q.QueryRowContext(ctx, query, userID, postID, quantity).Scan(&item.ID, &item.Quantity); err != nil {
+		return item, fmt.Errorf("could not insert basket item: %v", err)
+	}
+
+	return item, nil
+}
+
+func (s *pgStore) BasketItems(ctx context.Context, userID int64) ([]BasketItem, error) {
+	query := "SELECT id, post_id, quantity FROM shopping_basket WHERE user_id = $1 ORDER BY id"
+	rows, err := s.q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select basket items: %v", err)
+	}
+
+	defer rows.Close()
+
+	items := []BasketItem{}
+	for rows.Next() {
+		var item BasketItem
+		if err = rows.Scan(&item.ID, &item.PostID, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("could not scan basket item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate basket item rows: %v", err)
+	}
+
+	return items, nil
+}
+
+func (s *pgStore) DeleteBasketItem(ctx context.Context, userID, itemID int64) error {
+	query := "DELETE FROM shopping_basket WHERE id = $1 AND user_id = $2"
+	res, err := s.q.ExecContext(ctx, query, itemID, userID)
+	if err != nil {
+		return fmt.Errorf("could not delete basket item: %v", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrBasketItemNotFound
+	}
+
+	return nil
+}
+
+// 구매 기록, 판매 기록, 장바구니 비우기는 호출한 쪽의 트랜잭션 안에서
+func (s *pgStore) PlaceOrder(ctx context.Context, userID int64) (Order, error) {
+	o := Order{Items: []OrderItem{}}
+	if err := s.q.QueryRowContext(ctx, "SELECT nextval('order_numbers')").Scan(&o.Num); err != nil {
+		return o, fmt.Errorf("could not query next order number: %v", err)
+	}
+
+	query := `
+		INSERT INTO buy_record (quantity, ordernum, user_id, post_id)
+		SELECT quantity, $1, user_id, post_id FROM shopping_basket WHERE user_id = $2
+		RETURNING id, post_id, quantity`
+	rows, err := s.q.QueryContext(ctx, query, o.Num, userID)
+	if err != nil {
+		return o, fmt.Errorf("could not insert buy records: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		item := OrderItem{OrderNum: o.Num}
+		if err = rows.Scan(&item.ID, &item.PostID, &item.Quantity); err != nil {
+			return o, fmt.Errorf("could not scan buy record: %v", err)
+		}
+		o.Items = append(o.Items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return o, fmt.Errorf("could not iterate buy record rows: %v", err)
+	}
+
+	sort.Slice(o.Items, func(i, j int) bool { return o.Items[i].ID < o.Items[j].ID })
+
+	query = `
+		INSERT INTO sell_record (quantity, ordernum, user_id, post_id)
+		SELECT shopping_basket.quantity, $1, posts.user_id, shopping_basket.post_id
+		FROM shopping_basket
+		INNER JOIN posts ON posts.id = shopping_basket.post_id
+		WHERE shopping_basket.user_id = $2`
+	if err = s.exec(ctx, "insert sell records", query, o.Num, userID); err != nil {
+		return o, err
+	}
+
+	query = "DELETE FROM shopping_basket WHERE user_id = $1"
+	if err = s.exec(ctx, "delete basket items", query, userID); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+func (s *pgStore) OrderItems(ctx context.Context, userID int64, sales bool, last int, before int64) ([]OrderItem, error) {
+	query, args, err := buildQuery(`
+		SELECT id, ordernum, post_id, quantity
+		FROM {{if .sales}}sell_record{{else}}buy_record{{end}}
+		WHERE user_id = @uid
+		{{if .before}}AND id < @before{{end}}
+		ORDER BY id DESC
+		LIMIT @last`, map[string]interface{}{
+		"uid":    userID,
+		"sales":  sales,
+		"last":   last,
+		"before": before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build order items sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select order items: %v", err)
+	}
+
+	defer rows.Close()
+
+	items := make([]OrderItem, 0, last)
+	for rows.Next() {
+		var item OrderItem
+		if err = rows.Scan(&item.ID, &item.OrderNum, &item.PostID, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("could not scan order item: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate order item rows: %v", err)
+	}
+
+	return items, nil
+}
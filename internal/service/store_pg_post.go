@@ -0,0 +1,541 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (s *pgStore) InsertPost(ctx context.Context, p *Post) error {
+	query := "INSERT INTO posts (user_id, content, spoiler_of, nsfw, repost_of_id) VALUES ($1, $2, $3, $4, $5) " +
+		"RETURNING id, created_at"
+	err := s.q.QueryRowContext(ctx, query, p.UserID, p.Content, p.SpoilerOf, p.NSFW, p.RepostOfID).Scan(&p.ID, &p.CreatedAt)
+	// unique_reposts, 내용 없는 공유를 동시에 두 번
+	if isUniqueViolation(err) {
+		return ErrAlreadyReposted
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not insert post: %v", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) InsertPostMedia(ctx context.Context, postID int64, files []mediaFile) error {
+	query := `
+		INSERT INTO post_media (post_id, position, name, width, height, medium_width, medium_height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	for i, mf := range files {
+		if _, err := s.q.ExecContext(ctx, query, postID, i, mf.name, mf.width, mf.height, mf.mediumWidth, mf.mediumHeight); err != nil {
+			return fmt.Errorf("could not insert post media: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *pgStore) PostByID(ctx context.Context, id int64) (Post, error) {
+	p := Post{ID: id}
+	query := "SELECT user_id, repost_of_id, content FROM posts WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, id).Scan(&p.UserID, &p.RepostOfID, &p.Content)
+	if err == sql.ErrNoRows {
+		return p, ErrPostNotFound
+	}
+
+	if err != nil {
+		return p, fmt.Errorf("could not query select post: %v", err)
+	}
+
+	return p, nil
+}
+
+func (s *pgStore) Post(ctx context.Context, viewerID, id int64) (Post, error) {
+	var p Post
+	auth := viewerID != 0
+	query, args, err := buildQuery(`
+		SELECT posts.id, posts.user_id, content, spoiler_of, nsfw, likes_count, comments_count, reposts_count, repost_of_id, created_at
+		, users.username, users.avatar
+		{{if .auth}}
+		, posts.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		{{end}}
+		FROM posts
+		INNER JOIN users ON posts.user_id = users.id
+		{{if .auth}}
+		LEFT JOIN post_likes AS likes
+			ON likes.user_id = @uid AND likes.post_id = posts.id
+		{{end}}
+		WHERE posts.id = @post_id
+		AND (
+			posts.hidden_at IS NULL
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		AND (
+			users.private = false
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = posts.user_id)
+			{{end}}
+		)
+		{{if .auth}}AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id = posts.user_id) OR (blocker_id = posts.user_id AND blocked_id = @uid)
+		){{end}}
+	`, map[string]interface{}{
+		"auth":    auth,
+		"uid":     viewerID,
+		"post_id": id,
+	})
+	if err != nil {
+		return p, fmt.Errorf("could not build post sql query: %v", err)
+	}
+
+	var u User
+	var avatar sql.NullString
+	dest := []interface{}{&p.ID, &p.UserID, &p.Content, &p.SpoilerOf, &p.NSFW, &p.LikesCount, &p.CommentsCount, &p.RepostsCount, &p.RepostOfID, &p.CreatedAt, &u.UserName, &avatar}
+	if auth {
+		dest = append(dest, &p.Mine, &p.Liked)
+	}
+	err = s.q.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return p, ErrPostNotFound
+	}
+
+	if err != nil {
+		return p, fmt.Errorf("could not query select post: %v", err)
+	}
+
+	u.avatarName = avatar.String
+	p.User = &u
+	return p, nil
+}
+
+func (s *pgStore) Posts(ctx context.Context, viewerID int64, username string, last int, before int64) ([]Post, error) {
+	auth := viewerID != 0
+	query, args, err := buildQuery(`
+		SELECT id, content, spoiler_of, nsfw, likes_count, comments_count, reposts_count, repost_of_id, created_at
+		{{if .auth}}
+		, posts.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		{{end}}
+		FROM posts
+		{{if .auth}}
+		LEFT JOIN post_likes AS likes
+			ON likes.user_id = @uid AND likes.post_id = posts.id
+		{{end}}
+		WHERE posts.user_id = (SELECT id FROM users WHERE username = @username)
+		AND (
+			posts.hidden_at IS NULL
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		AND (
+			(SELECT private FROM users WHERE username = @username) = false
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = posts.user_id)
+			{{end}}
+		)
+		{{if .auth}}AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id = posts.user_id) OR (blocker_id = posts.user_id AND blocked_id = @uid)
+		){{end}}
+		{{if .before}}AND posts.id < @before{{end}}
+		ORDER BY created_at DESC
+		LIMIT @last
+	`, map[string]interface{}{
+		"auth":     auth,
+		"uid":      viewerID,
+		"username": username,
+		"last":     last,
+		"before":   before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build posts sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select posts: %v", err)
+	}
+
+	defer rows.Close()
+
+	pp := make([]Post, 0, last)
+	for rows.Next() {
+		var p Post
+		dest := []interface{}{&p.ID, &p.Content, &p.SpoilerOf, &p.NSFW, &p.LikesCount, &p.CommentsCount, &p.RepostsCount, &p.RepostOfID, &p.CreatedAt}
+		if auth {
+			dest = append(dest, &p.Mine, &p.Liked)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan post: %v", err)
+		}
+
+		pp = append(pp, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate posts rows: %v", err)
+	}
+
+	return pp, nil
+}
+
+func (s *pgStore) PostsByIDs(ctx context.Context, viewerID int64, ids []int64) (map[int64]Post, error) {
+	pp := map[int64]Post{}
+	if len(ids) == 0 {
+		return pp, nil
+	}
+
+	auth := viewerID != 0
+	query, args, err := buildQuery(`
+		SELECT posts.id, posts.user_id, content, spoiler_of, nsfw, likes_count, comments_count, reposts_count, created_at
+		, users.username, users.avatar
+		{{if .auth}}
+		, posts.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		{{end}}
+		FROM posts
+		INNER JOIN users ON posts.user_id = users.id
+		{{if .auth}}
+		LEFT JOIN post_likes AS likes
+			ON likes.user_id = @uid AND likes.post_id = posts.id
+		{{end}}
+		WHERE posts.id = ANY(@post_ids)
+		AND (
+			posts.hidden_at IS NULL
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		AND (
+			users.private = false
+			{{if .auth}}
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = posts.user_id)
+			{{end}}
+		)
+		{{if .auth}}AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id = posts.user_id) OR (blocker_id = posts.user_id AND blocked_id = @uid)
+		){{end}}
+	`, map[string]interface{}{
+		"auth":     auth,
+		"uid":      viewerID,
+		"post_ids": pq.Array(ids),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build posts by ids sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select posts by ids: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Post
+		var u User
+		var avatar sql.NullString
+		dest := []interface{}{&p.ID, &p.UserID, &p.Content, &p.SpoilerOf, &p.NSFW, &p.LikesCount, &p.CommentsCount, &p.RepostsCount, &p.CreatedAt, &u.UserName, &avatar}
+		if auth {
+			dest = append(dest, &p.Mine, &p.Liked)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan post: %v", err)
+		}
+
+		u.avatarName = avatar.String
+		p.User = &u
+		pp[p.ID] = p
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate posts rows: %v", err)
+	}
+
+	return pp, nil
+}
+
+func (s *pgStore) PostsMedia(ctx context.Context, ids []int64) (map[int64][]mediaFile, error) {
+	mm := map[int64][]mediaFile{}
+	if len(ids) == 0 {
+		return mm, nil
+	}
+
+	query := `
+		SELECT post_id, name, width, height, medium_width, medium_height
+		FROM post_media
+		WHERE post_id = ANY($1)
+		ORDER BY post_id, position`
+	rows, err := s.q.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not query select post media: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int64
+		var mf mediaFile
+		if err = rows.Scan(&postID, &mf.name, &mf.width, &mf.height, &mf.mediumWidth, &mf.mediumHeight); err != nil {
+			return nil, fmt.Errorf("could not scan post media: %v", err)
+		}
+
+		mm[postID] = append(mm[postID], mf)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate post media rows: %v", err)
+	}
+
+	return mm, nil
+}
+
+func (s *pgStore) Reposted(ctx context.Context, userID, postID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM posts WHERE user_id = $1 AND repost_of_id = $2 AND content = '')"
+	return s.exists(ctx, "repost", query, userID, postID)
+}
+
+func (s *pgStore) AddRepostsCount(ctx context.Context, postID int64, n int) error {
+	query := "UPDATE posts SET reposts_count = reposts_count + $1 WHERE id = $2"
+	return s.exec(ctx, "update post reposts count", query, n, postID)
+}
+
+func (s *pgStore) AddCommentsCount(ctx context.Context, postID int64, n int) error {
+	query := "UPDATE posts SET comments_count = comments_count + $1 WHERE id = $2"
+	return s.exec(ctx, "update post comments count", query, n, postID)
+}
+
+func (s *pgStore) SetPostHidden(ctx context.Context, postID int64, hidden bool) error {
+	query := "UPDATE posts SET hidden_at = NULL WHERE id = $1"
+	if hidden {
+		query = "UPDATE posts SET hidden_at = now() WHERE id = $1"
+	}
+	return s.exec(ctx, "update posts hidden", query, postID)
+}
+
+func (s *pgStore) PostLiked(ctx context.Context, userID, postID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM post_likes WHERE user_id = $1 AND post_id = $2)"
+	return s.exists(ctx, "post like", query, userID, postID)
+}
+
+func (s *pgStore) PostLikesCount(ctx context.Context, postID int64) (int, error) {
+	var n int
+	query := "SELECT likes_count FROM posts WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, postID).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, ErrPostNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not query select post likes count: %v", err)
+	}
+
+	return n, nil
+}
+
+func (s *pgStore) InsertPostLike(ctx context.Context, userID, postID int64) (int, error) {
+	query := "INSERT INTO post_likes (user_id, post_id) VALUES ($1, $2)"
+	_, err := s.q.ExecContext(ctx, query, userID, postID)
+	if isForeignKeyViolation(err) {
+		return 0, ErrPostNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not insert post like: %v", err)
+	}
+
+	query = "UPDATE posts SET likes_count = likes_count + 1 WHERE id = $1 RETURNING likes_count"
+	return s.count(ctx, "and increment post likes count", query, postID)
+}
+
+func (s *pgStore) DeletePostLike(ctx context.Context, userID, postID int64) (int, error) {
+	query := "DELETE FROM post_likes WHERE user_id = $1 AND post_id = $2"
+	if err := s.exec(ctx, "delete post like", query, userID, postID); err != nil {
+		return 0, err
+	}
+
+	query = "UPDATE posts SET likes_count = likes_count - 1 WHERE id = $1 RETURNING likes_count"
+	return s.count(ctx, "and decrement post likes count", query, postID)
+}
+
+func (s *pgStore) InsertTimelineItem(ctx context.Context, userID, postID int64) (int64, error) {
+	var id int64
+	query := "INSERT INTO timeline (user_id, post_id) VALUES ($1, $2) RETURNING id"
+	if err := s.q.QueryRowContext(ctx, query, userID, postID).Scan(&id); err != nil {
+		return 0, fmt.Errorf("could not insert timeline item: %v", err)
+	}
+
+	return id, nil
+}
+
+func (s *pgStore) TimelineItemID(ctx context.Context, userID, postID int64) (int64, error) {
+	var id int64
+	query := "SELECT id FROM timeline WHERE user_id = $1 AND post_id = $2"
+	err := s.q.QueryRowContext(ctx, query, userID, postID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not query select timeline item id: %v", err)
+	}
+
+	return id, nil
+}
+
+func (s *pgStore) SetPostPulled(ctx context.Context, postID int64) error {
+	query := "UPDATE posts SET pulled = true WHERE id = $1"
+	return s.exec(ctx, "update pulled post", query, postID)
+}
+
+func (s *pgStore) FanoutPost(ctx context.Context, postID, authorID int64) ([]TimelineItem, error) {
+	query := "INSERT INTO timeline (user_id, post_id) " +
+		"SELECT follower_id, $1 FROM follows WHERE followee_id = $2 " +
+		"ON CONFLICT (user_id, post_id) DO NOTHING " +
+		"RETURNING id, user_id"
+	rows, err := s.q.QueryContext(ctx, query, postID, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("could not insert timeline: %v", err)
+	}
+
+	defer rows.Close()
+
+	tt := []TimelineItem{}
+	for rows.Next() {
+		var ti TimelineItem
+		if err = rows.Scan(&ti.ID, &ti.UserID); err != nil {
+			return nil, fmt.Errorf("could not scan timeline time: %v", err)
+		}
+
+		ti.PostID = postID
+		tt = append(tt, ti)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate timeline rows: %v", err)
+	}
+
+	return tt, nil
+}
+
+func (s *pgStore) Timeline(ctx context.Context, userID int64, last int, before int64) ([]TimelineItem, error) {
+	query, args, err := buildQuery(`
+		{{if .before}}
+		WITH page_cursor AS (
+			SELECT posts.created_at, posts.id FROM timeline
+			INNER JOIN posts ON posts.id = timeline.post_id
+			WHERE timeline.id = @before AND (
+				timeline.user_id = @uid
+				OR (posts.pulled AND timeline.user_id = posts.user_id AND EXISTS (
+					SELECT 1 FROM follows WHERE follows.follower_id = @uid AND follows.followee_id = posts.user_id
+				))
+			)
+		)
+		{{end}}
+		SELECT items.id, posts.id, posts.user_id, posts.content, posts.spoiler_of, posts.nsfw, posts.likes_count, posts.comments_count, posts.reposts_count, posts.repost_of_id, posts.created_at
+		, posts.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		, users.username, users.avatar
+		FROM (
+			SELECT timeline.id, timeline.post_id FROM timeline
+			WHERE timeline.user_id = @uid
+			UNION ALL
+			SELECT authored.id, pulled.id FROM posts AS pulled
+			INNER JOIN follows ON follows.followee_id = pulled.user_id AND follows.follower_id = @uid
+			INNER JOIN timeline AS authored ON authored.user_id = pulled.user_id AND authored.post_id = pulled.id
+			WHERE pulled.pulled
+		) AS items
+		INNER JOIN posts ON items.post_id = posts.id
+		INNER JOIN users ON posts.user_id = users.id
+		LEFT JOIN post_likes AS likes
+			ON likes.user_id = @uid AND likes.post_id = posts.id
+		LEFT JOIN posts AS originals ON originals.id = posts.repost_of_id
+		{{if .before}}CROSS JOIN page_cursor{{end}}
+		WHERE (
+			posts.hidden_at IS NULL
+			OR posts.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id IN (posts.user_id, originals.user_id))
+				OR (blocked_id = @uid AND blocker_id IN (posts.user_id, originals.user_id))
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM mutes
+			WHERE muter_id = @uid AND muted_id IN (posts.user_id, originals.user_id)
+		)
+		{{if .before}}AND (posts.created_at, posts.id) < (page_cursor.created_at, page_cursor.id){{end}}
+		ORDER BY posts.created_at DESC, posts.id DESC
+		LIMIT @last
+	`, map[string]interface{}{
+		"uid":    userID,
+		"last":   last,
+		"before": before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build timeline sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select timeline: %v", err)
+	}
+
+	defer rows.Close()
+
+	tt := make([]TimelineItem, 0, last)
+	for rows.Next() {
+		var ti TimelineItem
+		var u User
+		var avatar sql.NullString
+		dest := []interface{}{
+			&ti.ID,
+			&ti.Post.ID,
+			&ti.Post.UserID,
+			&ti.Post.Content,
+			&ti.Post.SpoilerOf,
+			&ti.Post.NSFW,
+			&ti.Post.LikesCount,
+			&ti.Post.CommentsCount,
+			&ti.Post.RepostsCount,
+			&ti.Post.RepostOfID,
+			&ti.Post.CreatedAt,
+			&ti.Post.Mine,
+			&ti.Post.Liked,
+			&u.UserName,
+			&avatar}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan timeline: %v", err)
+		}
+
+		u.avatarName = avatar.String
+		ti.UserID = userID
+		ti.PostID = ti.Post.ID
+		ti.Post.User = &u
+		tt = append(tt, ti)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate timeline rows: %v", err)
+	}
+
+	return tt, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+func (s *pgStore) InsertReport(ctx context.Context, reporterID int64, r *Report) error {
+	// 자동 필터 신고는 신고자가 없음
+	var reporter sql.NullInt64
+	if reporterID != 0 {
+		reporter = sql.NullInt64{Int64: reporterID, Valid: true}
+	}
+
+	query := `
+		INSERT INTO reports (reporter_id, target_type, target_id, reason, note) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at`
+	err := s.q.QueryRowContext(ctx, query, reporter, r.TargetType, r.TargetID, r.Reason, r.Note).Scan(&r.ID, &r.Status, &r.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyReported
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not insert report: %v", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) Reports(ctx context.Context, status string, last int, before int64) ([]Report, error) {
+	query, args, err := buildQuery(`
+		SELECT reports.id, target_type, target_id, reason, note, status, created_at, resolved_at
+		, users.username, users.avatar
+		FROM reports
+		LEFT JOIN users ON reports.reporter_id = users.id
+		WHERE status = @status
+		{{if .before}}AND reports.id < @before{{end}}
+		ORDER BY reports.id DESC
+		LIMIT @last`, map[string]interface{}{
+		"status": status,
+		"last":   last,
+		"before": before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build reports sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select reports: %v", err)
+	}
+
+	defer rows.Close()
+
+	rr := make([]Report, 0, last)
+	for rows.Next() {
+		var r Report
+		var username, avatar sql.NullString
+		if err = rows.Scan(&r.ID, &r.TargetType, &r.TargetID, &r.Reason, &r.Note, &r.Status, &r.CreatedAt, &r.ResolvedAt, &username, &avatar); err != nil {
+			return nil, fmt.Errorf("could not scan report: %v", err)
+		}
+
+		if username.Valid {
+			r.Reporter = &User{UserName: username.String, avatarName: avatar.String}
+		}
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate report rows: %v", err)
+	}
+
+	return rr, nil
+}
+
+func (s *pgStore) Report(ctx context.Context, id int64) (Report, error) {
+	r := Report{ID: id}
+	query := "SELECT target_type, target_id, status FROM reports WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, id).Scan(&r.TargetType, &r.TargetID, &r.Status)
+	if err == sql.ErrNoRows {
+		return r, ErrReportNotFound
+	}
+
+	if err != nil {
+		return r, fmt.Errorf("could not query select report: %v", err)
+	}
+
+	return r, nil
+}
+
+func (s *pgStore) SetReportStatus(ctx context.Context, reportID int64, status string) error {
+	query := "UPDATE reports SET status = $1, resolved_at = now() WHERE id = $2"
+	return s.exec(ctx, "update report status", query, status, reportID)
+}
+
+func (s *pgStore) CloseReports(ctx context.Context, reportID int64, targetType string, targetID int64, status string) error {
+	query := `UPDATE reports SET status = $1, resolved_at = now()
+		WHERE (id = $2 OR (target_type = $3 AND target_id = $4)) AND status = 'open'`
+	return s.exec(ctx, "update report status", query, status, reportID, targetType, targetID)
+}
+
+func (s *pgStore) LastModerationAction(ctx context.Context, targetType string, targetID int64) (string, error) {
+	var action string
+	query := `SELECT action FROM moderation_actions
+		WHERE target_type = $1 AND target_id = $2 AND action IN ($3, $4, $5)
+		ORDER BY id DESC LIMIT 1`
+	err := s.q.QueryRowContext(ctx, query, targetType, targetID, ModerationHide, ModerationSuspend, ModerationRestore).Scan(&action)
+	if err == sql.ErrNoRows || action == ModerationRestore {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not query select last moderation action: %v", err)
+	}
+
+	return action, nil
+}
+
+func (s *pgStore) InsertModerationAction(ctx context.Context, a ModerationAction) error {
+	query := `INSERT INTO moderation_actions (moderator_id, report_id, action, target_type, target_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	return s.exec(ctx, "insert moderation action", query, a.ModeratorID, a.ReportID, a.Action, a.TargetType, a.TargetID, a.Note)
+}
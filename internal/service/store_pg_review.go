@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+func (s *pgStore) Purchased(ctx context.Context, userID, postID int64) (bool, error) {
+	var purchased bool
+	query := "SELECT EXISTS (SELECT 1 FROM buy_record WHERE user_id = $1 AND post_id = $2)"
+	if err := s.q.QueryRowContext(ctx, query, userID, postID).Scan(&purchased); err != nil {
+		return false, fmt.Errorf("could not query select purchase existence: %v", err)
+	}
+
+	return purchased, nil
+}
+
+func (s *pgStore) InsertReview(ctx context.Context, r *Review) error {
+	query := `
+		INSERT INTO reviews (user_id, post_id, rating, content) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err := s.q.QueryRowContext(ctx, query, r.UserID, r.PostID, r.Rating, r.Content).Scan(&r.ID, &r.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyReviewed
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not insert review: %v", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) ReviewByID(ctx context.Context, id int64) (Review, error) {
+	r := Review{ID: id}
+	query := "SELECT user_id, post_id FROM reviews WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, id).Scan(&r.UserID, &r.PostID)
+	if err == sql.ErrNoRows {
+		return r, ErrReviewNotFound
+	}
+
+	if err != nil {
+		return r, fmt.Errorf("could not query select review: %v", err)
+	}
+
+	return r, nil
+}
+
+// 후기 자체의 숨김과 차단을 본 뒤 게시물은 Post의 규칙으로
+func (s *pgStore) Review(ctx context.Context, viewerID, id int64) (Review, error) {
+	r := Review{ID: id}
+	query, args, err := buildQuery(`
+		SELECT user_id, post_id FROM reviews
+		WHERE id = @review_id
+		AND (
+			hidden_at IS NULL
+			{{if .auth}}
+			OR user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		{{if .auth}}AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id = reviews.user_id)
+				OR (blocked_id = @uid AND blocker_id = reviews.user_id)
+		){{end}}`, map[string]interface{}{
+		"auth":      viewerID != 0,
+		"uid":       viewerID,
+		"review_id": id,
+	})
+	if err != nil {
+		return r, fmt.Errorf("could not build review sql query: %v", err)
+	}
+
+	err = s.q.QueryRowContext(ctx, query, args...).Scan(&r.UserID, &r.PostID)
+	if err == sql.ErrNoRows {
+		return r, ErrReviewNotFound
+	}
+
+	if err != nil {
+		return r, fmt.Errorf("could not query select review: %v", err)
+	}
+
+	if _, err = s.Post(ctx, viewerID, r.PostID); err == ErrPostNotFound {
+		return r, ErrReviewNotFound
+	}
+
+	return r, err
+}
+
+func (s *pgStore) Reviews(ctx context.Context, viewerID, postID int64, last int, before int64) ([]Review, error) {
+	auth := viewerID != 0
+	query, args, err := buildQuery(`
+		SELECT reviews.id, reviews.post_id, reviews.rating, reviews.content, reviews.created_at, users.username, users.avatar
+		{{if .auth}}, reviews.user_id = @uid AS mine{{end}}
+		FROM reviews
+		INNER JOIN users ON reviews.user_id = users.id
+		WHERE reviews.post_id = @post_id
+		AND (
+			reviews.hidden_at IS NULL
+			{{if .auth}}
+			OR reviews.user_id = @uid
+			OR EXISTS (SELECT 1 FROM users WHERE id = @uid AND admin)
+			{{end}}
+		)
+		{{if .auth}}AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = @uid AND blocked_id = reviews.user_id)
+				OR (blocked_id = @uid AND blocker_id = reviews.user_id)
+		){{end}}
+		{{if .before}}AND reviews.id < @before{{end}}
+		ORDER BY reviews.id DESC
+		LIMIT @last`, map[string]interface{}{
+		"auth":    auth,
+		"uid":     viewerID,
+		"post_id": postID,
+		"last":    last,
+		"before":  before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build reviews sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select reviews: %v", err)
+	}
+
+	defer rows.Close()
+
+	rr := make([]Review, 0, last)
+	for rows.Next() {
+		var r Review
+		var u User
+		var avatar sql.NullString
+		dest := []interface{}{&r.ID, &r.PostID, &r.Rating, &r.Content, &r.CreatedAt, &u.UserName, &avatar}
+		if auth {
+			dest = append(dest, &r.Mine)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan review: %v", err)
+		}
+
+		u.avatarName = avatar.String
+		r.User = &u
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate review rows: %v", err)
+	}
+
+	return rr, nil
+}
+
+func (s *pgStore) SetReviewHidden(ctx context.Context, reviewID int64, hidden bool) error {
+	query := "UPDATE reviews SET hidden_at = NULL WHERE id = $1"
+	if hidden {
+		query = "UPDATE reviews SET hidden_at = now() WHERE id = $1"
+	}
+	return s.exec(ctx, "update reviews hidden", query, reviewID)
+}
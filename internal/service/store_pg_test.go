@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// 트랜잭션 재시도를 보는 가짜 드라이버, UPDATE가 fail번 err로 실패
+type txTestConnector struct {
+	fail  int
+	err   error
+	stmts []string
+}
+
+func (c *txTestConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return txTestConn{c}, nil
+}
+
+func (c *txTestConnector) Driver() driver.Driver {
+	return nil
+}
+
+type txTestConn struct{ c *txTestConnector }
+
+func (c txTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c txTestConn) Close() error {
+	return nil
+}
+
+func (c txTestConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c txTestConn) Commit() error {
+	c.c.stmts = append(c.c.stmts, "COMMIT")
+	return nil
+}
+
+func (c txTestConn) Rollback() error {
+	c.c.stmts = append(c.c.stmts, "ROLLBACK")
+	return nil
+}
+
+func (c txTestConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.c.stmts = append(c.c.stmts, query)
+	if strings.HasPrefix(query, "UPDATE") && c.c.fail > 0 {
+		c.c.fail--
+		return nil, c.c.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func TestRunTx(t *testing.T) {
+	restart := &pq.Error{Code: "40001", Message: "restart transaction: TransactionRetryWithProtoRefreshError"}
+	tests := []struct {
+		name     string
+		fail     int
+		err      error
+		calls    int
+		retries  int64
+		commits  int64
+		exhaust  int64
+		wantFail bool
+	}{
+		{name: "no conflict", calls: 1, commits: 1},
+		{name: "retried", fail: 2, err: restart, calls: 3, retries: 2, commits: 1},
+		{name: "exhausted", fail: maxTxAttempts, err: restart, calls: maxTxAttempts, retries: maxTxAttempts - 1, exhaust: 1, wantFail: true},
+		{name: "other sqlstate", fail: 1, err: &pq.Error{Code: "23505"}, calls: 1, wantFail: true},
+		// 메시지만 같은 에러는 재시도하지 않음
+		{name: "not a pq error", fail: 1, err: errors.New("restart transaction"), calls: 1, wantFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &txTestConnector{fail: tt.fail, err: tt.err}
+			db := sql.OpenDB(c)
+			defer db.Close()
+
+			before := map[string]int64{}
+			for _, key := range []string{"retries", "commits", "exhausted"} {
+				before[key] = metricValue(txMetrics, key)
+			}
+
+			calls := 0
+			err := NewPGStore(db).Tx(context.Background(), func(st Store) error {
+				calls++
+				// 저장소 메서드처럼 %v로 감싸도 쿼리에서 본 40001로 재시도
+				return st.(*pgStore).exec(context.Background(), "update test", "UPDATE test SET n = n + 1")
+			})
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("err = %v, want failure %v", err, tt.wantFail)
+			}
+
+			if calls != tt.calls {
+				t.Errorf("fn ran %d times, want %d", calls, tt.calls)
+			}
+
+			for key, want := range map[string]int64{"retries": tt.retries, "commits": tt.commits, "exhausted": tt.exhaust} {
+				if got := metricValue(txMetrics, key) - before[key]; got != want {
+					t.Errorf("%s metric +%d, want +%d", key, got, want)
+				}
+			}
+
+			rollbacks := 0
+			for _, stmt := range c.stmts {
+				if stmt == "ROLLBACK TO SAVEPOINT "+txSavepoint {
+					rollbacks++
+				}
+			}
+			if int64(rollbacks) != tt.retries {
+				t.Errorf("rolled back to savepoint %d times, want %d: %v", rollbacks, tt.retries, c.stmts)
+			}
+
+			if last := c.stmts[len(c.stmts)-1]; (last == "COMMIT") == tt.wantFail {
+				t.Errorf("statements = %v", c.stmts)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&pq.Error{Code: "40001"}, true},
+		{fmt.Errorf("could not insert post: %w", &pq.Error{Code: "40001"}), true},
+		{&pq.Error{Code: "40P01"}, false},
+		{errors.New("restart transaction"), false},
+		// %v로 감싼 에러는 txQuerier가 쿼리에서 확인
+		{fmt.Errorf("could not insert post: %v", &pq.Error{Code: "40001"}), false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+func (s *pgStore) InsertUser(ctx context.Context, email, username string) error {
+	query := "INSERT INTO users (email, username) VALUES ($1, $2)"
+	_, err := s.q.ExecContext(ctx, query, email, username)
+	unique := isUniqueViolation(err)
+
+	//동일한 데이터를 입력했을때
+	if unique && strings.Contains(err.Error(), "email") {
+		return ErrEmailTaken
+	}
+
+	if unique && strings.Contains(err.Error(), "username") {
+		return ErrUsernameTaken
+	}
+
+	//그 외 오류
+	if err != nil {
+		return fmt.Errorf("could not insert user: %v", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) UserByID(ctx context.Context, id int64) (User, error) {
+	var u User
+	var avatar sql.NullString
+	query := "SELECT username, avatar FROM users WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, id).Scan(&u.UserName, &avatar)
+	if err == sql.ErrNoRows {
+		return u, ErrUserNotFound
+	}
+
+	if err != nil {
+		return u, fmt.Errorf("could not query select user: %v", err)
+	}
+
+	u.ID = id
+	u.avatarName = avatar.String
+	return u, nil
+}
+
+func (s *pgStore) UserByEmail(ctx context.Context, email string) (User, error) {
+	var u User
+	var avatar sql.NullString
+	query := "SELECT id, username, avatar FROM users WHERE email = $1"
+	err := s.q.QueryRowContext(ctx, query, email).Scan(&u.ID, &u.UserName, &avatar)
+	if err == sql.ErrNoRows {
+		return u, ErrUserNotFound
+	}
+
+	if err != nil {
+		return u, fmt.Errorf("could not query select user: %v", err)
+	}
+
+	u.avatarName = avatar.String
+	return u, nil
+}
+
+func (s *pgStore) UserIDByUsername(ctx context.Context, username string) (int64, error) {
+	var id int64
+	query := "SELECT id FROM users WHERE username = $1"
+	err := s.q.QueryRowContext(ctx, query, username).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not query select user id from username: %v", err)
+	}
+
+	return id, nil
+}
+
+func (s *pgStore) UsersByIDs(ctx context.Context, ids []int64) (map[int64]User, error) {
+	uu := map[int64]User{}
+	if len(ids) == 0 {
+		return uu, nil
+	}
+
+	query := "SELECT id, username, avatar FROM users WHERE id = ANY($1)"
+	rows, err := s.q.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not query select users by ids: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var u User
+		var avatar sql.NullString
+		if err = rows.Scan(&u.ID, &u.UserName, &avatar); err != nil {
+			return nil, fmt.Errorf("could not scan user: %v", err)
+		}
+
+		u.avatarName = avatar.String
+		uu[u.ID] = u
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate user rows: %v", err)
+	}
+
+	return uu, nil
+}
+
+func (s *pgStore) UserFlags(ctx context.Context, id int64) (UserFlags, error) {
+	var f UserFlags
+	query := "SELECT private, admin, suspended_at IS NOT NULL FROM users WHERE id = $1"
+	err := s.q.QueryRowContext(ctx, query, id).Scan(&f.Private, &f.Admin, &f.Suspended)
+	if err != nil && err != sql.ErrNoRows {
+		return f, fmt.Errorf("could not query select user flags: %v", err)
+	}
+
+	return f, nil
+}
+
+func (s *pgStore) UserProfile(ctx context.Context, viewerID int64, username string) (UserProfile, error) {
+	var u UserProfile
+	auth := viewerID != 0
+
+	//인증된 요청만 처리하도록 조건 설정
+	//only authenticated request && query dynamically
+	var avatar sql.NullString
+	args := []interface{}{username}
+	dest := []interface{}{&u.ID, &u.Email, &avatar, &u.FollowersCount, &u.FolloweesCount, &u.Private}
+	query := "SELECT id, email, avatar, followers_count, followees_count, private "
+	if auth {
+		query += ", " +
+			"followers.follower_id IS NOT NULL AS following, " +
+			"followees.followee_id IS NOT NULL AS followeed, " +
+			"EXISTS (SELECT 1 FROM follow_requests WHERE follower_id = $2 AND followee_id = users.id) AS requested "
+		dest = append(dest, &u.Following, &u.Followeed, &u.Requested)
+	}
+	query += "FROM users "
+	if auth {
+		query += "LEFT JOIN follows AS followers ON followers.follower_id = $2 AND followers.followee_id = users.id " +
+			"LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = $2 "
+		args = append(args, viewerID)
+	}
+	query += "WHERE username = $1"
+	if auth {
+		// 차단 관계면 프로필을 볼 수 없음
+		query += " AND NOT EXISTS (SELECT 1 FROM blocks " +
+			"WHERE (blocker_id = $2 AND blocked_id = users.id) OR (blocker_id = users.id AND blocked_id = $2))"
+	}
+	err := s.q.QueryRowContext(ctx, query, args...).Scan(dest...)
+
+	if err == sql.ErrNoRows {
+		return u, ErrUserNotFound
+	}
+
+	if err != nil {
+		return u, fmt.Errorf("could not query select user: %v", err)
+	}
+
+	u.UserName = username
+	u.avatarName = avatar.String
+	return u, nil
+}
+
+func (s *pgStore) Users(ctx context.Context, viewerID int64, search string, first int, after string) ([]UserProfile, error) {
+	//인증된 유저의 요청만 처리하도록 하는 기능
+	//USer()와 다른 방식 - go template
+	query, args, err := buildQuery(`
+	SELECT id, email, username, avatar, followers_count, followees_count, private
+	{{if .auth}}
+	, followers.follower_id IS NOT NULL AS following
+	, followees.followee_id IS NOT NULL AS followeed
+	{{end}}
+	FROM users
+	{{if .auth}}
+	LEFT JOIN follows AS followers ON followers.follower_id = @uid AND followers.followee_id = users.id
+	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
+	{{end}}
+	WHERE true
+	{{if .auth}}AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = @uid AND blocked_id = users.id) OR (blocker_id = users.id AND blocked_id = @uid)
+	){{end}}
+	{{if .search}}AND username ILIKE '%' || @search || '%'{{end}}
+	{{if .after}}AND username > @after{{end}}
+	ORDER BY username ASC
+	LIMIT @first`, map[string]interface{}{
+		"auth":   viewerID != 0,
+		"uid":    viewerID,
+		"search": search,
+		"first":  first,
+		"after":  after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build users sql query: %v", err)
+	}
+
+	log.Printf("users query: %s\nargs: %v\n", query, args)
+
+	return s.userProfiles(ctx, "users", viewerID != 0, first, query, args...)
+}
+
+// 유저 목록 쿼리 결과
+func (s *pgStore) userProfiles(ctx context.Context, what string, auth bool, first int, query string, args ...interface{}) ([]UserProfile, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select %s: %v", what, err)
+	}
+
+	defer rows.Close()
+	uu := make([]UserProfile, 0, first)
+	for rows.Next() {
+		var u UserProfile
+		var avatar sql.NullString
+		dest := []interface{}{&u.ID, &u.Email, &u.UserName, &avatar, &u.FollowersCount, &u.FolloweesCount, &u.Private}
+		if auth {
+			dest = append(dest, &u.Following, &u.Followeed)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan %s: %v", what, err)
+		}
+
+		u.avatarName = avatar.String
+		uu = append(uu, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate %s rows: %v", what, err)
+	}
+
+	return uu, nil
+}
+
+func (s *pgStore) SetAvatar(ctx context.Context, userID int64, avatar string) (string, error) {
+	var oldAvatar sql.NullString
+	if err := s.q.QueryRowContext(ctx, `
+		UPDATE users SET avatar = $1 WHERE id = $2
+		RETURNING (SELECT avatar FROM users WHERE id = $2) AS old_avatar`, avatar, userID).Scan(&oldAvatar); err != nil {
+		return "", fmt.Errorf("could not update avatar: %v", err)
+	}
+
+	return oldAvatar.String, nil
+}
+
+func (s *pgStore) SetPrivate(ctx context.Context, userID int64, private bool) error {
+	return s.exec(ctx, "update user private", "UPDATE users SET private = $1 WHERE id = $2", private, userID)
+}
+
+func (s *pgStore) SetSuspended(ctx context.Context, userID int64, suspended bool) error {
+	query := "UPDATE users SET suspended_at = NULL WHERE id = $1"
+	if suspended {
+		query = "UPDATE users SET suspended_at = now() WHERE id = $1"
+	}
+	return s.exec(ctx, "update user suspended", query, userID)
+}
+
+func (s *pgStore) Followers(ctx context.Context, viewerID int64, username string, first int, after string) ([]UserProfile, error) {
+	query, args, err := buildQuery(`
+	SELECT id, email, username, avatar, followers_count, followees_count, private
+	{{if .auth}}
+	, followers.follower_id IS NOT NULL AS following
+	, followees.followee_id IS NOT NULL AS followeed
+	{{end}}
+	FROM follows
+	INNER JOIN users ON follows.follower_id = users.id
+	{{if .auth}}
+	LEFT JOIN follows AS followers ON followers.follower_id = @uid AND followers.followee_id = users.id
+	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
+	{{end}}
+	WHERE follows.followee_id = (SELECT id FROM users WHERE username = @username)
+	{{if .auth}}AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = @uid AND blocked_id IN (users.id, follows.followee_id))
+			OR (blocked_id = @uid AND blocker_id IN (users.id, follows.followee_id))
+	){{end}}
+	{{if .after}}AND username > @after{{end}}
+	ORDER BY username ASC
+	LIMIT @first`, map[string]interface{}{
+		"auth":     viewerID != 0,
+		"uid":      viewerID,
+		"username": username,
+		"first":    first,
+		"after":    after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build followers sql query: %v", err)
+	}
+
+	log.Printf("users query: %s\nargs: %v\n", query, args)
+
+	return s.userProfiles(ctx, "followers", viewerID != 0, first, query, args...)
+}
+
+func (s *pgStore) Followees(ctx context.Context, viewerID int64, username string, first int, after string) ([]UserProfile, error) {
+	query, args, err := buildQuery(`
+	SELECT id, email, username, avatar, followers_count, followees_count, private
+	{{if .auth}}
+	, followers.follower_id IS NOT NULL AS following
+	, followees.followee_id IS NOT NULL AS followeed
+	{{end}}
+	FROM follows
+	INNER JOIN users ON follows.followee_id = users.id
+	{{if .auth}}
+	LEFT JOIN follows AS followers ON followers.follower_id = @uid AND followers.followee_id = users.id
+	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
+	{{end}}
+	WHERE follows.follower_id = (SELECT id FROM users WHERE username = @username)
+	{{if .auth}}AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = @uid AND blocked_id IN (users.id, follows.follower_id))
+			OR (blocked_id = @uid AND blocker_id IN (users.id, follows.follower_id))
+	){{end}}
+	{{if .after}}AND username > @after{{end}}
+	ORDER BY username ASC
+	LIMIT @first`, map[string]interface{}{
+		"auth":     viewerID != 0,
+		"uid":      viewerID,
+		"username": username,
+		"first":    first,
+		"after":    after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build followees sql query: %v", err)
+	}
+
+	log.Printf("users query: %s\nargs: %v\n", query, args)
+
+	return s.userProfiles(ctx, "followees", viewerID != 0, first, query, args...)
+}
+
+func (s *pgStore) Following(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)"
+	return s.exists(ctx, "follow", query, followerID, followeeID)
+}
+
+// 팔로우 추가와 카운트 증가
+func (s *pgStore) InsertFollow(ctx context.Context, followerID, followeeID int64) error {
+	query := "INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)"
+	if err := s.exec(ctx, "insert follow", query, followerID, followeeID); err != nil {
+		return err
+	}
+
+	query = "UPDATE users SET followees_count = followees_count + 1 WHERE id = $1"
+	if err := s.exec(ctx, "update follower followees count (+)", query, followerID); err != nil {
+		return err
+	}
+
+	query = "UPDATE users SET followers_count = followers_count + 1 WHERE id = $1"
+	return s.exec(ctx, "update followee followers count (+)", query, followeeID)
+}
+
+// 팔로우 삭제와 카운트 감소, 팔로우가 없었으면 false
+func (s *pgStore) DeleteFollow(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	query := "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2"
+	res, err := s.q.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("could not delete follow : %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("could not get deleted follows count: %v", err)
+	}
+
+	if n == 0 {
+		return false, nil
+	}
+
+	query = "UPDATE users SET followees_count = followees_count - 1 WHERE id = $1"
+	if err = s.exec(ctx, "update follower followees count (-)", query, followerID); err != nil {
+		return false, err
+	}
+
+	query = "UPDATE users SET followers_count = followers_count - 1 WHERE id = $1"
+	if err = s.exec(ctx, "update followee followers count (-)", query, followeeID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *pgStore) FollowersCount(ctx context.Context, userID int64) (int, error) {
+	var n int
+	query := "SELECT followers_count FROM users WHERE id = $1"
+	if err := s.q.QueryRowContext(ctx, query, userID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("could not query select followers count: %v", err)
+	}
+
+	return n, nil
+}
+
+func (s *pgStore) FollowRequested(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM follow_requests WHERE follower_id = $1 AND followee_id = $2)"
+	return s.exists(ctx, "follow request", query, followerID, followeeID)
+}
+
+func (s *pgStore) InsertFollowRequest(ctx context.Context, followerID, followeeID int64) error {
+	query := "INSERT INTO follow_requests (follower_id, followee_id) VALUES ($1, $2)"
+	return s.exec(ctx, "insert follow request", query, followerID, followeeID)
+}
+
+func (s *pgStore) DeleteFollowRequest(ctx context.Context, followerID, followeeID int64) error {
+	query := "DELETE FROM follow_requests WHERE follower_id = $1 AND followee_id = $2"
+	return s.exec(ctx, "delete follow request", query, followerID, followeeID)
+}
+
+func (s *pgStore) FollowRequesterIDs(ctx context.Context, followeeID int64) ([]int64, error) {
+	query := "SELECT follower_id FROM follow_requests WHERE followee_id = $1"
+	rows, err := s.q.QueryContext(ctx, query, followeeID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select pending follow requests: %v", err)
+	}
+
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("could not scan follow request: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate follow request rows: %v", err)
+	}
+
+	return ids, nil
+}
+
+func (s *pgStore) FollowRequests(ctx context.Context, followeeID int64, first int, after string) ([]UserProfile, error) {
+	query, args, err := buildQuery(`
+	SELECT username, avatar, followers_count, followees_count, private
+	, followees.followee_id IS NOT NULL AS followeed
+	FROM follow_requests
+	INNER JOIN users ON follow_requests.follower_id = users.id
+	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
+	WHERE follow_requests.followee_id = @uid
+	{{if .after}}AND username > @after{{end}}
+	ORDER BY username ASC
+	LIMIT @first`, map[string]interface{}{
+		"uid":   followeeID,
+		"first": first,
+		"after": after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build follow requests sql query: %v", err)
+	}
+
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select follow requests: %v", err)
+	}
+
+	defer rows.Close()
+	uu := make([]UserProfile, 0, first)
+	for rows.Next() {
+		var u UserProfile
+		var avatar sql.NullString
+		if err = rows.Scan(&u.UserName, &avatar, &u.FollowersCount, &u.FolloweesCount, &u.Private, &u.Followeed); err != nil {
+			return nil, fmt.Errorf("could not scan follow request: %v", err)
+		}
+
+		u.avatarName = avatar.String
+		uu = append(uu, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate follow request rows: %v", err)
+	}
+
+	return uu, nil
+}
+
+func (s *pgStore) Blocked(ctx context.Context, a, b int64) (bool, error) {
+	query := `SELECT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))`
+	return s.exists(ctx, "block", query, a, b)
+}
+
+func (s *pgStore) Blocking(ctx context.Context, blockerID, blockedID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM blocks WHERE blocker_id = $1 AND blocked_id = $2)"
+	return s.exists(ctx, "block", query, blockerID, blockedID)
+}
+
+func (s *pgStore) InsertBlock(ctx context.Context, blockerID, blockedID int64) error {
+	query := "INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)"
+	return s.exec(ctx, "insert block", query, blockerID, blockedID)
+}
+
+func (s *pgStore) DeleteBlock(ctx context.Context, blockerID, blockedID int64) error {
+	query := "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2"
+	return s.exec(ctx, "delete block", query, blockerID, blockedID)
+}
+
+func (s *pgStore) Muting(ctx context.Context, muterID, mutedID int64) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1 AND muted_id = $2)"
+	return s.exists(ctx, "mute", query, muterID, mutedID)
+}
+
+func (s *pgStore) InsertMute(ctx context.Context, muterID, mutedID int64) error {
+	query := "INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2)"
+	return s.exec(ctx, "insert mute", query, muterID, mutedID)
+}
+
+func (s *pgStore) DeleteMute(ctx context.Context, muterID, mutedID int64) error {
+	query := "DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2"
+	return s.exec(ctx, "delete mute", query, muterID, mutedID)
+}
+
+func (s *pgStore) MuterIDs(ctx context.Context, mutedID int64) (map[int64]bool, error) {
+	rows, err := s.q.QueryContext(ctx, "SELECT muter_id FROM mutes WHERE muted_id = $1", mutedID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select muters: %v", err)
+	}
+
+	defer rows.Close()
+
+	ids := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("could not scan muter: %v", err)
+		}
+		ids[id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate muter rows: %v", err)
+	}
+
+	return ids, nil
+}
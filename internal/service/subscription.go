@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// 실시간 구독자, 연결이 끊기면(ctx 종료) 목록에서 빠짐
+type timelineItemClient struct {
+	ctx    context.Context
+	items  chan TimelineItem
+	userID int64
+}
+
+type notificationClient struct {
+	ctx           context.Context
+	notifications chan Notification
+	userID        int64
+}
+
+type subscriptions struct {
+	timelineItemClients sync.Map
+	notificationClients sync.Map
+}
+
+// 타임라인 구독
+// SubscribeToTimeline streams the new timeline items of the authenticated user until ctx is done.
+func (s *Service) SubscribeToTimeline(ctx context.Context) (<-chan TimelineItem, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	c := &timelineItemClient{ctx: ctx, items: make(chan TimelineItem), userID: uid}
+	s.subs.timelineItemClients.Store(c, struct{}{})
+	go func() {
+		<-ctx.Done()
+		s.subs.timelineItemClients.Delete(c)
+	}()
+
+	return c.items, nil
+}
+
+// 알림 구독
+// SubscribeToNotifications streams the new notifications of the authenticated user until ctx is done.
+func (s *Service) SubscribeToNotifications(ctx context.Context) (<-chan Notification, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(int64)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	c := &notificationClient{ctx: ctx, notifications: make(chan Notification), userID: uid}
+	s.subs.notificationClients.Store(c, struct{}{})
+	go func() {
+		<-ctx.Done()
+		s.subs.notificationClients.Delete(c)
+	}()
+
+	return c.notifications, nil
+}
+
+// 채널은 닫지 않고, 끊긴 구독자에게는 보내지 않음
+func (s *Service) broadcastTimelineItem(ti TimelineItem) {
+	s.subs.timelineItemClients.Range(func(key, _ interface{}) bool {
+		c := key.(*timelineItemClient)
+		if c.userID == ti.UserID {
+			select {
+			case c.items <- ti:
+			case <-c.ctx.Done():
+			}
+		}
+		return true
+	})
+}
+
+func (s *Service) broadcastNotification(n Notification) {
+	s.subs.notificationClients.Range(func(key, _ interface{}) bool {
+		c := key.(*notificationClient)
+		if c.userID == n.UserID {
+			select {
+			case c.notifications <- n:
+			case <-c.ctx.Done():
+			}
+		}
+		return true
+	})
+}
+
+// 작성자를 뮤트한 유저
+func (s *Service) muterIDs(ctx context.Context, userID int64) (map[int64]bool, error) {
+	return s.store.MuterIDs(ctx, userID)
+}
@@ -2,17 +2,16 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 )
 
 // TimelineItem model.
 type TimelineItem struct {
-	ID     int64 `json:"id"`
-	UserID int64 `json:"-"`
-	PostID int64 `json:"-"`
-	Post   Post  `json:"post"`
-	buyer  string
+	ID         int64 `json:"id"`
+	UserID     int64 `json:"-"`
+	PostID     int64 `json:"-"`
+	Post       Post  `json:"post"`
+	RepostedBy *User `json:"reposted_by,omitempty"`
+	buyer      string
 }
 
 // Timeline
@@ -27,71 +26,25 @@ func (s *Service) Timeline(
 	}
 	last = normailizePageSize(last)
 
-	query, args, err := buildQuery(`
-		SELECT timeline.id, posts.id, content, spoiler_of, nsfw, likes_count, comments_count, created_at
-		, posts.user_id = @uid AS mine
-		, likes.user_id IS NOT NULL AS liked
-		, users.username, users.avatar
-		FROM timeline
-		INNER JOIN posts ON timeline.post_id = posts.id
-		INNER JOIN users ON posts.user_id = users.id
-		LEFT JOIN post_likes AS likes
-			ON likes.user_id = @uid AND likes.post_id = posts.id
-		WHERE timeline.user_id = @uid
-		{{if .before}}AND timeline.id < @before{{end}}
-		ORDER BY created_at DESC
-		LIMIT @last
-	`, map[string]interface{}{
-		"uid":    uid,
-		"last":   last,
-		"before": before,
-	})
+	tt, err := s.store.Timeline(ctx, uid, last, before)
 	if err != nil {
-		return nil, fmt.Errorf("could not build timeline sql query: %v", err)
+		return nil, err
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("could not query select timeline: %v", err)
+	pp := make([]*Post, len(tt))
+	for i := range tt {
+		pp[i] = &tt[i].Post
 	}
 
-	defer rows.Close()
-
-	tt := make([]TimelineItem, 0, last)
-	for rows.Next() {
-		var ti TimelineItem
-		var u User
-		var avatar sql.NullString
-		dest := []interface{}{
-			&ti.ID,
-			&ti.Post.ID,
-			&ti.Post.Content,
-			&ti.Post.SpoilerOf,
-			&ti.Post.NSFW,
-			&ti.Post.LikesCount,
-			&ti.Post.CommentsCount,
-			&ti.Post.CreatedAt,
-			&ti.Post.Mine,
-			&ti.Post.Liked,
-			&u.UserName,
-			&avatar}
-
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan timeline: %v", err)
-		}
-
-		if avatar.Valid {
-			avatarURL := s.origin + "/img/avatars/" + avatar.String
-			u.AvatarURL = &avatarURL
-		}
-
-		ti.Post.User = &u
-
-		tt = append(tt, ti)
+	if err = s.decoratePosts(ctx, pp); err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate timeline rows: %v", err)
+	// 내용 없이 공유된 게시물은 누가 공유했는지 표시
+	for i := range tt {
+		if tt[i].Post.RepostOf != nil && tt[i].Post.Content == "" {
+			tt[i].RepostedBy = tt[i].Post.User
+		}
 	}
 
 	return tt, nil
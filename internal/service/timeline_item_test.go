@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestTimelinePagesPushedAndPulled(t *testing.T) {
+	s := newTestService(t, WithPullThreshold(2))
+	ctx := context.Background()
+	readerCtx, readerID := newTestUser(t, s, "reader")
+	pushedCtx, pushedID := newTestUser(t, s, "pushed")
+	pulledCtx, pulledID := newTestUser(t, s, "pulled")
+	_, otherID := newTestUser(t, s, "other")
+
+	// pulled는 팔로워가 기준 이상이라 읽을 때 합침
+	for _, f := range [][2]int64{{readerID, pushedID}, {readerID, pulledID}, {otherID, pulledID}} {
+		if err := s.store.InsertFollow(ctx, f[0], f[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var want []int64
+	for i, authorCtx := range []context.Context{pushedCtx, pulledCtx, readerCtx, pulledCtx, pushedCtx, pulledCtx, pushedCtx} {
+		ti, err := s.CreatePost(authorCtx, fmt.Sprintf("post %d", i), nil, false, nil)
+		if err != nil {
+			t.Fatalf("could not create post %d: %v", i, err)
+		}
+		want = append([]int64{ti.Post.ID}, want...)
+	}
+	runTestJobs(t, s)
+
+	var got []int64
+	var before int64
+	for page := 0; page < len(want); page++ {
+		tt, err := s.Timeline(readerCtx, 2, before)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tt) == 0 {
+			break
+		}
+
+		for _, ti := range tt {
+			// 넣은 항목은 읽는 사람의, 합친 항목은 작성자의 타임라인 항목 아이디
+			ownerID := readerID
+			if ti.Post.UserID == pulledID {
+				ownerID = pulledID
+			}
+
+			id, err := s.store.TimelineItemID(ctx, ownerID, ti.Post.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ti.ID != id {
+				t.Errorf("post %d has item %d, want %d", ti.Post.ID, ti.ID, id)
+			}
+			got = append(got, ti.Post.ID)
+		}
+		before = tt[len(tt)-1].ID
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("timeline posts = %v, want %v", got, want)
+	}
+
+	tt, err := s.Timeline(readerCtx, 2, before+1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tt) != 0 {
+		t.Errorf("unknown cursor gave %d items, want none", len(tt))
+	}
+}
+
+func TestTimelineLeavesOutBlockedAndMuted(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	readerCtx, readerID := newTestUser(t, s, "reader")
+	aliceCtx, aliceID := newTestUser(t, s, "alice")
+	bobCtx, bobID := newTestUser(t, s, "bob")
+	for _, followeeID := range []int64{aliceID, bobID} {
+		if err := s.store.InsertFollow(ctx, readerID, followeeID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alicePost, err := s.CreatePost(aliceCtx, "from alice", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bobPost, err := s.CreatePost(bobCtx, "from bob", nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bob이 alice의 게시물을 공유, alice를 뮤트하면 공유도 빠짐
+	if _, err = s.Repost(bobCtx, alicePost.Post.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	runTestJobs(t, s)
+
+	postIDs := func() []int64 {
+		t.Helper()
+		tt, err := s.Timeline(readerCtx, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]int64, len(tt))
+		for i, ti := range tt {
+			ids[i] = ti.Post.ID
+		}
+		return ids
+	}
+
+	if got := postIDs(); len(got) != 3 {
+		t.Fatalf("timeline = %v, want 3 posts", got)
+	}
+
+	if _, err = s.ToggleMute(readerCtx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := postIDs(); fmt.Sprint(got) != fmt.Sprint([]int64{bobPost.Post.ID}) {
+		t.Errorf("timeline with alice muted = %v, want [%d]", got, bobPost.Post.ID)
+	}
+
+	if _, err = s.ToggleBlock(bobCtx, "reader"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := postIDs(); len(got) != 0 {
+		t.Errorf("timeline with alice muted and blocked by bob = %v, want none", got)
+	}
+
+	_, err = s.Timeline(ctx, 0, 0)
+	assertError(t, err, ErrUnauthenticated)
+}
+
+// 팔로워 수가 기준 바로 아래(배포)와 기준(읽을 때 합침)일 때
+// 게시물 하나를 배포하는 비용과 팔로워 한 명의 타임라인을 읽는 비용, 메모리 저장소 기준
+func BenchmarkTimelineFanout(b *testing.B) {
+	const threshold = 500
+	for _, bc := range []struct {
+		name      string
+		followers int
+	}{
+		{"push", threshold - 1},
+		{"pull", threshold},
+	} {
+		s := newTestService(b, WithPullThreshold(threshold))
+		ctx := context.Background()
+		authorCtx, authorID := newTestUser(b, s, "author")
+
+		var readerCtx context.Context
+		for i := 0; i < bc.followers; i++ {
+			followerCtx, followerID := newTestUser(b, s, fmt.Sprintf("follower%d", i))
+			if err := s.store.InsertFollow(ctx, followerID, authorID); err != nil {
+				b.Fatal(err)
+			}
+
+			if readerCtx == nil {
+				readerCtx = followerCtx
+			}
+		}
+
+		post := func() {
+			ti, err := s.CreatePost(authorCtx, "hello", nil, false, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if err = s.dispatchPost(ctx, ti.Post.ID, authorID, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		for i := 0; i < 50; i++ {
+			post()
+		}
+
+		b.Run(bc.name+"/read", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Timeline(readerCtx, 20, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(bc.name+"/post", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				post()
+			}
+		})
+	}
+}
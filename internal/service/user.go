@@ -2,56 +2,46 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"errors"
-	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
-	"log"
-	"os"
-	"path"
+	"net/http"
 	"regexp"
 	"strings"
-
-	"github.com/disintegration/imaging"
-	gonanoid "github.com/matoous/go-nanoid"
 )
 
-// 아바타 용량 제한
-// MaxAvatarBytes to read
-const MaxAvatarBytes = 5 << 20 //5MB
-
 var (
 	//이메일 및 이름 확인(정규식 사용)
 	rxEmail    = regexp.MustCompile("^[^\\s@]+@[^\\s@]+\\.[^\\s@]+$")
 	rxUsername = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_-]{0,17}$")
-	avatarsDir = path.Join("web", "static", "img", "avatars")
 )
 
 var (
 	// ErrUserNotFound used when the user wasn't found on the db.
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound = newError(http.StatusNotFound, "user_not_found", "user not found")
 	// ErrInvalidEmail used when the mail. is not valid
-	ErrInvalidEmail = errors.New("invalid email")
+	ErrInvalidEmail = newFieldError(http.StatusUnprocessableEntity, "invalid_email", "invalid email", "email")
 	// ErrInvalidUsername used when the name. is not valid
-	ErrInvalidUsername = errors.New("invalid name")
+	ErrInvalidUsername = newFieldError(http.StatusUnprocessableEntity, "invalid_username", "invalid name", "username")
 	// ErrEmailTaken used when there is already an user registered with that email.
-	ErrEmailTaken = errors.New("email taken")
+	ErrEmailTaken = newFieldError(http.StatusConflict, "email_taken", "email taken", "email")
 	// ErrUsernameTaken used when there is already an user registered with that username.
-	ErrUsernameTaken = errors.New("username taken")
+	ErrUsernameTaken = newFieldError(http.StatusConflict, "username_taken", "username taken", "username")
 	// ErrForbiddenFollow is used when you try to following yourself
-	ErrForbiddenFollow = errors.New("cannot follow yourself")
+	ErrForbiddenFollow = newError(http.StatusConflict, "forbidden_follow", "cannot follow yourself")
 	// ErrUnsupportedAvatarFormat used for unsupported avatar format.
-	ErrUnsupportedAvatarFormat = errors.New("only png and jpeg allowed as avatar")
+	ErrUnsupportedAvatarFormat = newFieldError(http.StatusUnsupportedMediaType, "unsupported_avatar_format", "only png, jpeg, gif and webp allowed as avatar", "avatar")
+	// ErrAvatarTooLarge used when an avatar is over MaxAvatarBytes.
+	ErrAvatarTooLarge = newFieldError(http.StatusRequestEntityTooLarge, "avatar_too_large", "avatar too large", "avatar")
+	// ErrAvatarDimensionsTooLarge used when an avatar is over MaxAvatarPixels.
+	ErrAvatarDimensionsTooLarge = newFieldError(http.StatusUnprocessableEntity, "avatar_dimensions_too_large", "avatar dimensions too large", "avatar")
 )
 
 //User Model
 type User struct {
-	ID        int64   `json:"id,omitempty"` //omitempty는 필드에서 값 반환 금지
-	UserName  string  `json:"user_name"`
-	AvatarURL *string `json:"avatarUrl"`
+	ID       int64   `json:"id,omitempty"` //omitempty는 필드에서 값 반환 금지
+	UserName string  `json:"user_name"`
+	Avatar   *Avatar `json:"avatar"`
+
+	avatarName string // 저장소에 있는 아바타 이름
 }
 
 //디테일한 유저 구조체
@@ -64,46 +54,54 @@ type UserProfile struct {
 	Me             bool   `json:"me"`
 	Following      bool   `json:"following"`
 	Followeed      bool   `json:"followeed"`
+	Private        bool   `json:"private"`
+	Requested      bool   `json:"requested,omitempty"`
 }
 
 //팔로워 카운트
 //ToggleFollowOutput response
 type ToggleFollowOutput struct {
 	Following      bool `json:"following"`
+	Requested      bool `json:"requested"`
 	FollowersCount int  `json:"followers_count"`
 }
 
 // 유저 생성
 // CreateUser inserts a user int the database.
 func (s *Service) CreateUser(ctx context.Context, email, username string) error {
+	// 잘못된 입력을 한 번에 알려줌
+	var emailErr, usernameErr *Error
 	email = strings.TrimSpace(email)
 	if !rxEmail.MatchString(email) {
-		return ErrInvalidEmail
+		emailErr = ErrInvalidEmail
 	}
 
 	username = strings.TrimSpace(username)
 	if !rxUsername.MatchString(username) {
-		return ErrInvalidUsername
+		usernameErr = ErrInvalidUsername
 	}
 
-	query := "INSERT INTO users (email, username) VALUES ($1, $2)"
-	_, err := s.db.ExecContext(ctx, query, email, username)
-	unique := isUniqueViolation(err)
-
-	//동일한 데이터를 입력했을때
-	if unique && strings.Contains(err.Error(), "email") {
-		return ErrEmailTaken
+	if err := invalid(emailErr, usernameErr); err != nil {
+		return err
 	}
 
-	if unique && strings.Contains(err.Error(), "username") {
-		return ErrUsernameTaken
-	}
+	err := s.store.Tx(ctx, func(st Store) error {
+		if err := st.InsertUser(ctx, email, username); err != nil {
+			return err
+		}
+
+		uid, err := st.UserIDByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
 
-	//그 외 오류
+		return emitEvent(ctx, st, EventUserCreated, UserEvent{UserID: uid, Username: username})
+	})
 	if err != nil {
-		return fmt.Errorf("could not insert user: %v", err)
+		return err
 	}
 
+	s.wakeOutbox()
 	return nil
 }
 
@@ -113,94 +111,39 @@ func (s *Service) Users(ctx context.Context, search string, first int, after str
 	search = strings.TrimSpace(search)
 	first = normailizePageSize(first)
 	after = strings.TrimSpace(after)
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
-	//인증된 유저의 요청만 처리하도록 하는 기능
-	//USer()와 다른 방식 - go template
-	query, args, err := buildQuery(`
-	SELECT id, email, username, avatar, followers_count, followees_count
-	{{if .auth}}
-	, followers.follower_id IS NOT NULL AS following
-	, followees.followee_id IS NOT NULL AS followeed
-	{{end}}
-	FROM users
-	{{if .auth}}
-	LEFT JOIN follows AS followers ON followers.follower_id = @uid AND followers.followee_id = users.id
-	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
-	{{end}}
-	{{if or .search .after}} WHERE {{end}}
-	{{if .search}} username ILIKE '%' || @search || '%'{{end}}
-	{{if and .search .after}}AND{{end}}
-	{{if .after}}username > @after{{end}}
-	ORDER BY username ASC
-	LIMIT @first`, map[string]interface{}{
-		"auth":   auth,
-		"uid":    uid,
-		"search": search,
-		"first":  first,
-		"after":  after,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("could not build users sql query: %v", err)
-	}
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
 
-	log.Printf("users query: %s\nargs: %v\n", query, args)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	uu, err := s.store.Users(ctx, uid, search, first, after)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select users: %v", err)
+		return nil, err
 	}
 
-	defer rows.Close()
-	uu := make([]UserProfile, 0, first)
-	for rows.Next() {
-		var u UserProfile
-		var avatar sql.NullString
-		dest := []interface{}{&u.ID, &u.Email, &u.UserName, &avatar, &u.FollowersCount, &u.FolloweesCount}
-		if auth {
-			dest = append(dest, &u.Following, &u.Followeed)
-		}
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan user: %v", err)
-		}
+	s.userProfiles(uid, uu)
+	return uu, nil
+}
 
-		u.Me = auth && uid == u.ID
-		if !u.Me {
-			u.ID = 0
-			u.Email = ""
-		}
-		if avatar.Valid {
-			avatarURL := s.origin + "/img/avatars/" + avatar.String
-			u.AvatarURL = &avatarURL
-		}
-		uu = append(uu, u)
+// 여러 유저를 한 번에 불러오기
+// UsersByIDs selects the given users keyed by ID.
+func (s *Service) UsersByIDs(ctx context.Context, ids []int64) (map[int64]User, error) {
+	uu, err := s.store.UsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate user rows: %v", err)
+	for id, u := range uu {
+		s.userAvatar(&u)
+		uu[id] = u
 	}
-
 	return uu, nil
 }
 
 func (s *Service) userByID(ctx context.Context, id int64) (User, error) {
-	//Database query with the user ID in the context
-	var u User
-	var avatar sql.NullString
-	query := "SELECT username, avatar FROM users WHERE id = $1"
-	err := s.db.QueryRowContext(ctx, query, id).Scan(&u.UserName, &avatar)
-	if err == sql.ErrNoRows {
-		return u, ErrUserNotFound
-	}
-
+	u, err := s.store.UserByID(ctx, id)
 	if err != nil {
-		return u, fmt.Errorf("could not query select user: %v", err)
+		return u, err
 	}
 
-	u.ID = id
-	if avatar.Valid {
-		avatarURL := s.origin + "/img/avatars/" + avatar.String
-		u.AvatarURL = &avatarURL
-	}
+	s.userAvatar(&u)
 	return u, nil
 }
 
@@ -215,130 +158,98 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 	if !rxUsername.MatchString(username) {
 		return u, ErrInvalidUsername
 	}
-	//uid와 auth에 요청을 보낸 사람 ID 입력
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
-
-	//인증된 요청만 처리하도록 조건 설정
-	//only authenticated request && query dynamically
-	var avatar sql.NullString
-	args := []interface{}{username}
-	dest := []interface{}{&u.ID, &u.Email, &avatar, &u.FollowersCount, &u.FolloweesCount}
-	query := "SELECT id, email, avatar, followers_count, followees_count "
-	if auth {
-		query += ", " +
-			"followers.follower_id IS NOT NULL AS following, " +
-			"followees.followee_id IS NOT NULL AS followeed "
-		dest = append(dest, &u.Following, &u.Followeed)
-	}
-	query += "FROM users "
-	if auth {
-		query += "LEFT JOIN follows AS followers ON followers.follower_id = $2 AND followers.followee_id = users.id " +
-			"LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = $2 "
-		args = append(args, uid)
-		dest = append(dest)
-	}
-	query += "WHERE username = $1"
-	err := s.db.QueryRowContext(ctx, query, args...).Scan(dest...)
-
-	if err == sql.ErrNoRows {
-		return u, ErrUserNotFound
-	}
+	//uid에 요청을 보낸 사람 ID 입력
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
 
+	u, err := s.store.UserProfile(ctx, uid, username)
 	if err != nil {
-		return u, fmt.Errorf("could not query select user: %v", err)
+		return u, err
 	}
 
-	u.UserName = username
-	u.Me = auth && uid == u.ID
-	//만약 요청을 보낸 사람이 인증되지 않은 사용자이거나
-	//본인이 아닐시에는 id와 email 감추기
-	//reset user profile
-	if !u.Me {
-		u.ID = 0
-		u.Email = ""
-	}
-	if avatar.Valid {
-		avatarURL := s.origin + "/img/avatars/" + avatar.String
-		u.AvatarURL = &avatarURL
+	uu := []UserProfile{u}
+	s.userProfiles(uid, uu)
+	return uu[0], nil
+}
+
+//만약 요청을 보낸 사람이 인증되지 않은 사용자이거나
+//본인이 아닐시에는 id와 email 감추기
+//reset user profile
+func (s *Service) userProfiles(uid int64, uu []UserProfile) {
+	for i := range uu {
+		u := &uu[i]
+		u.Me = uid != 0 && uid == u.ID
+		if !u.Me {
+			u.ID = 0
+			u.Email = ""
+		}
+		s.userAvatar(&u.User)
 	}
-	return u, nil
 }
 
 // 아바타 생성
-// UpdateAvatar of the authenticated user returning the new avatar URL
-func (s *Service) UpdateAvatar(ctx context.Context, r io.Reader) (string, error) {
+// UpdateAvatar of the authenticated user returning the new avatar
+func (s *Service) UpdateAvatar(ctx context.Context, r io.Reader) (Avatar, error) {
+	var out Avatar
 	// 유저 확인
 	// checking authentication
 	uid, ok := ctx.Value(KeyAuthUserID).(int64)
 	if !ok {
-		return "", ErrUnauthenticated
-	}
-
-	// 아바타 용량 제한, 형식 제한
-	r = io.LimitReader(r, MaxAvatarBytes)
-	img, format, err := image.Decode(r)
-	if err != nil {
-		return "", fmt.Errorf("could not read avatar: %v", err)
-	}
-
-	if format != "png" && format != "jpeg" {
-		return "", ErrUnsupportedAvatarFormat
-	}
-
-	// 유저 이름에 맞는 아바타 자동 생성
-	avatar, err := gonanoid.Nanoid()
-	if err != nil {
-		return "", fmt.Errorf("could not generate avatar filename: %v", err)
-	}
-
-	// 추가한 아바타 사진 이름에 형식 추가
-	if format == "png" {
-		avatar += ".png"
-	} else {
-		avatar += ".jpg"
+		return out, ErrUnauthenticated
 	}
 
-	// 아바타 사진이 저정된 경로 불러오기
-	avatarPath := path.Join(avatarsDir, avatar)
-	f, err := os.Create(avatarPath)
+	avatar, err := s.saveAvatar(ctx, r)
 	if err != nil {
-		return "", fmt.Errorf("could not create avatar: %v", err)
+		return out, err
 	}
 
-	// 이미지 크기 변환
-	defer f.Close()
-	img = imaging.Fill(img, 400, 400, imaging.Center, imaging.CatmullRom)
+	out = *s.avatar(avatar)
 
-	if format == "png" {
-		err = png.Encode(f, img)
-	} else {
-		err = jpeg.Encode(f, img, nil)
-	}
+	//새로운 아바타가 업데이트 됐을 때 기존의 아바타 사진을 자동으로 지움
+	var oldAvatar string
+	err = s.store.Tx(ctx, func(st Store) error {
+		var err error
+		if oldAvatar, err = st.SetAvatar(ctx, uid, avatar); err != nil {
+			return err
+		}
 
+		return emitEvent(ctx, st, EventAvatarUpdated, UserEvent{UserID: uid, Avatar: out.URL})
+	})
 	if err != nil {
-		return "", fmt.Errorf("could not write avatar for disk: %v", err)
+		defer s.removeAvatar(avatar)
+		return Avatar{}, err
 	}
 
-	var oldAvatar sql.NullString
+	s.wakeOutbox()
 
-	//새로운 아바타가 업데이트 됐을 때 기존의 아바타 사진을 자동으로 지움
-	if err = s.db.QueryRowContext(ctx, `
-		UPDATE users SET avatar = $1 WHERE id = $2
-		RETURNING (SELECT avatar FROM users WHERE id = $2) AS old_avatar`, avatar, uid).Scan(&oldAvatar); err != nil {
-		defer os.Remove(avatarPath)
-		return "", fmt.Errorf("could not update avatar: %v", err)
+	if oldAvatar != "" {
+		defer s.removeAvatar(oldAvatar)
 	}
 
-	if oldAvatar.Valid {
-		defer os.Remove(path.Join(avatarsDir, oldAvatar.String))
-	}
-
-	return s.origin + "/img/avatars/" + avatar, nil
+	return out, nil
 }
 
 // 팔로워 이름을 받는 기능
 // ToggleFollow between Two users.
 func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFollowOutput, error) {
+	return s.setFollow(ctx, username, nil)
+}
+
+// 팔로우(비공개 계정은 요청), 이미 팔로우 중이거나 요청했으면 그대로
+// Follow is the idempotent form of ToggleFollow.
+func (s *Service) Follow(ctx context.Context, username string) (ToggleFollowOutput, error) {
+	follow := true
+	return s.setFollow(ctx, username, &follow)
+}
+
+// 팔로우나 팔로우 요청 취소, 둘 다 없으면 그대로
+// Unfollow is the idempotent form of ToggleFollow.
+func (s *Service) Unfollow(ctx context.Context, username string) (ToggleFollowOutput, error) {
+	follow := false
+	return s.setFollow(ctx, username, &follow)
+}
+
+// follow가 nil이면 토글
+func (s *Service) setFollow(ctx context.Context, username string, follow *bool) (ToggleFollowOutput, error) {
 	var out ToggleFollowOutput
 	// 유저 아이디 체크
 	// check for the auth user in the context
@@ -356,92 +267,116 @@ func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFoll
 
 	// 쿼리를 트랜잭션으로 보내기
 	// for queries to use transaction
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return out, fmt.Errorf("could not begin tx: %v", err)
-	}
+	var followeeID int64
+	var followed bool
+	err := s.store.Tx(ctx, func(st Store) error {
+		out = ToggleFollowOutput{}
+		followed = false
+
+		// 유저 이름으로부터 유저 아이디 받아오기
+		// Get the actual user ID from the username
+		var err error
+		followeeID, err = st.UserIDByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
 
-	defer tx.Rollback()
+		if followeeID == followerID {
+			return ErrForbiddenFollow
+		}
 
-	// 유저 이름으로부터 유저 아이디 받아오기
-	// Get the actual user ID from the username
-	var followeeID int64
-	query := "SELECT id FROM users WHERE username = $1"
-	tx.QueryRowContext(ctx, query, username).Scan(&followeeID)
-	if err == sql.ErrNoRows {
-		return out, ErrUserNotFound
-	}
+		flags, err := st.UserFlags(ctx, followeeID)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return out, fmt.Errorf("could not query select user id from followee username: %v", err)
-	}
+		// 차단 관계면 팔로우할 수 없음
+		// no follows between blocked users
+		blocked, err := st.Blocked(ctx, followerID, followeeID)
+		if err != nil {
+			return err
+		}
 
-	if followeeID == followerID {
-		return out, ErrForbiddenFollow
-	}
+		if blocked {
+			return ErrUserNotFound
+		}
 
-	// check overlap ID
-	// 팔로워 중복 확인
-	query = "SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)"
-	if err = tx.QueryRowContext(ctx, query, followerID, followeeID).Scan(&out.Following); err != nil {
-		return out, fmt.Errorf("could not query select existance of follow: %v", err)
-	}
+		// check overlap ID
+		// 팔로워 중복 확인
+		if out.Following, err = st.Following(ctx, followerID, followeeID); err != nil {
+			return err
+		}
 
-	// 팔로우를 두 번 눌렀을 때의 액션
-	// Action when pressed follow button twice
-
-	// 팔로우 취소와 팔로워 수 감소
-	// Cancle follow and decrease follow
-	if out.Following {
-		//취소
-		//cancle
-		query = "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2"
-		if _, err = tx.ExecContext(ctx, query, followerID, followeeID); err != nil {
-			return out, fmt.Errorf("could not delete follow : %v", err)
+		// 비공개 계정은 팔로우 요청
+		// private accounts get a follow request
+		if !out.Following && flags.Private {
+			if out.Requested, err = st.FollowRequested(ctx, followerID, followeeID); err != nil {
+				return err
+			}
 		}
-		//팔로위 감소
-		//followee
-		query = "UPDATE users SET followees_count = followees_count - 1 WHERE id = $1"
-		if _, err = tx.ExecContext(ctx, query, followerID); err != nil {
-			return out, fmt.Errorf("could not update follower followees count (-): %v", err)
+
+		// 팔로우를 두 번 눌렀을 때의 액션
+		// Action when pressed follow button twice
+		current := out.Following || out.Requested
+		want := !current
+		if follow != nil {
+			want = *follow
 		}
-		//팔로워 감소
-		//follower
-		query = "UPDATE users SET followers_count = followers_count - 1 WHERE id = $1 RETURNING followers_count"
-		if err = tx.QueryRowContext(ctx, query, followeeID).Scan(&out.FollowersCount); err != nil {
-			return out, fmt.Errorf("could not update followee followers count (-): %v", err)
+
+		var event string
+		switch {
+		case want == current:
+		case want && flags.Private:
+			err = st.InsertFollowRequest(ctx, followerID, followeeID)
+			out.Requested = true
+			event = EventFollowRequested
+		case want:
+			// 팔로우와 팔로워 증가
+			// increment follow and follower
+			err = st.InsertFollow(ctx, followerID, followeeID)
+			out.Following = true
+			followed = true
+			event = EventFollowed
+		case out.Following:
+			// 팔로우 취소와 팔로워 수 감소
+			// Cancle follow and decrease follow
+			_, err = st.DeleteFollow(ctx, followerID, followeeID)
+			out.Following = false
+			event = EventUnfollowed
+		default:
+			err = st.DeleteFollowRequest(ctx, followerID, followeeID)
+			out.Requested = false
+			event = EventFollowRequestDeleted
 		}
-	} else {
-		// 팔로우와 팔로워 증가
-		// increment follow and follower
-		query = "INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)"
-		if _, err = tx.ExecContext(ctx, query, followerID, followeeID); err != nil {
-			return out, fmt.Errorf("could not insert follow : %v", err)
+		if err != nil {
+			return err
 		}
-		// 팔로위 증가
-		// followee increment
-		query = "UPDATE users SET followees_count = followees_count + 1 WHERE id = $1"
-		if _, err = tx.ExecContext(ctx, query, followerID); err != nil {
-			return out, fmt.Errorf("could not update follower followees count (+): %v", err)
+
+		if event != "" {
+			if err = emitEvent(ctx, st, event, RelationEvent{UserID: followerID, TargetID: followeeID}); err != nil {
+				return err
+			}
 		}
-		// 팔로워 증가
-		// follower increment
-		query = "UPDATE users SET followers_count = followers_count + 1 WHERE id = $1 RETURNING followers_count"
-		if err = tx.QueryRowContext(ctx, query, followeeID).Scan(&out.FollowersCount); err != nil {
-			return out, fmt.Errorf("could not update followee followers count (+): %v", err)
+
+		// 팔로잉을 끊었을 시 더이상 알림 받지 않음
+		// Queue a notification
+		if followed {
+			if err = enqueueJob(ctx, st, JobNotifyFollow, notifyFollowJob{FollowerID: followerID, FolloweeID: followeeID}); err != nil {
+				return err
+			}
 		}
-	}
 
-	if err = tx.Commit(); err != nil {
-		return out, fmt.Errorf("could not commit toggle follow : %v", err)
+		out.FollowersCount, err = st.FollowersCount(ctx, followeeID)
+		return err
+	})
+	if err != nil {
+		return out, err
 	}
 
-	out.Following = !out.Following
-	// 팔로잉을 끊었을 시 더이상 알림 받지 않음
-	// Dispatch a notification
-	if out.Following {
-		go s.notifyFollow(followerID, followeeID)
+	if followed {
+		s.wakeJobs(JobNotifyFollow)
 	}
+	s.wakeOutbox()
 
 	return out, nil
 }
@@ -455,71 +390,14 @@ func (s *Service) Followers(ctx context.Context, username string, first int, aft
 	}
 	first = normailizePageSize(first)
 	after = strings.TrimSpace(after)
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
-	//인증된 유저의 요청만 처리하도록 하는 기능
-	//USer()와 다른 방식 - go template
-	query, args, err := buildQuery(`
-	SELECT id, email, username, avatar, followers_count, followees_count
-	{{if .auth}}
-	, followers.follower_id IS NOT NULL AS following
-	, followees.followee_id IS NOT NULL AS followeed
-	{{end}}
-	FROM follows
-	INNER JOIN users ON follows.follower_id = users.id
-	{{if .auth}}
-	LEFT JOIN follows AS followers ON followers.follower_id = @uid AND followers.followee_id = users.id
-	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
-	{{end}}
-	WHERE follows.followee_id = (SELECT id FROM users WHERE username = @username)
-	{{if .after}}AND username > @after{{end}}
-	ORDER BY username ASC
-	LIMIT @first`, map[string]interface{}{
-		"auth":     auth,
-		"uid":      uid,
-		"username": username,
-		"first":    first,
-		"after":    after,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("could not build followers sql query: %v", err)
-	}
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
 
-	log.Printf("users query: %s\nargs: %v\n", query, args)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	uu, err := s.store.Followers(ctx, uid, username, first, after)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select followers: %v", err)
-	}
-
-	defer rows.Close()
-	uu := make([]UserProfile, 0, first)
-	for rows.Next() {
-		var u UserProfile
-		var avatar sql.NullString
-		dest := []interface{}{&u.ID, &u.Email, &u.UserName, &avatar, &u.FollowersCount, &u.FolloweesCount}
-		if auth {
-			dest = append(dest, &u.Following, &u.Followeed)
-		}
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan follower: %v", err)
-		}
-
-		u.Me = auth && uid == u.ID
-		if !u.Me {
-			u.ID = 0
-			u.Email = ""
-		}
-		if avatar.Valid {
-			avatarURL := s.origin + "/img/avatars/" + avatar.String
-			u.AvatarURL = &avatarURL
-		}
-		uu = append(uu, u)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate follower rows: %v", err)
+		return nil, err
 	}
 
+	s.userProfiles(uid, uu)
 	return uu, nil
 }
 
@@ -532,70 +410,13 @@ func (s *Service) Followees(ctx context.Context, username string, first int, aft
 	}
 	first = normailizePageSize(first)
 	after = strings.TrimSpace(after)
-	uid, auth := ctx.Value(KeyAuthUserID).(int64)
-	//인증된 유저의 요청만 처리하도록 하는 기능
-	//USer()와 다른 방식 - go template
-	query, args, err := buildQuery(`
-	SELECT id, email, username, avatar followers_count, followees_count
-	{{if .auth}}
-	, followers.follower_id IS NOT NULL AS following
-	, followees.followee_id IS NOT NULL AS followeed
-	{{end}}
-	FROM follows
-	INNER JOIN users ON follows.followee_id = users.id
-	{{if .auth}}
-	LEFT JOIN follows AS followers ON followers.follower_id = @uid AND followers.followee_id = users.id
-	LEFT JOIN follows AS followees ON followees.follower_id = users.id AND followees.followee_id = @uid
-	{{end}}
-	WHERE follows.follower_id = (SELECT id FROM users WHERE username = @username)
-	{{if .after}}AND username > @after{{end}}
-	ORDER BY username ASC
-	LIMIT @first`, map[string]interface{}{
-		"auth":     auth,
-		"uid":      uid,
-		"username": username,
-		"first":    first,
-		"after":    after,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("could not build followees sql query: %v", err)
-	}
-
-	log.Printf("users query: %s\nargs: %v\n", query, args)
+	uid, _ := ctx.Value(KeyAuthUserID).(int64)
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	uu, err := s.store.Followees(ctx, uid, username, first, after)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select followees: %v", err)
-	}
-
-	defer rows.Close()
-	uu := make([]UserProfile, 0, first)
-	for rows.Next() {
-		var u UserProfile
-		var avatar sql.NullString
-		dest := []interface{}{&u.ID, &u.Email, &u.UserName, &avatar, &u.FollowersCount, &u.FolloweesCount}
-		if auth {
-			dest = append(dest, &u.Following, &u.Followeed)
-		}
-		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan followee: %v", err)
-		}
-
-		u.Me = auth && uid == u.ID
-		if !u.Me {
-			u.ID = 0
-			u.Email = ""
-		}
-		if avatar.Valid {
-			avatarURL := s.origin + "/img/avatars/" + avatar.String
-			u.AvatarURL = &avatarURL
-		}
-		uu = append(uu, u)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate followee rows: %v", err)
+		return nil, err
 	}
 
+	s.userProfiles(uid, uu)
 	return uu, nil
 }
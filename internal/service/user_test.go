@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestToggleFollow(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	bobCtx, _ := newTestUser(t, s, "bob")
+
+	out, err := s.ToggleFollow(aliceCtx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !out.Following || out.Requested || out.FollowersCount != 1 {
+		t.Errorf("follow = %+v, want following with 1 follower", out)
+	}
+
+	// 팔로우 알림은 작업으로
+	runTestJobs(t, s)
+	if nn, _ := s.Notifications(bobCtx, 0, 0); len(nn) != 1 || nn[0].Type != "follow" {
+		t.Errorf("notifications = %+v, want one follow", nn)
+	}
+
+	if out, err = s.ToggleFollow(aliceCtx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Following || out.Requested || out.FollowersCount != 0 {
+		t.Errorf("unfollow = %+v, want no followers", out)
+	}
+}
+
+func TestNotifyFollowUnfollowed(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	bobCtx, _ := newTestUser(t, s, "bob")
+
+	// 작업이 돌기 전에 팔로우를 취소하면 알림 없음
+	for i := 0; i < 2; i++ {
+		if _, err := s.ToggleFollow(aliceCtx, "bob"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runTestJobs(t, s)
+	if nn, _ := s.Notifications(bobCtx, 0, 0); len(nn) != 0 {
+		t.Errorf("notifications = %+v, want none", nn)
+	}
+}
+
+func TestToggleFollowPrivate(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	bobCtx, _ := newTestUser(t, s, "bob")
+	if err := s.SetPrivate(bobCtx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// 비공개 계정은 요청만, 다시 누르면 요청 취소
+	out, err := s.ToggleFollow(aliceCtx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Following || !out.Requested || out.FollowersCount != 0 {
+		t.Errorf("follow private = %+v, want requested", out)
+	}
+
+	if uu, _ := s.FollowRequests(bobCtx, 0, ""); len(uu) != 1 || uu[0].UserName != "alice" {
+		t.Errorf("follow requests = %+v, want alice", uu)
+	}
+
+	if out, err = s.ToggleFollow(aliceCtx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Following || out.Requested {
+		t.Errorf("cancel follow request = %+v", out)
+	}
+
+	if uu, _ := s.FollowRequests(bobCtx, 0, ""); len(uu) != 0 {
+		t.Errorf("follow requests after cancel = %+v, want none", uu)
+	}
+}
+
+func TestFollowIdempotent(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+	newTestUser(t, s, "bob")
+	carolCtx, _ := newTestUser(t, s, "carol")
+	if err := s.SetPrivate(carolCtx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// 두 번 보내도 한 번만 바뀌고 이벤트도 하나
+	steps := []struct {
+		name      string
+		fn        func(context.Context, string) (ToggleFollowOutput, error)
+		username  string
+		following bool
+		requested bool
+		followers int
+		event     string
+		events    int
+	}{
+		{"follow", s.Follow, "bob", true, false, 1, EventFollowed, 1},
+		{"follow again", s.Follow, "bob", true, false, 1, EventFollowed, 1},
+		{"unfollow", s.Unfollow, "bob", false, false, 0, EventUnfollowed, 1},
+		{"unfollow again", s.Unfollow, "bob", false, false, 0, EventUnfollowed, 1},
+		{"request", s.Follow, "carol", false, true, 0, EventFollowRequested, 1},
+		{"request again", s.Follow, "carol", false, true, 0, EventFollowRequested, 1},
+		{"cancel request", s.Unfollow, "carol", false, false, 0, EventFollowRequestDeleted, 1},
+		{"cancel request again", s.Unfollow, "carol", false, false, 0, EventFollowRequestDeleted, 1},
+	}
+	for _, step := range steps {
+		out, err := step.fn(aliceCtx, step.username)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if out.Following != step.following || out.Requested != step.requested || out.FollowersCount != step.followers {
+			t.Errorf("%s = %+v, want following %v, requested %v with %d followers",
+				step.name, out, step.following, step.requested, step.followers)
+		}
+
+		if n := outboxCount(s, step.event); n != step.events {
+			t.Errorf("%s: %d %s events, want %d", step.name, n, step.event, step.events)
+		}
+	}
+
+	// 자기 자신은 409
+	_, err := s.Follow(aliceCtx, "alice")
+	assertError(t, err, ErrForbiddenFollow)
+	if ErrForbiddenFollow.Status != http.StatusConflict {
+		t.Errorf("forbidden follow status = %d, want 409", ErrForbiddenFollow.Status)
+	}
+}
+
+func TestToggleFollowErrors(t *testing.T) {
+	s := newTestService(t)
+	aliceCtx, _ := newTestUser(t, s, "alice")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		username string
+		want     *Error
+	}{
+		{"unauthenticated", context.Background(), "alice", ErrUnauthenticated},
+		{"invalid username", aliceCtx, "no spaces", ErrInvalidUsername},
+		{"missing user", aliceCtx, "nobody", ErrUserNotFound},
+		{"self", aliceCtx, "alice", ErrForbiddenFollow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ToggleFollow(tt.ctx, tt.username)
+			assertError(t, err, tt.want)
+		})
+	}
+}
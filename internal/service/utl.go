@@ -62,6 +62,11 @@ func buildQuery(text string, data map[string]interface{}) (string, []interface{}
 
 }
 
+// PageSize the service uses when n items are requested.
+func PageSize(n int) int {
+	return normailizePageSize(n)
+}
+
 //limit the page sizes
 func normailizePageSize(i int) int {
 	if i == 0 {
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// 무손실(VP8L) WebP 인코더
+// subtract green, predictor 변환 후 허프만 코드만 사용 (LZ77, color cache 없음)
+// 아바타처럼 작은 이미지에 충분하고 외부 패키지 없이 사용할 수 있음
+
+const (
+	vp8lSignature      = 0x2f
+	vp8lMaxCodeLength  = 15
+	vp8lMaxCLCodeLen   = 7
+	vp8lNumCLCodes     = 19
+	vp8lGreenAlphabet  = 256 + 24
+	vp8lDistanceSymbol = 40
+	vp8lPredictorBits  = 5
+
+	vp8lPredictorTransform     = 0
+	vp8lSubtractGreenTransform = 2
+)
+
+var (
+	vp8lCodeLengthOrder = [vp8lNumCLCodes]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	// 타일마다 시도해보는 예측 모드: L, T, ClampAddSubtractFull(L, T, TL)
+	vp8lPredictorModes = []byte{1, 2, 12}
+)
+
+// encodeWebP writes img as a lossless WebP image.
+func encodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	m := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(m, m.Bounds(), img, b.Min, draw.Src)
+	width, height := m.Rect.Dx(), m.Rect.Dy()
+
+	alphaUsed := false
+	for i := 3; i < len(m.Pix); i += 4 {
+		if m.Pix[i] != 0xff {
+			alphaUsed = true
+			break
+		}
+	}
+
+	// subtract green: 빨강, 파랑에서 초록을 뺌
+	pix := m.Pix
+	for i := 0; i < len(pix); i += 4 {
+		pix[i] -= pix[i+1]
+		pix[i+2] -= pix[i+1]
+	}
+
+	modes, residuals := vp8lPredict(pix, width, height)
+
+	bw := &vp8lBitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if alphaUsed {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lSubtractGreenTransform, 2)
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lPredictorTransform, 2)
+	bw.writeBits(vp8lPredictorBits-2, 3)
+	bw.writeImage(modes, false)
+	bw.writeBits(0, 1) // no more transforms
+
+	bw.writeImage(residuals, true)
+
+	data := bw.bytes()
+	chunkLen := len(data)
+	pad := chunkLen & 1
+
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+chunkLen+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(chunkLen))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	if pad != 0 {
+		data = append(data, 0)
+	}
+
+	_, err := w.Write(data)
+	return err
+}
+
+// vp8lPredict picks a predictor mode per tile and returns the mode image and the residuals.
+func vp8lPredict(pix []byte, width, height int) ([]byte, []byte) {
+	tileSize := 1 << vp8lPredictorBits
+	tilesW := (width + tileSize - 1) / tileSize
+	tilesH := (height + tileSize - 1) / tileSize
+	modes := make([]byte, tilesW*tilesH*4)
+	residuals := make([]byte, len(pix))
+
+	for ty := 0; ty < tilesH; ty++ {
+		for tx := 0; tx < tilesW; tx++ {
+			best, bestCost := vp8lPredictorModes[0], -1
+			for _, mode := range vp8lPredictorModes {
+				cost := 0
+				for y := ty * tileSize; y < height && y < (ty+1)*tileSize; y++ {
+					for x := tx * tileSize; x < width && x < (tx+1)*tileSize; x++ {
+						pred := vp8lPredictPixel(pix, width, x, y, mode)
+						p := (y*width + x) * 4
+						for c := 0; c < 4; c++ {
+							cost += absResidual(pix[p+c] - pred[c])
+						}
+					}
+				}
+				if bestCost == -1 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[(ty*tilesW+tx)*4+1] = best
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mode := modes[((y>>vp8lPredictorBits)*tilesW+x>>vp8lPredictorBits)*4+1]
+			pred := vp8lPredictPixel(pix, width, x, y, mode)
+			p := (y*width + x) * 4
+			for c := 0; c < 4; c++ {
+				residuals[p+c] = pix[p+c] - pred[c]
+			}
+		}
+	}
+
+	return modes, residuals
+}
+
+// 첫 픽셀은 불투명한 검정, 첫 줄은 왼쪽, 첫 열은 위쪽 픽셀로 예측
+func vp8lPredictPixel(pix []byte, width, x, y int, mode byte) [4]byte {
+	p := (y*width + x) * 4
+	switch {
+	case x == 0 && y == 0:
+		return [4]byte{0, 0, 0, 0xff}
+	case y == 0:
+		mode = 1
+	case x == 0:
+		mode = 2
+	}
+
+	l, t, tl := p-4, p-width*4, p-width*4-4
+	var pred [4]byte
+	for c := 0; c < 4; c++ {
+		switch mode {
+		case 1:
+			pred[c] = pix[l+c]
+		case 2:
+			pred[c] = pix[t+c]
+		case 12:
+			v := int(pix[l+c]) + int(pix[t+c]) - int(pix[tl+c])
+			if v < 0 {
+				v = 0
+			} else if v > 255 {
+				v = 255
+			}
+			pred[c] = byte(v)
+		}
+	}
+	return pred
+}
+
+func absResidual(r byte) int {
+	if r > 127 {
+		return 256 - int(r)
+	}
+	return int(r)
+}
+
+// writeImage writes an entropy coded image without color cache.
+// meta is only set for the main image which may carry meta prefix codes.
+func (w *vp8lBitWriter) writeImage(pix []byte, meta bool) {
+	w.writeBits(0, 1) // no color cache
+	if meta {
+		w.writeBits(0, 1) // no meta prefix codes
+	}
+
+	green := make([]int, vp8lGreenAlphabet)
+	red := make([]int, 256)
+	blue := make([]int, 256)
+	alpha := make([]int, 256)
+	for i := 0; i < len(pix); i += 4 {
+		red[pix[i]]++
+		green[pix[i+1]]++
+		blue[pix[i+2]]++
+		alpha[pix[i+3]]++
+	}
+
+	codes := []*vp8lCode{
+		w.writeCode(green),
+		w.writeCode(red),
+		w.writeCode(blue),
+		w.writeCode(alpha),
+		w.writeCode(make([]int, vp8lDistanceSymbol)),
+	}
+
+	for i := 0; i < len(pix); i += 4 {
+		w.writeSymbol(codes[0], int(pix[i+1]))
+		w.writeSymbol(codes[1], int(pix[i]))
+		w.writeSymbol(codes[2], int(pix[i+2]))
+		w.writeSymbol(codes[3], int(pix[i+3]))
+	}
+}
+
+// 허프만 코드, 심볼 하나뿐이면 비트를 쓰지 않음
+type vp8lCode struct {
+	lengths []int
+	codes   []uint32
+	single  bool
+}
+
+type vp8lBitWriter struct {
+	buf  []byte
+	acc  uint64
+	nacc uint
+}
+
+// 비트는 LSB부터 채움
+func (w *vp8lBitWriter) writeBits(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nacc
+	w.nacc += n
+	for w.nacc >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nacc -= 8
+	}
+}
+
+func (w *vp8lBitWriter) bytes() []byte {
+	if w.nacc > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nacc = 0, 0
+	}
+	return w.buf
+}
+
+// 디코더는 코드의 최상위 비트부터 읽기 때문에 뒤집어서 씀
+func (w *vp8lBitWriter) writeSymbol(c *vp8lCode, symbol int) {
+	if c.single {
+		return
+	}
+
+	n := c.lengths[symbol]
+	code := c.codes[symbol]
+	var rev uint32
+	for i := 0; i < n; i++ {
+		rev = rev<<1 | (code>>uint(i))&1
+	}
+	w.writeBits(rev, uint(n))
+}
+
+// writeCode writes the prefix code for the histogram and returns it for the pixel data.
+func (w *vp8lBitWriter) writeCode(histogram []int) *vp8lCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count != 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// 쓰이는 심볼이 2개 이하면 simple code
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+
+		w.writeBits(1, 1)
+		w.writeBits(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			w.writeBits(0, 1)
+			w.writeBits(uint32(used[0]), 1)
+		} else {
+			w.writeBits(1, 1)
+			w.writeBits(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			w.writeBits(uint32(used[1]), 8)
+		}
+
+		lengths := make([]int, len(histogram))
+		codes := make([]uint32, len(histogram))
+		for i, symbol := range used {
+			lengths[symbol] = len(used) - 1
+			codes[symbol] = uint32(i)
+		}
+		return &vp8lCode{lengths: lengths, codes: codes, single: len(used) == 1}
+	}
+
+	lengths := huffmanLengths(histogram, vp8lMaxCodeLength)
+
+	// 코드 길이 자체도 허프만 코드로 씀 (반복 코드 16~18은 사용하지 않음)
+	clHistogram := make([]int, vp8lNumCLCodes)
+	for _, l := range lengths {
+		clHistogram[l]++
+	}
+	clLengths := huffmanLengths(clHistogram, vp8lMaxCLCodeLen)
+	numCL := 4
+	for i := vp8lNumCLCodes - 1; i >= 4; i-- {
+		if clLengths[vp8lCodeLengthOrder[i]] != 0 {
+			numCL = i + 1
+			break
+		}
+	}
+
+	w.writeBits(0, 1) // normal code
+	w.writeBits(uint32(numCL-4), 4)
+	for i := 0; i < numCL; i++ {
+		w.writeBits(uint32(clLengths[vp8lCodeLengthOrder[i]]), 3)
+	}
+
+	w.writeBits(0, 1) // every symbol has a code length
+	clCode := canonicalCode(clLengths)
+	for _, l := range lengths {
+		w.writeSymbol(clCode, l)
+	}
+
+	return canonicalCode(lengths)
+}
+
+func canonicalCode(lengths []int) *vp8lCode {
+	var count [vp8lMaxCodeLength + 1]int
+	used := 0
+	for _, l := range lengths {
+		if l != 0 {
+			count[l]++
+			used++
+		}
+	}
+
+	var next [vp8lMaxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		code = (code + uint32(count[l-1])) << 1
+		next[l] = code
+	}
+	next[0] = 0
+
+	codes := make([]uint32, len(lengths))
+	for symbol, l := range lengths {
+		if l != 0 {
+			codes[symbol] = next[l]
+			next[l]++
+		}
+	}
+
+	return &vp8lCode{lengths: lengths, codes: codes, single: used == 1}
+}
+
+// huffmanLengths builds code lengths no longer than maxLength.
+// 길이가 넘치면 작은 빈도수를 끌어올려서 다시 만듦
+func huffmanLengths(histogram []int, maxLength int) []int {
+	lengths := make([]int, len(histogram))
+	for minCount := 1; ; minCount *= 2 {
+		h := &huffmanHeap{}
+		for symbol, count := range histogram {
+			if count == 0 {
+				continue
+			}
+			if count < minCount {
+				count = minCount
+			}
+			*h = append(*h, &huffmanNode{count: count, symbol: symbol})
+		}
+
+		if len(*h) == 1 {
+			lengths[(*h)[0].symbol] = 1
+			return lengths
+		}
+
+		heap.Init(h)
+		for h.Len() > 1 {
+			a := heap.Pop(h).(*huffmanNode)
+			b := heap.Pop(h).(*huffmanNode)
+			heap.Push(h, &huffmanNode{count: a.count + b.count, symbol: -1, left: a, right: b})
+		}
+
+		for i := range lengths {
+			lengths[i] = 0
+		}
+		if maxDepth := assignDepths((*h)[0], 0, lengths); maxDepth <= maxLength {
+			return lengths
+		}
+	}
+}
+
+func assignDepths(n *huffmanNode, depth int, lengths []int) int {
+	if n.left == nil {
+		lengths[n.symbol] = depth
+		return depth
+	}
+
+	l := assignDepths(n.left, depth+1, lengths)
+	r := assignDepths(n.right, depth+1, lengths)
+	if l > r {
+		return l
+	}
+	return r
+}
+
+type huffmanNode struct {
+	count       int
+	symbol      int
+	left, right *huffmanNode
+}
+
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].count == h[j].count {
+		return h[i].symbol < h[j].symbol
+	}
+	return h[i].count < h[j].count
+}
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
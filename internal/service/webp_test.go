@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	fills := []struct {
+		name string
+		fill func(x, y int) color.NRGBA
+	}{
+		{"solid", func(x, y int) color.NRGBA {
+			return color.NRGBA{R: 0x20, G: 0x80, B: 0xe0, A: 0xff}
+		}},
+		{"gradient", func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 7), G: uint8(y * 5), B: uint8(x + y), A: 0xff}
+		}},
+		{"noise", func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 0xff}
+		}},
+		{"alpha", func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 3), G: uint8(y * 3), B: 0x40, A: uint8(x*y + 1)}
+		}},
+		{"transparent noise", func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: uint8(rnd.Intn(256))}
+		}},
+		// 완전히 투명해도 색은 그대로 남아야 함
+		{"invisible colors", func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 11), G: uint8(y * 13), B: uint8(rnd.Intn(256)), A: 0}
+		}},
+		{"few colors", func(x, y int) color.NRGBA {
+			return [...]color.NRGBA{{0, 0, 0, 0xff}, {0xff, 0xff, 0xff, 0xff}, {0xff, 0, 0, 0x80}, {0, 0, 0, 0}}[(x/3+y)%4]
+		}},
+	}
+	// 타일(32px) 경계 앞뒤, 한 줄짜리, 아바타 크기(48, 96, 400)
+	sizes := [][2]int{{1, 1}, {1, 7}, {7, 1}, {3, 5}, {31, 33}, {33, 31}, {65, 17}, {96, 96}, {200, 1}, {1, 200}, {400, 400}}
+	for n := 1; n <= 70; n += 3 {
+		sizes = append(sizes, [2]int{n, 71 - n})
+	}
+
+	for _, f := range fills {
+		name, fill := f.name, f.fill
+		for _, size := range sizes {
+			w, h := size[0], size[1]
+			t.Run(fmt.Sprintf("%s %dx%d", name, w, h), func(t *testing.T) {
+				src := image.NewNRGBA(image.Rect(0, 0, w, h))
+				for y := 0; y < h; y++ {
+					for x := 0; x < w; x++ {
+						src.SetNRGBA(x, y, fill(x, y))
+					}
+				}
+
+				assertWebPRoundTrip(t, src)
+			})
+		}
+	}
+}
+
+// 원점이 (0, 0)이 아닌 이미지
+func TestEncodeWebPSubImage(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 0xff})
+		}
+	}
+
+	assertWebPRoundTrip(t, src.SubImage(image.Rect(5, 9, 28, 30)))
+}
+
+// gif 아바타처럼 팔레트나 흑백으로 읽힌 이미지
+func TestEncodeWebPColorModels(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	palette := color.Palette{color.Transparent, color.Black, color.White, color.NRGBA{R: 0xff, A: 0x80}}
+	for i := 0; i < 252; i++ {
+		palette = append(palette, color.NRGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 0xff})
+	}
+
+	for _, size := range [][2]int{{1, 1}, {17, 9}, {48, 48}, {130, 70}} {
+		w, h := size[0], size[1]
+		r := image.Rect(0, 0, w, h)
+		few, full := image.NewPaletted(r, palette[:4]), image.NewPaletted(r, palette)
+		gray, gray16 := image.NewGray(r), image.NewGray16(r)
+		rgba := image.NewRGBA(r)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				few.SetColorIndex(x, y, uint8((x+y)%4))
+				full.SetColorIndex(x, y, uint8(rnd.Intn(len(palette))))
+				gray.SetGray(x, y, color.Gray{Y: uint8(x*y + x)})
+				gray16.SetGray16(x, y, color.Gray16{Y: uint16(rnd.Intn(1 << 16))})
+				a := uint8(rnd.Intn(256))
+				rgba.SetRGBA(x, y, color.RGBA{R: a / 2, G: a / 3, B: a, A: a})
+			}
+		}
+
+		for name, img := range map[string]image.Image{"4 colors": few, "256 colors": full, "gray": gray, "gray16": gray16, "premultiplied": rgba} {
+			t.Run(fmt.Sprintf("%s %dx%d", name, w, h), func(t *testing.T) {
+				assertWebPRoundTrip(t, img)
+			})
+		}
+	}
+}
+
+// 크기와 픽셀을 마구 바꿔도 디코더가 같은 이미지를 읽어야 함
+// go test -run - -fuzz FuzzEncodeWebP ./internal/service
+func FuzzEncodeWebP(f *testing.F) {
+	f.Add(uint8(1), uint8(1), []byte{0, 0, 0, 0})
+	f.Add(uint8(33), uint8(2), []byte("gradient of a few bytes"))
+	f.Add(uint8(64), uint8(65), bytes.Repeat([]byte{0xff, 0, 0x80, 0x7f}, 100))
+	f.Fuzz(func(t *testing.T, w, h uint8, pix []byte) {
+		if w == 0 || h == 0 || len(pix) == 0 {
+			return
+		}
+
+		m := image.NewNRGBA(image.Rect(0, 0, int(w), int(h)))
+		for i := range m.Pix {
+			m.Pix[i] = pix[i%len(pix)] + uint8(i/len(pix))
+		}
+
+		assertWebPRoundTrip(t, m)
+	})
+}
+
+func assertWebPRoundTrip(t *testing.T, src image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, src); err != nil {
+		t.Fatalf("could not encode: %v", err)
+	}
+
+	got, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+
+	b := src.Bounds()
+	if got.Bounds().Dx() != b.Dx() || got.Bounds().Dy() != b.Dy() {
+		t.Fatalf("decoded size = %v, want %v", got.Bounds().Size(), b.Size())
+	}
+
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			want := color.NRGBAModel.Convert(src.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			have := color.NRGBAModel.Convert(got.At(got.Bounds().Min.X+x, got.Bounds().Min.Y+y)).(color.NRGBA)
+			if have != want {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, have, want)
+			}
+		}
+	}
+}
@@ -1,23 +1,60 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"sodam/internal/handler"
+	"sodam/internal/migrate"
+	"sodam/internal/rpc"
 	"sodam/internal/service"
+	"strconv"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/hako/branca"
 	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
 	var (
 		port        = env("PORT", "3000")
+		grpcPort    = env("GRPC_PORT", "3001")
+		grpcHost    = env("GRPC_HOST", "127.0.0.1")
+		grpcCert    = os.Getenv("GRPC_TLS_CERT")
+		grpcKey     = os.Getenv("GRPC_TLS_KEY")
 		origin      = env("ORIGIN", "http://localhost:"+port)
 		databaseURL = env("DATABASE_URL", "postgresql://root@127.0.0.1:26257/sodam?sslmode=disable")
 		brancaKey   = env("BRANACA_KEY", "supersecretkeyyoushouldnotcommit")
+		blobStore   = env("BLOB_STORE", "local")
+		avatarWebP  = env("AVATAR_WEBP", "false")
+		filterPath  = os.Getenv("CONTENT_FILTER")
+		rateLimit   = env("RATE_LIMIT", "true")
+		rateLimits  = os.Getenv("RATE_LIMITS")
+		redisURL    = os.Getenv("REDIS_URL")
+		trustProxy  = env("TRUST_PROXY", "false")
+		idempotent  = env("IDEMPOTENCY", "true")
+		pullAbove   = env("FANOUT_PULL_THRESHOLD", strconv.Itoa(service.DefaultPullThreshold))
+		debugAddr   = os.Getenv("DEBUG_ADDR")
+		runJobs     = env("JOBS", "true")
+		runOutbox   = env("OUTBOX", "true")
+		jobWorkers  = os.Getenv("JOB_WORKERS")
+		drainFor    = env("SHUTDOWN_TIMEOUT", "30s")
+		natsURL     = os.Getenv("NATS_URL")
+		natsSubject = env("NATS_SUBJECT", "sodam")
 	)
 
 	db, err := sql.Open("postgres", databaseURL)
@@ -32,14 +69,297 @@ func main() {
 		return
 	}
 
+	// go run main.go migrate up|down [n]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err = migrateDB(db, os.Args[2:]); err != nil {
+			log.Fatalf("could not migrate: %v\n", err)
+		}
+		return
+	}
+
 	cdc := branca.NewBranca(brancaKey)
 	cdc.SetTTL(uint32(service.TokenLifespan.Seconds()))
-	s := service.New(db, cdc, origin)
-	h := handler.New(s)
-	log.Printf("accepting connetions on port %d\n", port)
-	if err = http.ListenAndServe(":"+port, h); err != nil {
-		log.Fatalf("could not start server: %v\n", err)
+
+	var blobs service.BlobStore
+	switch blobStore {
+	case "local":
+		blobs = service.NewLocalBlobStore(filepath.Join("web", "static", "img"), origin+"/img")
+	case "s3":
+		signTTL, err := time.ParseDuration(env("S3_SIGN_TTL", "0s"))
+		if err != nil {
+			log.Fatalf("could not parse S3_SIGN_TTL: %v\n", err)
+			return
+		}
+
+		blobs = service.NewS3BlobStore(service.S3Config{
+			Endpoint:  env("S3_ENDPOINT", "http://localhost:9000"),
+			Region:    env("S3_REGION", "us-east-1"),
+			Bucket:    env("S3_BUCKET", "sodam"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			SignTTL:   signTTL,
+		})
+	default:
+		log.Fatalf("unknown blob store %q\n", blobStore)
+		return
+	}
+
+	var opts []service.Option
+	if ok, _ := strconv.ParseBool(avatarWebP); ok {
+		opts = append(opts, service.WithAvatarWebP())
+	}
+
+	threshold, err := strconv.Atoi(pullAbove)
+	if err != nil {
+		log.Fatalf("could not parse FANOUT_PULL_THRESHOLD: %v\n", err)
+		return
+	}
+
+	opts = append(opts, service.WithPullThreshold(threshold))
+
+	if filterPath != "" {
+		b, err := ioutil.ReadFile(filterPath)
+		if err != nil {
+			log.Fatalf("could not read content filter: %v\n", err)
+			return
+		}
+
+		f, err := service.ParseContentFilter(b)
+		if err != nil {
+			log.Fatalf("invalid content filter: %v\n", err)
+			return
+		}
+
+		opts = append(opts, service.WithContentFilter(f))
+	}
+
+	var handlerOpts []handler.Option
+	if ok, _ := strconv.ParseBool(rateLimit); ok {
+		limits := handler.DefaultRateLimits
+		if rateLimits != "" {
+			if limits, err = handler.ParseRateLimits(rateLimits); err != nil {
+				log.Fatalf("could not parse RATE_LIMITS: %v\n", err)
+				return
+			}
+		}
+
+		var store handler.RateLimitStore = handler.NewMemoryRateLimitStore()
+		if redisURL != "" {
+			if store, err = handler.NewRedisRateLimitStore(redisURL); err != nil {
+				log.Fatalf("could not create redis rate limit store: %v\n", err)
+				return
+			}
+		}
+
+		proxied, _ := strconv.ParseBool(trustProxy)
+		handlerOpts = append(handlerOpts, handler.WithRateLimit(limits, store, proxied))
+	}
+
+	if ok, _ := strconv.ParseBool(idempotent); ok {
+		var store handler.IdempotencyStore = handler.NewMemoryIdempotencyStore()
+		if redisURL != "" {
+			if store, err = handler.NewRedisIdempotencyStore(redisURL); err != nil {
+				log.Fatalf("could not create redis idempotency store: %v\n", err)
+				return
+			}
+		}
+
+		handlerOpts = append(handlerOpts, handler.WithIdempotency(store))
+	}
+
+	s := service.New(service.NewPGStore(db), cdc, origin, blobs, opts...)
+	h := handler.New(s, handlerOpts...)
+
+	// expvar 지표(트랜잭션 재시도 등), 외부에 열지 말 것
+	if debugAddr != "" {
+		go func() {
+			log.Printf("serving /debug/vars on %s\n", debugAddr)
+			if err := http.ListenAndServe(debugAddr, expvar.Handler()); err != nil {
+				log.Printf("could not start debug server: %v\n", err)
+			}
+		}()
 	}
+
+	//내부 도구용 gRPC, HTTP와 다른 포트
+	// 토큰이 오가니 TLS 없이는 루프백에서만
+	var grpcOpts []grpc.ServerOption
+	if grpcCert != "" || grpcKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(grpcCert, grpcKey)
+		if err != nil {
+			log.Fatalf("could not load grpc tls credentials: %v\n", err)
+			return
+		}
+
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	} else if ip := net.ParseIP(grpcHost); grpcHost != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		log.Fatalf("grpc on %s needs GRPC_TLS_CERT and GRPC_TLS_KEY\n", grpcHost)
+		return
+	}
+
+	grpcAddr := net.JoinHostPort(grpcHost, grpcPort)
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("could not listen on grpc port: %v\n", err)
+		return
+	}
+
+	grpcSrv := rpc.New(s, grpcOpts...)
+	go func() {
+		log.Printf("accepting grpc connetions on %s\n", grpcAddr)
+		if err := grpcSrv.Serve(lis); err != nil {
+			log.Fatalf("could not start grpc server: %v\n", err)
+		}
+	}()
+
+	drainTimeout, err := time.ParseDuration(drainFor)
+	if err != nil {
+		log.Fatalf("could not parse SHUTDOWN_TIMEOUT: %v\n", err)
+		return
+	}
+
+	// SIGINT, SIGTERM을 받으면 새 요청과 작업을 멈추고 진행 중인 것은 끝까지
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 아웃박스 이벤트는 NATS로, 없으면 릴레이를 돌리지 않고 outbox에 남겨둠
+	var pub service.EventPublisher
+	if natsURL != "" {
+		nats, err := service.NewNATSPublisher(natsURL, natsSubject)
+		if err != nil {
+			log.Fatalf("could not create nats publisher: %v\n", err)
+			return
+		}
+
+		defer nats.Close()
+		pub = nats
+	}
+
+	var wg sync.WaitGroup
+	if ok, _ := strconv.ParseBool(runJobs); ok {
+		workers, err := service.ParseJobWorkers(jobWorkers)
+		if err != nil {
+			log.Fatalf("could not parse JOB_WORKERS: %v\n", err)
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.RunJobs(ctx, workers); err != nil {
+				log.Printf("could not run jobs: %v\n", err)
+			}
+		}()
+	}
+
+	if ok, _ := strconv.ParseBool(runOutbox); ok && pub == nil {
+		log.Println("WARNING: NATS_URL is not set, events are kept in the outbox table and not published")
+	} else if ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.RunOutbox(ctx, pub)
+		}()
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(jobsDone)
+	}()
+
+	srv := &http.Server{Addr: ":" + port, Handler: h}
+	go func() {
+		log.Printf("accepting connetions on port %s\n", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("could not start server: %v\n", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("could not shutdown server: %v\n", err)
+	}
+
+	// 스트림(WatchTimeline ...)이 끝나지 않으면 시간이 지나고 강제로 닫음
+	grpcDone := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(grpcDone)
+	}()
+
+	select {
+	case <-grpcDone:
+	case <-shutdownCtx.Done():
+		grpcSrv.Stop()
+	}
+
+	// 끝나지 못한 작업과 이벤트는 임대 시간이 지나면 다시 실행, 발행됨
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		log.Println("could not drain jobs and events before the shutdown timeout")
+	}
+}
+
+func migrateDB(db *sql.DB, args []string) error {
+	m, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [n]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		mm, err := m.Up(ctx)
+		for _, mig := range mm {
+			log.Printf("applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(mm) == 0 {
+			log.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+
+		mm, err := m.Down(ctx, n)
+		for _, mig := range mm {
+			log.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		ss, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range ss {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	return fmt.Errorf("unknown migrate command %q", args[0])
 }
 
 func env(key, fallbackValue string) string {